@@ -8,6 +8,7 @@ import (
 	"log"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
@@ -21,9 +22,45 @@ type S3Getter struct {
 	folder     string
 }
 
-func New(cfg aws.Config, bucketName string, folder string) *S3Getter {
+// Option configures S3 client of Getter.
+type Option func(o *s3.Options)
+
+// WithEndpoint sends requests to S3 compatible server instead of AWS, sample: http://localhost:9000.
+func WithEndpoint(endpoint string) Option {
+	return func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+	}
+}
+
+// WithPathStyle addresses bucket in path instead of host name, most S3 compatible servers need it.
+func WithPathStyle() Option {
+	return func(o *s3.Options) {
+		o.UsePathStyle = true
+	}
+}
+
+// WithStaticCredentials signs requests with access key, session token is optional.
+func WithStaticCredentials(accessKeyID string, secretAccessKey string, sessionToken string) Option {
+	return func(o *s3.Options) {
+		o.Credentials = credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken)
+	}
+}
+
+// WithAnonymous sends unsigned requests, public buckets like Common Crawl one can be read without AWS account.
+func WithAnonymous() Option {
+	return func(o *s3.Options) {
+		o.Credentials = aws.AnonymousCredentials{}
+	}
+}
+
+func New(cfg aws.Config, bucketName string, folder string, opts ...Option) *S3Getter {
+	optFns := make([]func(*s3.Options), 0, len(opts))
+	for _, opt := range opts {
+		optFns = append(optFns, opt)
+	}
+
 	return &S3Getter{
-		client:     s3.NewFromConfig(cfg),
+		client:     s3.NewFromConfig(cfg, optFns...),
 		bucketName: bucketName,
 		folder:     folder,
 	}
@@ -1,9 +1,16 @@
 package aws_test
 
 import (
+	"cmp"
+	"errors"
+	"os"
+	"strings"
 	"testing"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/dharnitski/cc-hosts/access/aws"
 	"github.com/dharnitski/cc-hosts/vertices"
 
@@ -11,9 +18,77 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// Integration tests are skipped unless environment names the server, local run with MinIO:
+//
+//	docker run -d -p 9000:9000 minio/minio server /data
+//	CC_HOSTS_TEST_S3_ENDPOINT=http://localhost:9000 go test ./access/aws
+const (
+	// endpointEnv is URL of S3 compatible server
+	endpointEnv = "CC_HOSTS_TEST_S3_ENDPOINT"
+	// accessKeyEnv and secretKeyEnv are credentials of the server, MinIO defaults are used when not set
+	accessKeyEnv = "CC_HOSTS_TEST_S3_ACCESS_KEY_ID"
+	secretKeyEnv = "CC_HOSTS_TEST_S3_SECRET_ACCESS_KEY"
+	// awsEnv enables tests reading project bucket with AWS credentials
+	awsEnv = "CC_HOSTS_TEST_AWS"
+	// publicEnv enables tests reading public Common Crawl bucket without credentials
+	publicEnv = "CC_HOSTS_TEST_PUBLIC"
+
+	testBucket = "cc-hosts-test"
+)
+
+func TestS3Getter_Integration(t *testing.T) {
+	endpoint := os.Getenv(endpointEnv)
+	if endpoint == "" {
+		t.Skipf("%s is not set", endpointEnv)
+	}
+
+	t.Parallel()
+
+	opts := []aws.Option{
+		aws.WithEndpoint(endpoint),
+		aws.WithPathStyle(),
+		aws.WithStaticCredentials(cmp.Or(os.Getenv(accessKeyEnv), "minioadmin"), cmp.Or(os.Getenv(secretKeyEnv), "minioadmin"), ""),
+	}
+	cfg := awssdk.Config{Region: "us-east-1"}
+
+	optFns := make([]func(*s3.Options), 0, len(opts))
+	for _, opt := range opts {
+		optFns = append(optFns, opt)
+	}
+
+	client := s3.NewFromConfig(cfg, optFns...)
+
+	_, err := client.CreateBucket(t.Context(), &s3.CreateBucketInput{Bucket: awssdk.String(testBucket)})
+
+	var owned *types.BucketAlreadyOwnedByYou
+	if err != nil && !errors.As(err, &owned) {
+		require.NoError(t, err)
+	}
+
+	_, err = client.PutObject(t.Context(), &s3.PutObjectInput{
+		Bucket: awssdk.String(testBucket),
+		Key:    awssdk.String(vertices.Folder + "/part-00000.txt"),
+		Body:   strings.NewReader(testContent),
+	})
+	require.NoError(t, err)
+
+	getter := aws.New(cfg, testBucket, vertices.Folder, opts...)
+
+	buffer, err := getter.Get(t.Context(), "part-00000.txt", 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "com.a", string(buffer))
+
+	_, err = getter.Get(t.Context(), "missed.txt", 0, 1)
+	require.Error(t, err)
+}
+
 func TestOffsetBucket(t *testing.T) {
-	t.Skip()
+	if os.Getenv(awsEnv) == "" {
+		t.Skipf("%s is not set", awsEnv)
+	}
+
 	t.Parallel()
+
 	cfg, err := config.LoadDefaultConfig(t.Context())
 	require.NoError(t, err)
 
@@ -24,3 +99,20 @@ func TestOffsetBucket(t *testing.T) {
 
 	assert.Equal(t, "88296	ae.regards", string(buffer))
 }
+
+func TestCommonCrawlBucket(t *testing.T) {
+	if os.Getenv(publicEnv) == "" {
+		t.Skipf("%s is not set", publicEnv)
+	}
+
+	t.Parallel()
+
+	cfg := awssdk.Config{Region: "us-east-1"}
+	getter := aws.New(cfg, "commoncrawl", "projects/hyperlinkgraph/cc-main-2024-oct-nov-dec/host/vertices", aws.WithAnonymous())
+
+	buffer, err := getter.Get(t.Context(), "part-00000-4ba7987d-67a0-4f7d-b410-1d92df440699-c000.txt.gz", 0, 2)
+	require.NoError(t, err)
+
+	// gzip magic number
+	assert.Equal(t, []byte{0x1f, 0x8b}, buffer)
+}
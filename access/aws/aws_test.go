@@ -1,9 +1,115 @@
 package aws_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/dharnitski/cc-hosts/access"
 	"github.com/dharnitski/cc-hosts/access/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Verify that S3Getter implements Getter interface.
 var _ access.Getter = (*aws.S3Getter)(nil)
+
+const testContent = "0\tcom.a\n1\tcom.b\n"
+
+// fakeS3 serves testContent ranges of any key and records requests.
+type fakeS3 struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.mu.Unlock()
+
+	var start, end int
+
+	_, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+	if err != nil || end >= len(testContent) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+
+		return
+	}
+
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(testContent)))
+	w.Header().Set("Content-Length", fmt.Sprint(end-start+1))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write([]byte(testContent[start : end+1]))
+}
+
+func (f *fakeS3) last() *http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests[len(f.requests)-1]
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	t.Helper()
+
+	fake := &fakeS3{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server.URL
+}
+
+func TestS3Getter_Endpoint(t *testing.T) {
+	t.Parallel()
+
+	fake, endpoint := newFakeS3(t)
+	cfg := awssdk.Config{Region: "us-east-1"}
+
+	getter := aws.New(cfg, "bucket", "vertices", aws.WithEndpoint(endpoint), aws.WithPathStyle(),
+		aws.WithStaticCredentials("AKIDTEST", "secret", ""))
+
+	buffer, err := getter.Get(t.Context(), "part-00000.txt", 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "com.a", string(buffer))
+
+	request := fake.last()
+	assert.Equal(t, "/bucket/vertices/part-00000.txt", request.URL.Path)
+	assert.Equal(t, "bytes=2-6", request.Header.Get("Range"))
+	assert.Contains(t, request.Header.Get("Authorization"), "Credential=AKIDTEST/")
+}
+
+func TestS3Getter_Anonymous(t *testing.T) {
+	t.Parallel()
+
+	fake, endpoint := newFakeS3(t)
+	cfg := awssdk.Config{Region: "us-east-1"}
+
+	getter := aws.New(cfg, "bucket", "edges", aws.WithEndpoint(endpoint), aws.WithPathStyle(), aws.WithAnonymous())
+
+	buffer, err := getter.Get(t.Context(), "part-00000.txt", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "0", string(buffer))
+	assert.Empty(t, fake.last().Header.Get("Authorization"))
+}
+
+func TestS3Getter_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, endpoint := newFakeS3(t)
+	cfg := awssdk.Config{Region: "us-east-1", RetryMaxAttempts: 1}
+
+	getter := aws.New(cfg, "bucket", "edges", aws.WithEndpoint(endpoint), aws.WithPathStyle(), aws.WithAnonymous())
+
+	_, err := getter.Get(t.Context(), "part-00000.txt", -1, 1)
+	require.Error(t, err)
+
+	_, err = getter.Get(t.Context(), "part-00000.txt", 0, 0)
+	require.Error(t, err)
+
+	_, err = getter.Get(t.Context(), "part-00000.txt", 10, len(testContent))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "bucket")
+}
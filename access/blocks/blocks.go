@@ -0,0 +1,242 @@
+package blocks
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ext is extension of block compressed file, name without it is the name of data file.
+// Blocks are independent gzip members, so file is valid gzip file as well.
+const Ext = ".bgz"
+
+// Block maps start of uncompressed chunk to start of its compressed data.
+type Block struct {
+	// offset in uncompressed data file
+	Offset int
+	// offset in compressed file
+	Compressed int
+}
+
+// Index has blocks of every data file sorted by offset.
+// The last block of file is its end, it has uncompressed and compressed sizes of file.
+type Index struct {
+	files map[string][]Block
+}
+
+// Append adds blocks of data file.
+func (x *Index) Append(file string, blocks []Block) {
+	if x.files == nil {
+		x.files = make(map[string][]Block)
+	}
+
+	x.files[file] = append(x.files[file], blocks...)
+}
+
+// Len returns number of data files.
+func (x *Index) Len() int {
+	return len(x.files)
+}
+
+// find returns range of blocks covering uncompressed range.
+func (x *Index) find(file string, offset int, length int) ([]Block, error) {
+	blocks, ok := x.files[file]
+	if !ok {
+		return nil, fmt.Errorf("file %q is not in block index", file)
+	}
+
+	end := offset + length
+	if offset < 0 || length < 0 || len(blocks) == 0 || end > blocks[len(blocks)-1].Offset {
+		return nil, fmt.Errorf("range %d-%d is out of file %q", offset, end, file)
+	}
+
+	// the last block starting at or before offset
+	first := sort.Search(len(blocks), func(i int) bool { return blocks[i].Offset > offset }) - 1
+	// the first block starting at or after end
+	last := sort.Search(len(blocks), func(i int) bool { return blocks[i].Offset >= end })
+
+	return blocks[first : last+1], nil
+}
+
+// Validate checks that offsets grow within every file.
+func (x *Index) Validate() error {
+	if x.Len() == 0 {
+		return errors.New("no blocks found")
+	}
+
+	for file, blocks := range x.files {
+		if blocks[0] != (Block{}) {
+			return fmt.Errorf("file %q does not start at zero offset", file)
+		}
+
+		for i := 1; i < len(blocks); i++ {
+			if blocks[i].Offset <= blocks[i-1].Offset || blocks[i].Compressed <= blocks[i-1].Compressed {
+				return fmt.Errorf("offset goes down in file %q: %v, previous %v", file, blocks[i], blocks[i-1])
+			}
+		}
+	}
+
+	return nil
+}
+
+// Save writes index in "file \t offset \t compressed offset" format.
+func (x *Index) Save(fileName string) error {
+	file, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	writer := bufio.NewWriter(file)
+
+	names := make([]string, 0, len(x.files))
+	for name := range x.files {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, block := range x.files[name] {
+			_, err := fmt.Fprintf(writer, "%s\t%d\t%d\n", name, block.Offset, block.Compressed)
+			if err != nil {
+				return fmt.Errorf("error writing to file %q: %w", fileName, err)
+			}
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing to file %q: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// Load reads index saved by Save.
+func (x *Index) Load(fileName string) error {
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	x.files = make(map[string][]Block)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, block, err := loadBlock(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("error loading block: %w", err)
+		}
+
+		x.files[name] = append(x.files[name], block)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	return x.Validate()
+}
+
+func loadBlock(line string) (string, Block, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) != 3 {
+		return "", Block{}, fmt.Errorf("invalid line: %s, %d parts", line, len(parts))
+	}
+
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", Block{}, fmt.Errorf("invalid offset: %s", parts[1])
+	}
+
+	compressed, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", Block{}, fmt.Errorf("invalid compressed offset: %s", parts[2])
+	}
+
+	return parts[0], Block{Offset: offset, Compressed: compressed}, nil
+}
+
+// Writer compresses data file into independent blocks.
+// Data written between two Flush calls is one block.
+type Writer struct {
+	writer  io.Writer
+	gzip    *gzip.Writer
+	pending bytes.Buffer
+	block   bytes.Buffer
+	// current end of uncompressed and compressed data
+	end    Block
+	blocks []Block
+}
+
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{writer: writer, gzip: gzip.NewWriter(nil)}
+}
+
+// Write buffers uncompressed data of the current block.
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.pending.Write(p)
+}
+
+// Flush compresses buffered data as a block, empty block is not written.
+func (w *Writer) Flush() error {
+	if w.pending.Len() == 0 {
+		return nil
+	}
+
+	w.block.Reset()
+	w.gzip.Reset(&w.block)
+
+	_, err := w.gzip.Write(w.pending.Bytes())
+	if err != nil {
+		return fmt.Errorf("error compressing block: %w", err)
+	}
+
+	err = w.gzip.Close()
+	if err != nil {
+		return fmt.Errorf("error compressing block: %w", err)
+	}
+
+	_, err = w.writer.Write(w.block.Bytes())
+	if err != nil {
+		return fmt.Errorf("error writing block: %w", err)
+	}
+
+	w.blocks = append(w.blocks, w.end)
+	w.end.Offset += w.pending.Len()
+	w.end.Compressed += w.block.Len()
+	w.pending.Reset()
+
+	return nil
+}
+
+// Close flushes the last block, it does not close underlying writer.
+func (w *Writer) Close() error {
+	return w.Flush()
+}
+
+// Blocks returns written blocks followed by the end of file.
+func (w *Writer) Blocks() []Block {
+	return append(slices.Clone(w.blocks), w.end)
+}
@@ -0,0 +1,100 @@
+package blocks_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/blocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	writer := blocks.NewWriter(&buffer)
+
+	_, err := writer.Write([]byte("0\tcom.a\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	// empty block is skipped
+	require.NoError(t, writer.Flush())
+
+	_, err = writer.Write([]byte("1\tcom.b\n2\tcom.c\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	result := writer.Blocks()
+	require.Len(t, result, 3)
+	assert.Equal(t, blocks.Block{}, result[0])
+	assert.Equal(t, 8, result[1].Offset)
+	assert.Equal(t, 24, result[2].Offset)
+	assert.Equal(t, buffer.Len(), result[2].Compressed)
+
+	// blocks are gzip members
+	reader, err := gzip.NewReader(&buffer)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "0\tcom.a\n1\tcom.b\n2\tcom.c\n", string(data))
+}
+
+func TestIndex_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	index := blocks.Index{}
+	index.Append("part-1.txt", []blocks.Block{{}, {Offset: 100, Compressed: 40}, {Offset: 150, Compressed: 70}})
+	index.Append("part-0.txt", []blocks.Block{{}, {Offset: 10, Compressed: 30}})
+	require.NoError(t, index.Validate())
+
+	fileName := filepath.Join(t.TempDir(), "blocks.txt")
+	require.NoError(t, index.Save(fileName))
+
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, "part-0.txt\t0\t0\npart-0.txt\t10\t30\npart-1.txt\t0\t0\npart-1.txt\t100\t40\npart-1.txt\t150\t70\n", string(data))
+
+	loaded := blocks.Index{}
+	require.NoError(t, loaded.Load(fileName))
+	assert.Equal(t, index, loaded)
+}
+
+func TestIndex_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := [][]blocks.Block{
+		{{Offset: 1}},
+		{{}, {Offset: 10, Compressed: 5}, {Offset: 10, Compressed: 8}},
+		{{}, {Offset: 10, Compressed: 5}, {Offset: 20, Compressed: 5}},
+	}
+
+	for _, test := range tests {
+		index := blocks.Index{}
+		index.Append("part-0.txt", test)
+		require.Error(t, index.Validate(), test)
+	}
+
+	require.Error(t, (&blocks.Index{}).Validate())
+}
+
+func TestIndex_LoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{"part-0.txt\t0\n", "part-0.txt\tx\t0\n", "part-0.txt\t0\tx\n", ""} {
+		fileName := filepath.Join(t.TempDir(), "blocks.txt")
+		require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+
+		index := blocks.Index{}
+		require.Error(t, index.Load(fileName), content)
+	}
+
+	index := blocks.Index{}
+	require.Error(t, index.Load(filepath.Join(t.TempDir(), "missed.txt")))
+}
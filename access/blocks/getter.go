@@ -0,0 +1,57 @@
+package blocks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/dharnitski/cc-hosts/access"
+)
+
+// Getter reads ranges of data files from block compressed files of underlying Getter.
+// Blocks covering the range are fetched with one request and decompressed.
+type Getter struct {
+	getter access.Getter
+	index  *Index
+}
+
+func NewGetter(getter access.Getter, index *Index) *Getter {
+	return &Getter{getter: getter, index: index}
+}
+
+func (g *Getter) Get(ctx context.Context, fileName string, offset int, length int) ([]byte, error) {
+	blocks, err := g.index.find(fileName, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty range is read like from plain file
+	if length == 0 {
+		return []byte{}, nil
+	}
+
+	first, last := blocks[0], blocks[len(blocks)-1]
+
+	compressed, err := g.getter.Get(ctx, fileName+Ext, first.Compressed, last.Compressed-first.Compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing %q: %w", fileName, err)
+	}
+
+	data := make([]byte, last.Offset-first.Offset)
+
+	_, err = io.ReadFull(reader, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing %q: %w", fileName, err)
+	}
+
+	start := offset - first.Offset
+
+	return data[start : start+length], nil
+}
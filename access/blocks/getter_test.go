@@ -0,0 +1,111 @@
+package blocks_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access"
+	"github.com/dharnitski/cc-hosts/access/blocks"
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ access.Getter = (*blocks.Getter)(nil)
+
+// writeBlocks saves lines as block compressed file, a block has blockLines lines.
+func writeBlocks(t *testing.T, folder string, name string, lines []string, blockLines int) []blocks.Block {
+	t.Helper()
+
+	out, err := os.Create(filepath.Join(folder, name+blocks.Ext))
+	require.NoError(t, err)
+
+	writer := blocks.NewWriter(out)
+
+	for i, line := range lines {
+		if i%blockLines == 0 {
+			require.NoError(t, writer.Flush())
+		}
+
+		_, err := writer.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, out.Close())
+
+	return writer.Blocks()
+}
+
+func TestGetter(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	lines := make([]string, 0, 100)
+
+	for i := range 100 {
+		lines = append(lines, fmt.Sprintf("%d\tcom.example%d", i, i))
+	}
+
+	content := strings.Join(lines, "\n") + "\n"
+
+	index := &blocks.Index{}
+	index.Append("part-0.txt", writeBlocks(t, folder, "part-0.txt", lines, 7))
+	require.NoError(t, index.Validate())
+
+	getter := blocks.NewGetter(file.NewGetter(folder), index)
+
+	tests := []struct {
+		offset int
+		length int
+	}{
+		// the first line
+		{0, len(lines[0])},
+		// inside one block
+		{20, 10},
+		// across several blocks
+		{100, 500},
+		// the whole file
+		{0, len(content)},
+		// the last byte
+		{len(content) - 1, 1},
+		// empty range
+		{10, 0},
+	}
+
+	for _, test := range tests {
+		buffer, err := getter.Get(t.Context(), "part-0.txt", test.offset, test.length)
+		require.NoError(t, err, test)
+		assert.Equal(t, content[test.offset:test.offset+test.length], string(buffer), test)
+	}
+}
+
+func TestGetter_Errors(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+
+	index := &blocks.Index{}
+	index.Append("part-0.txt", writeBlocks(t, folder, "part-0.txt", []string{"0\tcom.a", "1\tcom.b"}, 1))
+	index.Append("missed.txt", []blocks.Block{{}, {Offset: 10, Compressed: 10}})
+
+	getter := blocks.NewGetter(file.NewGetter(folder), index)
+
+	for _, test := range []struct {
+		file   string
+		offset int
+		length int
+	}{
+		{"part-0.txt", -1, 2},
+		{"part-0.txt", 0, -1},
+		{"part-0.txt", 10, 10},
+		{"other.txt", 0, 1},
+		{"missed.txt", 0, 1},
+	} {
+		_, err := getter.Get(t.Context(), test.file, test.offset, test.length)
+		require.Error(t, err, test)
+	}
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// GzipExt is extension of gzip compressed files published by Common Crawl.
+const GzipExt = ".gz"
+
+// DataName returns name of decompressed file, offsets point into decompressed data.
+func DataName(fileName string) string {
+	return strings.TrimSuffix(fileName, GzipExt)
+}
+
+// ReadDir returns names of files in folder sorted by name.
+// Both compressed and decompressed copy of the same file in folder is an error.
+func ReadDir(folder string) ([]string, error) {
+	// entries are sorted by filename
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %q: %w", folder, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	seen := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if other, ok := seen[DataName(name)]; ok {
+			return nil, fmt.Errorf("duplicated files %q and %q in %q", other, name, folder)
+		}
+
+		seen[DataName(name)] = name
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// gzipFile closes decompressor and file.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+
+	if closeErr := g.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Open opens file for reading, files with .gz extension are decompressed on the fly.
+func Open(fileName string) (io.ReadCloser, error) {
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	if !strings.HasSuffix(fileName, GzipExt) {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+
+		return nil, fmt.Errorf("error opening gzip file %q: %w", fileName, err)
+	}
+
+	return &gzipFile{Reader: reader, file: file}, nil
+}
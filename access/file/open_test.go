@@ -0,0 +1,73 @@
+package file_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGzip(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, os.WriteFile(fileName, buffer.Bytes(), 0o644))
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	writeGzip(t, filepath.Join(folder, "part-00000.txt.gz"), "0\taaa.a\n")
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00001.txt"), []byte("1\taaa.b\n"), 0o644))
+
+	for name, expected := range map[string]string{"part-00000.txt.gz": "0\taaa.a\n", "part-00001.txt": "1\taaa.b\n"} {
+		reader, err := file.Open(filepath.Join(folder, name))
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, expected, string(data))
+	}
+
+	// plain file with .gz extension
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "bad.txt.gz"), []byte("1\taaa.b\n"), 0o644))
+
+	_, err := file.Open(filepath.Join(folder, "bad.txt.gz"))
+	require.Error(t, err)
+
+	_, err = file.Open(filepath.Join(folder, "missed.txt"))
+	require.Error(t, err)
+}
+
+func TestReadDir(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(folder, "nested"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00001.txt"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00000.txt.gz"), nil, 0o644))
+
+	names, err := file.ReadDir(folder)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"part-00000.txt.gz", "part-00001.txt"}, names)
+	assert.Equal(t, "part-00000.txt", file.DataName(names[0]))
+	assert.Equal(t, "part-00001.txt", file.DataName(names[1]))
+
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00000.txt"), nil, 0o644))
+
+	_, err = file.ReadDir(folder)
+	require.ErrorContains(t, err, "duplicated")
+}
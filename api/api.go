@@ -0,0 +1,395 @@
+// Package api serves search requests independent of transport.
+// Lambda and HTTP server convert their requests into Request and write Response back.
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+)
+
+const (
+	// MaxBatchSize is default max number of domains in one batch request
+	MaxBatchSize = 1_000
+	// MaxPathDepth is max number of links in path request
+	MaxPathDepth = 5
+	// MaxPathLimit is max number of neighbours followed from every host in path request
+	MaxPathLimit = 100
+	// MaxPathFrontier is max number of hosts expanded on one level of path request
+	MaxPathFrontier = 100
+
+	contentTypeJSON = "application/json"
+	contentTypeCSV  = "text/csv"
+)
+
+// OpenAPI is OpenAPI document describing all routes.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// Request is a transport independent request.
+// Params has path and query parameters by name, query parameter can't override path parameter.
+// Headers are keyed by canonical header name, sample: If-None-Match.
+type Request struct {
+	Params  map[string]string
+	Headers map[string]string
+	Body    []byte
+}
+
+// Response is a transport independent response.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	Body        []byte
+}
+
+// HandlerFunc serves one route, error is returned together with response for server failures.
+type HandlerFunc func(ctx context.Context, request Request) (Response, error)
+
+// API serves searchers of snapshots registry.
+type API struct {
+	registry *snapshots.Registry
+	limits   Limits
+}
+
+// Limits bound requests served by API.
+type Limits struct {
+	// MaxResults is max number of neighbours in one direction
+	MaxResults int
+	// MaxBatchSize is max number of domains in one batch request
+	MaxBatchSize int
+	// CacheMaxAge is cache age in seconds of responses for default snapshot
+	CacheMaxAge int
+	// PinnedCacheMaxAge is cache age in seconds of responses for requested snapshots
+	PinnedCacheMaxAge int
+}
+
+// DefaultLimits returns limits compiled into packages.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxResults:        edges.DefaultMaxSize,
+		MaxBatchSize:      MaxBatchSize,
+		CacheMaxAge:       DefaultMaxAge,
+		PinnedCacheMaxAge: PinnedMaxAge,
+	}
+}
+
+// Option configures API.
+type Option func(a *API)
+
+// WithLimits replaces DefaultLimits of API.
+func WithLimits(limits Limits) Option {
+	return func(a *API) {
+		a.limits = limits
+	}
+}
+
+func New(registry *snapshots.Registry, opts ...Option) *API {
+	a := &API{registry: registry, limits: DefaultLimits()}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Registry returns snapshots served by API.
+func (a *API) Registry() *snapshots.Registry {
+	return a.registry
+}
+
+// BatchRequest is the body of POST /domains request.
+type BatchRequest struct {
+	Domains  []string `json:"domains"`
+	Mutual   bool     `json:"mutual"`
+	Snapshot string   `json:"snapshot,omitempty"`
+	// optional search options, see search.SearchOptions
+	Direction       search.Direction `json:"direction,omitempty"`
+	Order           search.Order     `json:"order,omitempty"`
+	Limit           int              `json:"limit,omitempty"`
+	Ranks           []string         `json:"ranks,omitempty"`
+	Include         []string         `json:"include,omitempty"`
+	Exclude         []string         `json:"exclude,omitempty"`
+	ExcludeInternal bool             `json:"exclude_internal,omitempty"`
+}
+
+func (b *BatchRequest) options() search.SearchOptions {
+	return search.SearchOptions{
+		Mutual:          b.Mutual,
+		Direction:       b.Direction,
+		Order:           b.Order,
+		Limit:           b.Limit,
+		Ranks:           b.Ranks,
+		Include:         b.Include,
+		Exclude:         b.Exclude,
+		ExcludeInternal: b.ExcludeInternal,
+	}
+}
+
+// ParseBatchRequest decodes and validates batch request body, batch can have up to maxSize domains.
+func ParseBatchRequest(body []byte, maxSize int) (*BatchRequest, error) {
+	var batch BatchRequest
+
+	err := json.Unmarshal(body, &batch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if len(batch.Domains) == 0 {
+		return nil, errors.New("no domains in request")
+	}
+
+	if len(batch.Domains) > maxSize {
+		return nil, fmt.Errorf("too many domains: %d, max %d", len(batch.Domains), maxSize)
+	}
+
+	if batch.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit: %d", batch.Limit)
+	}
+
+	return &batch, nil
+}
+
+func jsonResponse(value any) (Response, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return ErrorResponse(http.StatusInternalServerError, CodeInternal, "internal server error"), err
+	}
+
+	return Response{StatusCode: http.StatusOK, ContentType: contentTypeJSON, Body: body}, nil
+}
+
+// DomainResult is the response of domain request, NextCursor is set when more neighbours are available.
+type DomainResult struct {
+	*search.Result
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Domain serves GET /domain/{domain}?snapshot=name&limit=n&direction=out&cursor=c&filter=gov,-gov.nasa.
+// Unknown host is answered with 404.
+func (a *API) Domain(ctx context.Context, request Request) (Response, error) {
+	domain, err := Host(request.Params["domain"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	opts, page, err := searchOptions(request, a.limits.MaxResults)
+	if err != nil {
+		return badRequest(CodeBadRequest, err)
+	}
+
+	searcher, err := a.registry.Get(ctx, request.Params["snapshot"])
+	if err != nil {
+		return failure(err)
+	}
+
+	result, err := searcher.GetTargetsWithOptions(ctx, domain, opts)
+	if err != nil {
+		return failure(err)
+	}
+
+	if result == nil {
+		return notFound(fmt.Sprintf("host %q is not found", domain))
+	}
+
+	next := page.apply(result)
+
+	return jsonResponse(DomainResult{Result: result, NextCursor: next})
+}
+
+// Batch serves POST /domains with BatchRequest body.
+func (a *API) Batch(ctx context.Context, request Request) (Response, error) {
+	batch, err := ParseBatchRequest(request.Body, a.limits.MaxBatchSize)
+	if err != nil {
+		return badRequest(CodeBadRequest, err)
+	}
+
+	searcher, err := a.registry.Get(ctx, batch.Snapshot)
+	if err != nil {
+		return failure(err)
+	}
+
+	results, err := searcher.GetTargetsBatch(ctx, batch.Domains, batch.options())
+	if err != nil {
+		return failure(err)
+	}
+
+	return jsonResponse(results)
+}
+
+// Diff serves GET /diff/{domain}?from=snapshot&to=snapshot.
+// Host missed in both snapshots is answered with 404.
+func (a *API) Diff(ctx context.Context, request Request) (Response, error) {
+	domain, err := Host(request.Params["domain"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	from := request.Params["from"]
+	to := request.Params["to"]
+
+	if from == "" || to == "" {
+		return badRequest(CodeBadRequest, errors.New("from and to parameters are required"))
+	}
+
+	fromSearcher, err := a.registry.Get(ctx, from)
+	if err != nil {
+		return failure(err)
+	}
+
+	toSearcher, err := a.registry.Get(ctx, to)
+	if err != nil {
+		return failure(err)
+	}
+
+	result, err := search.Diff(ctx, fromSearcher, toSearcher, domain, search.SearchOptions{})
+	if err != nil {
+		return failure(err)
+	}
+
+	if result == nil {
+		return notFound(fmt.Sprintf("host %q is not found in %q and %q", domain, from, to))
+	}
+
+	result.From = from
+	result.To = to
+
+	return jsonResponse(result)
+}
+
+// History serves GET /history/{domain}?snapshots=a,b&format=csv.
+// All snapshots are used when snapshots parameter is not set, JSON is default format.
+func (a *API) History(ctx context.Context, request Request) (Response, error) {
+	domain, err := Host(request.Params["domain"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	format := request.Params["format"]
+	if format != "" && format != "json" && format != "csv" {
+		return badRequest(CodeBadRequest, fmt.Errorf("invalid format parameter: %q, expected json or csv", format))
+	}
+
+	names := SplitList(request.Params["snapshots"])
+
+	for _, name := range names {
+		_, err := a.registry.Get(ctx, name)
+		if err != nil {
+			return failure(err)
+		}
+	}
+
+	history, err := a.registry.History(ctx, domain, names)
+	if err != nil {
+		return failure(err)
+	}
+
+	if format != "csv" {
+		return jsonResponse(history)
+	}
+
+	body := strings.Builder{}
+
+	err = snapshots.WriteCSVHeader(&body)
+	if err != nil {
+		return failure(err)
+	}
+
+	err = history.WriteCSVRows(&body)
+	if err != nil {
+		return failure(err)
+	}
+
+	return Response{StatusCode: http.StatusOK, ContentType: contentTypeCSV, Body: []byte(body.String())}, nil
+}
+
+// SubdomainsResult is the response of subdomains request.
+type SubdomainsResult struct {
+	Target     string   `json:"target"`
+	Subdomains []string `json:"subdomains"`
+}
+
+// Subdomains serves GET /subdomains/{domain}?snapshot=name&limit=n.
+func (a *API) Subdomains(ctx context.Context, request Request) (Response, error) {
+	domain, err := Host(request.Params["domain"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	limit, err := intParam(request, "limit", 0)
+	if err != nil {
+		return badRequest(CodeBadRequest, err)
+	}
+
+	searcher, err := a.registry.Get(ctx, request.Params["snapshot"])
+	if err != nil {
+		return failure(err)
+	}
+
+	hosts, err := searcher.Subdomains(ctx, domain, limit)
+	if err != nil {
+		return failure(err)
+	}
+
+	return jsonResponse(SubdomainsResult{Target: domain, Subdomains: hosts})
+}
+
+// PathResult is the response of path request, Path is empty when hosts are not connected within depth links.
+type PathResult struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Depth int      `json:"depth"`
+	Path  []string `json:"path"`
+}
+
+// Path serves GET /path/{from}/{to}?snapshot=name&depth=n&limit=n.
+func (a *API) Path(ctx context.Context, request Request) (Response, error) {
+	from, err := Host(request.Params["from"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	to, err := Host(request.Params["to"])
+	if err != nil {
+		return badRequest(CodeInvalidHost, err)
+	}
+
+	depth, err := intParam(request, "depth", search.DefaultPathDepth)
+	if err != nil || depth > MaxPathDepth {
+		return badRequest(CodeBadRequest, fmt.Errorf("invalid depth parameter, max %d", MaxPathDepth))
+	}
+
+	// path request is unauthenticated, every level reads neighbours of up to MaxPathFrontier hosts
+	limit, err := intParam(request, "limit", MaxPathLimit)
+	if err != nil || limit > MaxPathLimit {
+		return badRequest(CodeBadRequest, fmt.Errorf("invalid limit parameter, max %d", MaxPathLimit))
+	}
+
+	searcher, err := a.registry.Get(ctx, request.Params["snapshot"])
+	if err != nil {
+		return failure(err)
+	}
+
+	limits := search.PathLimits{Depth: depth, Frontier: MaxPathFrontier}
+
+	path, err := searcher.Path(ctx, from, to, limits, search.SearchOptions{Limit: limit})
+	if err != nil {
+		return failure(err)
+	}
+
+	if path == nil {
+		path = []string{}
+	}
+
+	return jsonResponse(PathResult{From: from, To: to, Depth: depth, Path: path})
+}
@@ -0,0 +1,353 @@
+package api_test
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFile = "part-00000.txt"
+
+// newTestSnapshot writes graph with offsets into temporary folder, vertice ID is the index in domains.
+// Links have to be sorted by source and target.
+func newTestSnapshot(t *testing.T, domains []string, links [][2]int) snapshots.Snapshot {
+	t.Helper()
+
+	root := t.TempDir()
+	offsetsFolder := filepath.Join(root, offsets.Folder)
+	require.NoError(t, os.MkdirAll(offsetsFolder, 0o755))
+
+	lines := make([]string, 0, len(domains))
+	for id, domain := range domains {
+		lines = append(lines, fmt.Sprintf("%d\t%s", id, domain))
+	}
+
+	size := writeLines(t, filepath.Join(root, vertices.Folder), lines)
+	vOffsets := vertices.Offsets{}
+	vOffsets.Append([]vertices.Offset{
+		vertices.NewOffset(0, domains[0], 0, testFile),
+		vertices.NewOffset(size, domains[len(domains)-1], len(domains)-1, testFile),
+	})
+	require.NoError(t, vOffsets.Save(filepath.Join(offsetsFolder, offsets.VerticesOffsetsFile)))
+
+	reversed := make([][2]int, 0, len(links))
+	for _, link := range links {
+		reversed = append(reversed, [2]int{link[1], link[0]})
+	}
+
+	writeEdges(t, filepath.Join(root, edges.EdgesFolder), filepath.Join(offsetsFolder, offsets.EdgesOffsetsFile), links)
+	writeEdges(t, filepath.Join(root, edges.EdgesReversedFolder), filepath.Join(offsetsFolder, offsets.EdgesReversedOffsetFile),
+		sortLinks(reversed))
+
+	return snapshots.Snapshot{Location: root, Offsets: offsetsFolder}
+}
+
+func sortLinks(links [][2]int) [][2]int {
+	slices.SortFunc(links, func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+
+	return links
+}
+
+func writeEdges(t *testing.T, folder string, offsetsFile string, links [][2]int) {
+	t.Helper()
+
+	lines := make([]string, 0, len(links))
+	for _, link := range links {
+		lines = append(lines, fmt.Sprintf("%d\t%d", link[0], link[1]))
+	}
+
+	size := writeLines(t, folder, lines)
+	eOffsets := edges.Offsets{}
+	eOffsets.Append([]edges.Offset{
+		edges.NewOffset(0, strconv.Itoa(links[0][0]), testFile),
+		edges.NewOffset(size, strconv.Itoa(links[len(links)-1][0]), testFile),
+	})
+	require.NoError(t, eOffsets.Save(offsetsFile))
+}
+
+func writeLines(t *testing.T, folder string, lines []string) int {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+
+	content := strings.Join(lines, "\n") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(folder, testFile), []byte(content), 0o644))
+
+	return len(content)
+}
+
+// newTestAPI serves two snapshots: in "old" a.com links b.com, in "new" a.com and b.com link blog.a.com, a.com links b.com.
+func newTestAPI(t *testing.T, opts ...api.Option) *api.API {
+	t.Helper()
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default: "new",
+		Snapshots: map[string]snapshots.Snapshot{
+			"old": newTestSnapshot(t, []string{"com.a", "com.b"}, [][2]int{{0, 1}}),
+			"new": newTestSnapshot(t, []string{"com.a", "com.a.blog", "com.b"}, [][2]int{{0, 1}, {0, 2}, {2, 1}}),
+		},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	return api.New(registry, opts...)
+}
+
+func TestAPI_Limits(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t, api.WithLimits(api.Limits{MaxResults: 1, MaxBatchSize: 1, CacheMaxAge: 7, PinnedCacheMaxAge: 9}))
+
+	response, err := a.Batch(t.Context(), api.Request{Body: []byte(`{"domains":["a.com","b.com"]}`)})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = a.Domain(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "limit": "2"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	route, ok := a.Find(http.MethodGet, "/domain/{domain}")
+	require.True(t, ok)
+
+	response, err = route.Handler(t.Context(), api.Request{Params: map[string]string{"domain": "a.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, "public, max-age=7", response.Headers["Cache-Control"])
+	assert.NotEmpty(t, response.Headers["ETag"])
+}
+
+func TestAPI_Routes(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	route, ok := a.Find(http.MethodGet, "/path/{from}/{to}")
+	require.True(t, ok)
+	assert.Equal(t, []string{"from", "to"}, route.Params())
+
+	_, ok = a.Find(http.MethodPost, "/domain/{domain}")
+	assert.False(t, ok)
+
+	// every route is documented
+	var document struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+
+	require.NoError(t, json.Unmarshal(api.OpenAPI, &document))
+
+	for _, route := range a.Routes() {
+		assert.Contains(t, document.Paths[route.Pattern], strings.ToLower(route.Method), route.Pattern)
+	}
+}
+
+func TestAPI_Domain(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	response, err := a.Domain(t.Context(), api.Request{Params: map[string]string{"domain": "a.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.ContentType)
+
+	var result search.Result
+
+	require.NoError(t, json.Unmarshal(response.Body, &result))
+	assert.Equal(t, []string{"b.com", "blog.a.com"}, result.Out)
+
+	response, err = a.Domain(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "snapshot": "old"}})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(response.Body, &result))
+	assert.Equal(t, []string{"b.com"}, result.Out)
+
+	response, err = a.Domain(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "snapshot": "missed"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = a.Domain(t.Context(), api.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestAPI_DomainErrors(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	tests := []struct {
+		params map[string]string
+		status int
+		code   string
+	}{
+		{params: map[string]string{"domain": "x.com"}, status: http.StatusNotFound, code: api.CodeNotFound},
+		{params: map[string]string{"domain": "a b"}, status: http.StatusBadRequest, code: api.CodeInvalidHost},
+		{params: map[string]string{"domain": "a.com", "limit": "-1"}, status: http.StatusBadRequest, code: api.CodeBadRequest},
+		{params: map[string]string{"domain": "a.com", "snapshot": "x"}, status: http.StatusBadRequest, code: api.CodeUnknownSnapshot},
+	}
+
+	for _, test := range tests {
+		response, err := a.Domain(t.Context(), api.Request{Params: test.params})
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", response.ContentType)
+
+		var body api.Error
+
+		require.NoError(t, json.Unmarshal(response.Body, &body))
+		assert.Equal(t, test.status, body.Status, test.params)
+		assert.Equal(t, test.status, response.StatusCode, test.params)
+		assert.Equal(t, test.code, body.Code, test.params)
+		assert.NotEmpty(t, body.Message)
+	}
+}
+
+func TestAPI_DomainPages(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	var result api.DomainResult
+
+	params := map[string]string{"domain": "A.com", "limit": "1", "direction": "out"}
+	response, err := a.Domain(t.Context(), api.Request{Params: params})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(response.Body, &result))
+	assert.Equal(t, "a.com", result.Target)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+	assert.Empty(t, result.In)
+	require.NotEmpty(t, result.NextCursor)
+
+	params["cursor"] = result.NextCursor
+	result = api.DomainResult{}
+	response, err = a.Domain(t.Context(), api.Request{Params: params})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(response.Body, &result))
+	assert.Equal(t, []string{"blog.a.com"}, result.Out)
+	assert.Empty(t, result.NextCursor)
+
+	params = map[string]string{"domain": "a.com", "filter": "com,-com.a"}
+	result = api.DomainResult{}
+	response, err = a.Domain(t.Context(), api.Request{Params: params})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(response.Body, &result))
+	assert.Equal(t, []string{"b.com"}, result.Out)
+}
+
+func TestAPI_Batch(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	body := `{"domains":["a.com","x.com"],"direction":"in","snapshot":"new"}`
+	response, err := a.Batch(t.Context(), api.Request{Body: []byte(body)})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var results []search.BatchResult
+
+	require.NoError(t, json.Unmarshal(response.Body, &results))
+	require.Len(t, results, 2)
+	assert.Empty(t, results[0].Result.Out)
+	assert.Nil(t, results[1].Result)
+
+	tests := []string{
+		`{`,
+		`{"domains":[]}`,
+		`{"domains":["a.com"],"limit":-1}`,
+		`{"domains":["a.com"],"direction":"up"}`,
+		`{"domains":["a.com"],"order":"pagerank"}`,
+	}
+
+	for _, body := range tests {
+		response, err := a.Batch(t.Context(), api.Request{Body: []byte(body)})
+		require.NoError(t, err, body)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, body)
+	}
+}
+
+func TestAPI_DiffHistory(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	response, err := a.Diff(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "from": "old", "to": "new"}})
+	require.NoError(t, err)
+
+	var diff search.DiffResult
+
+	require.NoError(t, json.Unmarshal(response.Body, &diff))
+	assert.Equal(t, []string{"blog.a.com"}, diff.Out.Added)
+	assert.Equal(t, "old", diff.From)
+
+	response, err = a.Diff(t.Context(), api.Request{Params: map[string]string{"domain": "x.com", "from": "old", "to": "new"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	params := map[string]string{"domain": "blog.a.com", "snapshots": "old, new,", "format": "csv"}
+	response, err = a.History(t.Context(), api.Request{Params: params})
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", response.ContentType)
+	assert.Equal(t, "host,snapshot,present,id,out_degree,in_degree\n"+
+		"blog.a.com,old,false,,0,0\n"+
+		"blog.a.com,new,true,1,0,2\n", string(response.Body))
+
+	// snapshots have no release dates, so their time order is unknown
+	response, err = a.History(t.Context(), api.Request{Params: map[string]string{"domain": "blog.a.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestAPI_Subdomains(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	response, err := a.Subdomains(t.Context(), api.Request{Params: map[string]string{"domain": "a.com"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"target":"a.com","subdomains":["blog.a.com"]}`, string(response.Body))
+
+	response, err = a.Subdomains(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "limit": "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestAPI_Path(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+
+	response, err := a.Path(t.Context(), api.Request{Params: map[string]string{"from": "b.com", "to": "blog.a.com"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"from":"b.com","to":"blog.a.com","depth":3,"path":["b.com","blog.a.com"]}`, string(response.Body))
+
+	response, err = a.Path(t.Context(), api.Request{Params: map[string]string{"from": "b.com", "to": "a.com", "depth": "1"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"from":"b.com","to":"a.com","depth":1,"path":[]}`, string(response.Body))
+
+	for _, depth := range []string{"0", "6", "x"} {
+		response, err = a.Path(t.Context(), api.Request{Params: map[string]string{"from": "a.com", "to": "b.com", "depth": depth}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, depth)
+	}
+
+	for _, limit := range []string{"0", "101", "x"} {
+		response, err = a.Path(t.Context(), api.Request{Params: map[string]string{"from": "a.com", "to": "b.com", "limit": limit}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, limit)
+	}
+}
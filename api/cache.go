@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Default cache ages, they are changed with Limits.
+const (
+	// PinnedMaxAge is cache age in seconds of responses for requested snapshots, indexed snapshot never changes
+	PinnedMaxAge = 7 * 24 * 60 * 60
+	// DefaultMaxAge is cache age in seconds of responses for default snapshot, default can move to a new release
+	DefaultMaxAge = 5 * 60
+)
+
+// cached adds ETag and Cache-Control headers to successful and not found responses of GET route.
+// Snapshots are immutable, so ETag is built from route, parameters and snapshots serving them
+// and request with matching If-None-Match is answered with 304 without search.
+func (a *API) cached(pattern string, next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request Request) (Response, error) {
+		etag := a.etag(pattern, request)
+		maxAge := a.limits.CacheMaxAge
+
+		if pinned(request) {
+			maxAge = a.limits.PinnedCacheMaxAge
+		}
+
+		headers := map[string]string{
+			"ETag":          etag,
+			"Cache-Control": fmt.Sprintf("public, max-age=%d", maxAge),
+		}
+
+		if matches(request.Headers["If-None-Match"], etag) {
+			return Response{StatusCode: http.StatusNotModified, Headers: headers}, nil
+		}
+
+		response, err := next(ctx, request)
+
+		if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+			headers = map[string]string{"Cache-Control": "no-store"}
+		}
+
+		if response.Headers == nil {
+			response.Headers = make(map[string]string, len(headers))
+		}
+
+		maps.Copy(response.Headers, headers)
+
+		return response, err
+	}
+}
+
+// pinned reports whether request names its snapshots instead of default or all snapshots.
+func pinned(request Request) bool {
+	return request.Params["snapshot"] != "" || request.Params["from"] != "" && request.Params["to"] != "" ||
+		request.Params["snapshots"] != ""
+}
+
+// etag hashes route, sorted parameters, default and all snapshot names.
+// Weak validator is used because timings in body differ between equal responses.
+func (a *API) etag(pattern string, request Request) string {
+	hash := sha256.New()
+
+	_, _ = fmt.Fprintf(hash, "%s\n%s\n%s\n", pattern, a.registry.Default(), strings.Join(a.registry.Names(), ","))
+
+	for _, name := range slices.Sorted(maps.Keys(request.Params)) {
+		_, _ = fmt.Fprintf(hash, "%s=%s\n", name, request.Params[name])
+	}
+
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// matches reports whether If-None-Match header has etag, weak comparison is used.
+func matches(header string, etag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" && strings.TrimPrefix(value, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Cache(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAPI(t)
+	route, ok := a.Find(http.MethodGet, "/domain/{domain}")
+	require.True(t, ok)
+
+	response, err := route.Handler(t.Context(), api.Request{Params: map[string]string{"domain": "a.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "public, max-age="+strconv.Itoa(api.DefaultMaxAge), response.Headers["Cache-Control"])
+
+	etag := response.Headers["ETag"]
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+	// equal request gets the same etag and is not searched again
+	response, err = route.Handler(t.Context(), api.Request{
+		Params:  map[string]string{"domain": "a.com"},
+		Headers: map[string]string{"If-None-Match": `"other", ` + etag},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+	assert.Empty(t, response.Body)
+	assert.Equal(t, etag, response.Headers["ETag"])
+
+	// snapshot is part of etag and pinned snapshot is cached longer
+	response, err = route.Handler(t.Context(), api.Request{Params: map[string]string{"domain": "a.com", "snapshot": "new"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, etag, response.Headers["ETag"])
+	assert.Equal(t, "public, max-age="+strconv.Itoa(api.PinnedMaxAge), response.Headers["Cache-Control"])
+
+	// not found is cached, client errors are not
+	response, err = route.Handler(t.Context(), api.Request{Params: map[string]string{"domain": "x.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.NotEmpty(t, response.Headers["ETag"])
+
+	response, err = route.Handler(t.Context(), api.Request{Params: map[string]string{"domain": "a com"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, map[string]string{"Cache-Control": "no-store"}, response.Headers)
+
+	// batch is not cached
+	route, ok = a.Find(http.MethodPost, "/domains")
+	require.True(t, ok)
+
+	response, err = route.Handler(t.Context(), api.Request{Body: []byte(`{"domains":["a.com"]}`)})
+	require.NoError(t, err)
+	assert.Empty(t, response.Headers)
+}
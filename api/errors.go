@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+)
+
+// Error codes, clients should check code instead of message.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidHost      = "invalid_host"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnknownSnapshot  = "unknown_snapshot"
+	CodeTimeout          = "timeout"
+	CodeInternal         = "internal"
+)
+
+// Error is the body of all error responses.
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse creates JSON error response.
+func ErrorResponse(statusCode int, code string, message string) Response {
+	body, err := json.Marshal(Error{Status: statusCode, Code: code, Message: message})
+	if err != nil {
+		// Error always can be encoded
+		panic(err)
+	}
+
+	return Response{StatusCode: statusCode, ContentType: contentTypeJSON, Body: body}
+}
+
+func badRequest(code string, err error) (Response, error) {
+	return ErrorResponse(http.StatusBadRequest, code, err.Error()), nil
+}
+
+// failure converts error into response, unknown snapshots and invalid options are client errors.
+// Server errors are not exposed to client, the error is returned for logging.
+func failure(err error) (Response, error) {
+	switch {
+	case errors.Is(err, snapshots.ErrUnknownSnapshot):
+		return badRequest(CodeUnknownSnapshot, err)
+	case errors.Is(err, search.ErrInvalidOptions), errors.Is(err, snapshots.ErrNoReleaseOrder):
+		return badRequest(CodeBadRequest, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorResponse(http.StatusGatewayTimeout, CodeTimeout, "search timed out"), err
+	default:
+		return ErrorResponse(http.StatusInternalServerError, CodeInternal, "internal server error"), err
+	}
+}
+
+func notFound(message string) (Response, error) {
+	return ErrorResponse(http.StatusNotFound, CodeNotFound, message), nil
+}
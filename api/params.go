@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/search"
+)
+
+const (
+	// max length of host name
+	maxHostLength = 253
+	// max length of one label of host name
+	maxLabelLength = 63
+	// cursor prefix keeps cursors opaque and versioned
+	cursorPrefix = "o1:"
+)
+
+// Host validates host in browser format and returns it in lower case, trailing dot is dropped.
+// Labels can have letters, digits, hyphens and underscores, IP addresses pass as numeric labels.
+func Host(value string) (string, error) {
+	host := strings.TrimSuffix(strings.ToLower(value), ".")
+
+	if host == "" {
+		return "", errors.New("host is empty")
+	}
+
+	if len(host) > maxHostLength {
+		return "", fmt.Errorf("host is longer than %d characters", maxHostLength)
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > maxLabelLength {
+			return "", fmt.Errorf("invalid host: %q", value)
+		}
+
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+				return "", fmt.Errorf("invalid host: %q", value)
+			}
+		}
+	}
+
+	return host, nil
+}
+
+// intParam parses optional positive integer parameter, fallback is returned when parameter is empty.
+func intParam(request Request, name string, fallback int) (int, error) {
+	value := request.Params[name]
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s parameter: %q", name, value)
+	}
+
+	return n, nil
+}
+
+// page is a window of neighbours requested with limit and cursor.
+type page struct {
+	offset int
+	limit  int
+	// max number of neighbours in all pages
+	max int
+}
+
+// searchOptions parses limit, direction, cursor and filter query parameters.
+// Filter is comma separated list of reversed domain prefixes, prefix starting with - is excluded,
+// sample: gov,-gov.nasa keeps gov hosts except nasa.gov and its subdomains.
+// Pages end at maxResults neighbours.
+func searchOptions(request Request, maxResults int) (search.SearchOptions, page, error) {
+	var opts search.SearchOptions
+
+	limit, err := intParam(request, "limit", maxResults)
+	if err != nil {
+		return opts, page{}, err
+	}
+
+	if limit > maxResults {
+		return opts, page{}, fmt.Errorf("invalid limit parameter: max %d", maxResults)
+	}
+
+	opts.Direction = search.Direction(request.Params["direction"])
+	if opts.Direction != search.DirectionIn && opts.Direction != search.DirectionOut && opts.Direction != search.DirectionBoth {
+		return opts, page{}, fmt.Errorf("invalid direction parameter: %q, expected in or out", opts.Direction)
+	}
+
+	offset, err := decodeCursor(request.Params["cursor"], maxResults)
+	if err != nil {
+		return opts, page{}, err
+	}
+
+	for _, prefix := range strings.Split(request.Params["filter"], ",") {
+		prefix = strings.TrimSpace(prefix)
+
+		if excluded, ok := strings.CutPrefix(prefix, "-"); ok {
+			opts.Exclude = append(opts.Exclude, excluded)
+		} else if prefix != "" {
+			opts.Include = append(opts.Include, prefix)
+		}
+	}
+
+	// one more neighbour tells whether the next page exists
+	opts.Limit = min(offset+limit+1, maxResults)
+
+	return opts, page{offset: offset, limit: limit, max: maxResults}, nil
+}
+
+// apply cuts page out of result neighbours in both directions and returns cursor of the next page.
+// Cursor is empty on the last page, pages end at max neighbours.
+func (p page) apply(result *search.Result) string {
+	end := p.offset + p.limit
+	more := len(result.Out) > end || len(result.In) > end
+
+	result.Out = window(result.Out, p.offset, end)
+	result.In = window(result.In, p.offset, end)
+
+	if !more || end >= p.max {
+		return ""
+	}
+
+	return encodeCursor(end)
+}
+
+func window(items []string, from int, to int) []string {
+	if from >= len(items) {
+		return []string{}
+	}
+
+	return items[from:min(to, len(items))]
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns offset of cursor, empty cursor is the first page.
+// Offset has to be less than maxResults.
+func decodeCursor(cursor string, maxResults int) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor parameter: %q", cursor)
+	}
+
+	value, ok := strings.CutPrefix(string(data), cursorPrefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor parameter: %q", cursor)
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 || offset >= maxResults {
+		return 0, fmt.Errorf("invalid cursor parameter: %q", cursor)
+	}
+
+	return offset, nil
+}
+
+// SplitList splits comma separated list, items are trimmed and empty items are skipped.
+func SplitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
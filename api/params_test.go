@@ -0,0 +1,101 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHost(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]string{
+		"example.com":           "example.com",
+		"WWW.Example.COM.":      "www.example.com",
+		"xn--80ak6aa92e.com":    "xn--80ak6aa92e.com",
+		"_dmarc.example.com":    "_dmarc.example.com",
+		"192.168.0.1":           "192.168.0.1",
+		"gov":                   "gov",
+		"my-site.example.co.uk": "my-site.example.co.uk",
+	}
+
+	for value, expected := range valid {
+		host, err := Host(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, host)
+	}
+
+	for _, value := range []string{"", ".", "a..com", ".a.com", "a b.com", "a/b", "a.com:80", "ünicode.com"} {
+		_, err := Host(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, p, err := searchOptions(Request{Params: map[string]string{}}, edges.DefaultMaxSize)
+	require.NoError(t, err)
+	assert.Equal(t, search.SearchOptions{Limit: edges.DefaultMaxSize}, opts)
+	assert.Equal(t, page{offset: 0, limit: edges.DefaultMaxSize, max: edges.DefaultMaxSize}, p)
+
+	params := map[string]string{"limit": "10", "direction": "in", "cursor": encodeCursor(20), "filter": "gov, -gov.nasa,,com"}
+	opts, p, err = searchOptions(Request{Params: params}, edges.DefaultMaxSize)
+	require.NoError(t, err)
+	assert.Equal(t, search.SearchOptions{
+		Direction: search.DirectionIn,
+		Limit:     31,
+		Include:   []string{"gov", "com"},
+		Exclude:   []string{"gov.nasa"},
+	}, opts)
+	assert.Equal(t, page{offset: 20, limit: 10, max: edges.DefaultMaxSize}, p)
+
+	for _, params := range []map[string]string{
+		{"limit": "0"},
+		{"limit": "5001"},
+		{"direction": "up"},
+		{"cursor": "20"},
+		{"cursor": encodeCursor(edges.DefaultMaxSize)},
+	} {
+		_, _, err := searchOptions(Request{Params: params}, edges.DefaultMaxSize)
+		require.Error(t, err, params)
+	}
+
+	// limit and cursor are bounded by max results of API
+	_, _, err = searchOptions(Request{Params: map[string]string{"limit": "11"}}, 10)
+	require.Error(t, err)
+
+	_, _, err = searchOptions(Request{Params: map[string]string{"cursor": encodeCursor(10)}}, 10)
+	require.Error(t, err)
+}
+
+func TestPage_Apply(t *testing.T) {
+	t.Parallel()
+
+	result := &search.Result{Out: []string{"a", "b", "c", "d"}, In: []string{"a"}}
+	next := page{offset: 0, limit: 2, max: edges.DefaultMaxSize}.apply(result)
+	assert.Equal(t, []string{"a", "b"}, result.Out)
+	assert.Equal(t, []string{"a"}, result.In)
+
+	offset, err := decodeCursor(next, edges.DefaultMaxSize)
+	require.NoError(t, err)
+	assert.Equal(t, 2, offset)
+
+	result = &search.Result{Out: []string{"a", "b", "c", "d"}, In: []string{"a"}}
+	next = page{offset: 2, limit: 2, max: edges.DefaultMaxSize}.apply(result)
+	assert.Equal(t, []string{"c", "d"}, result.Out)
+	assert.Equal(t, []string{}, result.In)
+	assert.Empty(t, next)
+}
+
+func TestSplitList(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"a", "b"}, SplitList("a, b,"))
+	assert.Equal(t, []string{"a"}, SplitList(" a "))
+	assert.Nil(t, SplitList(""))
+	assert.Nil(t, SplitList(" , "))
+}
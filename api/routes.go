@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Route binds handler to method and path pattern.
+// Pattern uses {name} placeholders for path parameters, the syntax of API Gateway resources and http.ServeMux.
+type Route struct {
+	Method  string
+	Pattern string
+	Handler HandlerFunc
+}
+
+// Params returns names of path parameters in pattern order.
+func (r Route) Params() []string {
+	names := []string{}
+
+	for _, part := range strings.Split(r.Pattern, "/") {
+		if name, ok := strings.CutPrefix(part, "{"); ok {
+			names = append(names, strings.TrimSuffix(name, "}"))
+		}
+	}
+
+	return names
+}
+
+// Routes returns all routes of API, GET routes are cacheable.
+func (a *API) Routes() []Route {
+	routes := []Route{
+		{Method: http.MethodGet, Pattern: "/domain/{domain}", Handler: a.Domain},
+		{Method: http.MethodPost, Pattern: "/domains", Handler: a.Batch},
+		{Method: http.MethodGet, Pattern: "/diff/{domain}", Handler: a.Diff},
+		{Method: http.MethodGet, Pattern: "/history/{domain}", Handler: a.History},
+		{Method: http.MethodGet, Pattern: "/subdomains/{domain}", Handler: a.Subdomains},
+		{Method: http.MethodGet, Pattern: "/path/{from}/{to}", Handler: a.Path},
+	}
+
+	for i, route := range routes {
+		if route.Method == http.MethodGet {
+			routes[i].Handler = a.cached(route.Pattern, route.Handler)
+		}
+	}
+
+	return routes
+}
+
+// Find returns route for method and pattern, API Gateway passes pattern as request resource.
+func (a *API) Find(method string, pattern string) (Route, bool) {
+	for _, route := range a.Routes() {
+		if route.Method == method && route.Pattern == pattern {
+			return route, true
+		}
+	}
+
+	return Route{}, false
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+// adjacency converts TSV edges and reversed edges into adjacency format.
+// Converted edges and their offsets are saved next to copy of vertices offsets and manifest,
+// so output folders are location and offsets of a new snapshot.
+func main() {
+	dataFolder := flag.String("data", "data", "folder with edges and edges_reversed in TSV format")
+	offsetsFolder := flag.String("offsets", offsets.Folder, "offsets folder of data, vertices offsets and manifest are copied from it")
+	outFolder := flag.String("out", "data/adjacency", "folder for converted edges")
+	outOffsets := flag.String("out-offsets", "offsets/adjacency", "folder for offsets of converted edges")
+	flag.Parse()
+
+	err := run(*dataFolder, *offsetsFolder, *outFolder, *outOffsets)
+	if err != nil {
+		log.Fatal("Adjacency Error: ", err)
+	}
+}
+
+func run(dataFolder, offsetsFolder, outFolder, outOffsets string) error {
+	manifest, err := vertices.LoadManifest(path.Join(offsetsFolder, offsets.ManifestFile))
+	if err != nil {
+		return err
+	}
+
+	if manifest.Compression != "" {
+		return fmt.Errorf("%s compressed data can not be converted", manifest.Compression)
+	}
+
+	err = os.MkdirAll(outOffsets, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating folder %q: %w", outOffsets, err)
+	}
+
+	for folder, offsetsFile := range map[string]string{
+		edges.EdgesFolder:         offsets.EdgesOffsetsFile,
+		edges.EdgesReversedFolder: offsets.EdgesReversedOffsetFile,
+	} {
+		err := convertFolder(path.Join(dataFolder, folder), path.Join(outFolder, folder), path.Join(outOffsets, offsetsFile))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = copyFile(path.Join(offsetsFolder, offsets.VerticesOffsetsFile), path.Join(outOffsets, offsets.VerticesOffsetsFile))
+	if err != nil {
+		return err
+	}
+
+	manifest.EdgesFormat = string(edges.FormatAdjacency)
+	saveFile := path.Join(outOffsets, offsets.ManifestFile)
+
+	err = manifest.Save(saveFile)
+	if err != nil {
+		return fmt.Errorf("error saving manifest: %w", err)
+	}
+
+	log.Printf("Saved manifest to %s\n", saveFile)
+
+	return nil
+}
+
+func convertFolder(edgesFolder, outFolder, offsetsFile string) error {
+	log.Printf("Loading  Edges from %s folder\n", edgesFolder)
+	// names are sorted by filename
+	names, err := file.ReadDir(edgesFolder)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(outFolder, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating folder %q: %w", outFolder, err)
+	}
+
+	results := edges.Offsets{}
+
+	for _, name := range names {
+		inFile := filepath.Join(edgesFolder, name)
+		outFile := filepath.Join(outFolder, edges.AdjacencyName(file.DataName(name)))
+		log.Printf("Converting Edges file: %s\n", inFile)
+
+		items, err := convertFile(inFile, outFile)
+		if err != nil {
+			return fmt.Errorf("error processing file %q: %w", inFile, err)
+		}
+
+		results.Append(items)
+	}
+
+	if results.Len() > 0 {
+		err := results.Validate()
+		if err != nil {
+			return fmt.Errorf("error validating offsets: %w", err)
+		}
+
+		err = results.Save(offsetsFile)
+		if err != nil {
+			return fmt.Errorf("error saving offsets: %w", err)
+		}
+
+		log.Printf("Saved %d edges offsets to %s\n", results.Len(), offsetsFile)
+	}
+
+	return nil
+}
+
+func convertFile(inFile, outFile string) ([]edges.Offset, error) {
+	reader, err := file.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", inFile, err)
+		}
+	}()
+
+	out, err := os.Create(outFile) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("error creating file %q: %w", outFile, err)
+	}
+
+	defer func() {
+		if err := out.Close(); err != nil {
+			log.Printf("error closing file %s: %v", outFile, err)
+		}
+	}()
+
+	writer := edges.NewAdjacencyWriter(out, filepath.Base(outFile))
+
+	err = convert(reader, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer.Offsets(), nil
+}
+
+// convert writes every run of TSV edges as one record, targets of run are sorted by numeric value.
+func convert(reader io.Reader, writer *edges.AdjacencyWriter) error {
+	scanner := bufio.NewScanner(reader)
+	from := -1
+	targets := []int{}
+	lines := 0
+
+	for scanner.Scan() {
+		lines++
+		line := scanner.Text()
+
+		edge, err := edges.LoadEdge(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lines, err)
+		}
+
+		id, err := strconv.Atoi(edge.FromID())
+		if err != nil || id < 0 {
+			return fmt.Errorf("line %d: invalid ID: %q", lines, edge.FromID())
+		}
+
+		target, err := strconv.Atoi(edge.ToID())
+		if err != nil || target < 0 {
+			return fmt.Errorf("line %d: invalid ID: %q", lines, edge.ToID())
+		}
+
+		if id != from && len(targets) > 0 {
+			err := writeRun(writer, from, targets)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lines, err)
+			}
+
+			targets = targets[:0]
+		}
+
+		from = id
+		targets = append(targets, target)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	if len(targets) > 0 {
+		err := writeRun(writer, from, targets)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+func writeRun(writer *edges.AdjacencyWriter, from int, targets []int) error {
+	slices.Sort(targets)
+
+	return writer.Write(from, targets)
+}
+
+func copyFile(from, to string) error {
+	data, err := os.ReadFile(from) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error reading file %q: %w", from, err)
+	}
+
+	err = os.WriteFile(to, data, 0o644) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error writing file %q: %w", to, err)
+	}
+
+	return nil
+}
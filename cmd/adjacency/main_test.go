@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+func writeFile(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0o755))
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := t.TempDir()
+	offsetsFolder := t.TempDir()
+	outFolder := t.TempDir()
+	outOffsets := filepath.Join(t.TempDir(), "adjacency")
+
+	writeFile(t, filepath.Join(dataFolder, edges.EdgesFolder, "part-00000.txt"), "75\t229\n75\t63\n77\t47\n84\t63\n")
+	writeFile(t, filepath.Join(dataFolder, edges.EdgesFolder, "part-00001.txt"), "90\t47\n111\t138\n")
+	writeFile(t, filepath.Join(dataFolder, edges.EdgesReversedFolder, "part-00000.txt"), "47\t77\n47\t90\n63\t75\n")
+	writeFile(t, filepath.Join(offsetsFolder, offsets.VerticesOffsetsFile), "0\t1\tpart-00000.txt\n")
+	writeFile(t, filepath.Join(offsetsFolder, offsets.ManifestFile), `{"graph": "host"}`)
+
+	require.NoError(t, run(dataFolder, offsetsFolder, outFolder, outOffsets))
+
+	_, err := os.Stat(filepath.Join(outFolder, edges.EdgesFolder, "part-00001.adj"))
+	require.NoError(t, err)
+
+	manifest, err := vertices.LoadManifest(filepath.Join(outOffsets, offsets.ManifestFile))
+	require.NoError(t, err)
+	assert.Equal(t, string(edges.FormatAdjacency), manifest.EdgesFormat)
+	assert.Equal(t, vertices.GraphHost, manifest.Graph)
+
+	data, err := os.ReadFile(filepath.Join(outOffsets, offsets.VerticesOffsetsFile))
+	require.NoError(t, err)
+	assert.Equal(t, "0\t1\tpart-00000.txt\n", string(data))
+
+	load := func(folder string, offsetsFile string) *edges.Edges {
+		items := edges.Offsets{}
+		require.NoError(t, items.Load(filepath.Join(outOffsets, offsetsFile)))
+
+		result, err := edges.NewFormatEdges(file.NewGetter(filepath.Join(outFolder, folder)), items, edges.FormatAdjacency)
+		require.NoError(t, err)
+
+		return result
+	}
+
+	out := load(edges.EdgesFolder, offsets.EdgesOffsetsFile)
+	in := load(edges.EdgesReversedFolder, offsets.EdgesReversedOffsetFile)
+
+	targets, err := out.Get(context.Background(), "75")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"229", "63"}, targets)
+
+	targets, err = out.Get(context.Background(), "111")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"138"}, targets)
+
+	targets, err = in.Get(context.Background(), "47")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"77", "90"}, targets)
+}
+
+func TestRun_Compressed(t *testing.T) {
+	t.Parallel()
+
+	offsetsFolder := t.TempDir()
+	writeFile(t, filepath.Join(offsetsFolder, offsets.ManifestFile), `{"graph": "host", "compression": "blocks"}`)
+
+	err := run(t.TempDir(), offsetsFolder, t.TempDir(), t.TempDir())
+	require.ErrorContains(t, err, "blocks compressed")
+}
+
+func TestConvert_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "invalid line", input: "75\t63\n75\n", err: "line 2"},
+		{name: "invalid ID", input: "75\t63\nabc\t63\n", err: "invalid ID"},
+		{name: "source goes down", input: "75\t63\n74\t63\n", err: "source ID goes down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			writer := edges.NewAdjacencyWriter(&bytes.Buffer{}, "part-00000.adj")
+			err := convert(strings.NewReader(tt.input), writer)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -63,7 +63,15 @@ func convertAndSave(ctx context.Context, biggestIDs map[string]int, outFile stri
 		return fmt.Errorf("error loading offsets: %w", err)
 	}
 
-	vertices := vertices.NewVertices(file.NewGetter("data/vertices"), *offsets)
+	manifest, err := vertices.NewManifest()
+	if err != nil {
+		return fmt.Errorf("error loading manifest: %w", err)
+	}
+
+	vertices, err := vertices.NewGraphVertices(file.NewGetter("data/vertices"), *offsets, manifest.Graph)
+	if err != nil {
+		return fmt.Errorf("error creating vertices: %w", err)
+	}
 
 	biggest := make(map[string]int)
 
@@ -110,31 +118,27 @@ func convertAndSave(ctx context.Context, biggestIDs map[string]int, outFile stri
 func loadBiggestHosts(edgesFolder string, biggest map[string]int) error {
 	log.Printf("Loading  Edges from %s folder\n", edgesFolder)
 
-	entries, err := os.ReadDir(edgesFolder)
+	names, err := file.ReadDir(edgesFolder)
 	if err != nil {
-		return fmt.Errorf("error reading directory %q: %w", edgesFolder, err)
+		return err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filePath := filepath.Join(edgesFolder, entry.Name())
+	for _, name := range names {
+		filePath := filepath.Join(edgesFolder, name)
 		log.Printf("Processing Edges file: %s\n", filePath)
 
-		file, err := os.Open(filePath) //nolint:gosec
+		reader, err := file.Open(filePath)
 		if err != nil {
-			return fmt.Errorf("error opening file %q: %w", filePath, err)
+			return err
 		}
 
 		defer func() {
-			if err := file.Close(); err != nil {
+			if err := reader.Close(); err != nil {
 				log.Printf("error closing file %s: %v", filePath, err)
 			}
 		}()
 
-		scanner := bufio.NewScanner(file)
+		scanner := bufio.NewScanner(reader)
 
 		err = processOneEdgesFile(scanner, biggest)
 		if err != nil {
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+const (
+	dataFolder = "data"
+)
+
+// in-degree of every vertice is a length of its run in reversed edges.
+// Counters for all vertices are kept in memory, 4 bytes per vertice.
+func main() {
+	err := run(dataFolder)
+	if err != nil {
+		log.Fatal("Degrees Error: ", err)
+	}
+}
+
+func run(dataFolder string) error {
+	// table has value for every vertice, vertices without incoming links at the end get zero
+	n, err := vertices.Count(path.Join(dataFolder, vertices.Folder))
+	if err != nil {
+		return err
+	}
+
+	counts, err := countDegrees(path.Join(dataFolder, edges.EdgesReversedFolder))
+	if err != nil {
+		return err
+	}
+
+	if len(counts) > n {
+		return fmt.Errorf("vertice ID %d is out of %d vertices", len(counts)-1, n)
+	}
+
+	counts = append(counts, make([]uint32, n-len(counts))...)
+
+	err = os.MkdirAll(path.Join(dataFolder, ranks.Folder), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating folder: %w", err)
+	}
+
+	saveFile := path.Join(dataFolder, ranks.Folder, ranks.FileName(ranks.InDegree))
+
+	err = saveDegrees(saveFile, counts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Saved %d degrees to %s\n", len(counts), saveFile)
+
+	return nil
+}
+
+func saveDegrees(fileName string, counts []uint32) error {
+	file, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	writer := ranks.NewWriter(file)
+
+	for id, count := range counts {
+		err := writer.Write(id, float32(count))
+		if err != nil {
+			return fmt.Errorf("error writing to file %q: %w", fileName, err)
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing to file %q: %w", fileName, err)
+	}
+
+	return nil
+}
+
+func countDegrees(edgesFolder string) ([]uint32, error) {
+	log.Printf("Loading  Edges from %s folder\n", edgesFolder)
+
+	entries, err := os.ReadDir(edgesFolder)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %q: %w", edgesFolder, err)
+	}
+
+	counts := make([]uint32, 0)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filePath := filepath.Join(edgesFolder, entry.Name())
+		log.Printf("Processing Edges file: %s\n", filePath)
+
+		counts, err = countFile(filePath, counts)
+		if err != nil {
+			return nil, fmt.Errorf("error processing file %q: %w", filePath, err)
+		}
+	}
+
+	return counts, nil
+}
+
+func countFile(filePath string, counts []uint32) ([]uint32, error) {
+	file, err := os.Open(filePath) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %q: %w", filePath, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	return processOneEdgesFile(bufio.NewScanner(file), counts)
+}
+
+// processOneEdgesFile adds number of edges for every source vertice to counts.
+func processOneEdgesFile(scanner *bufio.Scanner, counts []uint32) ([]uint32, error) {
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		edge, err := edges.LoadEdge(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line: %s: %w", line, err)
+		}
+
+		id, err := strconv.Atoi(edge.FromID())
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid ID: %q", edge.FromID())
+		}
+
+		if id >= len(counts) {
+			counts = append(counts, make([]uint32, id-len(counts)+1)...)
+		}
+
+		counts[id]++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/vertices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessOneEdgesFile(t *testing.T) {
+	t.Parallel()
+
+	counts, err := processOneEdgesFile(bufio.NewScanner(strings.NewReader("1\t5\n1\t7\n4\t1\n")), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0, 2, 0, 0, 1}, counts)
+
+	// every file has own subset of vertices
+	counts, err = processOneEdgesFile(bufio.NewScanner(strings.NewReader("0\t3\n4\t2\n")), counts)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2, 0, 0, 2}, counts)
+}
+
+func TestProcessOneEdgesFile_InvalidLine(t *testing.T) {
+	t.Parallel()
+
+	_, err := processOneEdgesFile(bufio.NewScanner(strings.NewReader("1\t5\nbad_data\n")), nil)
+	require.Error(t, err)
+
+	_, err = processOneEdgesFile(bufio.NewScanner(strings.NewReader("x\t5\n")), nil)
+	require.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := t.TempDir()
+	verticesFolder := filepath.Join(dataFolder, vertices.Folder)
+	edgesFolder := filepath.Join(dataFolder, edges.EdgesReversedFolder)
+
+	require.NoError(t, os.MkdirAll(verticesFolder, 0o755))
+	require.NoError(t, os.MkdirAll(edgesFolder, 0o755))
+	// vertices 3 and 4 have no incoming links
+	require.NoError(t, os.WriteFile(filepath.Join(verticesFolder, "part-0.txt"), []byte("0\tcom.a\n1\tcom.b\n2\tcom.c\n3\tcom.d\n4\tcom.e\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(edgesFolder, "part-0.txt"), []byte("0\t1\n2\t0\n2\t1\n"), 0o644))
+
+	require.NoError(t, run(dataFolder))
+
+	info, err := os.Stat(filepath.Join(dataFolder, ranks.Folder, ranks.FileName(ranks.InDegree)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5*ranks.ValueSize), info.Size())
+
+	table := ranks.NewTable(file.NewGetter(filepath.Join(dataFolder, ranks.Folder)), ranks.InDegree)
+	values, err := table.Get(context.Background(), []int{0, 2, 4})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]float32{0: 1, 2: 2, 4: 0}, values)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/access/blocks"
+)
+
+// blockOutput saves block compressed copies of data folder files and their block index.
+// Blocks start at chunk offsets saved by indexer. Exact offset added inside a chunk when run is found to be hub
+// is not a block start, its lookup reads the block from the beginning.
+// Files are compressed in parallel, index is guarded by mutex.
+type blockOutput struct {
+	folder string
+	mu     sync.Mutex
+	index  blocks.Index
+}
+
+// newBlockOutput creates output folder named as data folder in blocks folder.
+func newBlockOutput(blocksFolder string, dataFolder string) (*blockOutput, error) {
+	folder := path.Join(blocksFolder, filepath.Base(dataFolder))
+
+	err := os.MkdirAll(folder, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating folder %q: %w", folder, err)
+	}
+
+	return &blockOutput{folder: folder}, nil
+}
+
+// blockFile is compressed copy of one data file.
+type blockFile struct {
+	*blocks.Writer
+	file *os.File
+	name string
+}
+
+func (b *blockOutput) create(name string) (*blockFile, error) {
+	fileName := path.Join(b.folder, name+blocks.Ext)
+
+	file, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	return &blockFile{Writer: blocks.NewWriter(file), file: file, name: name}, nil
+}
+
+// finish writes the last block and adds blocks of file to index.
+func (b *blockOutput) finish(f *blockFile) error {
+	err := f.Close()
+
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("error writing file %q: %w", f.file.Name(), err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.index.Append(f.name, f.Blocks())
+
+	return nil
+}
+
+func (b *blockOutput) save(outFolder string, blocksFile string) error {
+	err := b.index.Validate()
+	if err != nil {
+		return fmt.Errorf("error validating blocks: %w", err)
+	}
+
+	saveFile := path.Join(outFolder, blocksFile)
+
+	err = b.index.Save(saveFile)
+	if err != nil {
+		return fmt.Errorf("error saving blocks: %w", err)
+	}
+
+	log.Printf("Saved blocks of %d files to %s\n", b.index.Len(), saveFile)
+
+	return nil
+}
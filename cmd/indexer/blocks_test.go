@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/blocks"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessOneEdgesFile_Blocks(t *testing.T) {
+	t.Parallel()
+
+	buffer := strings.Builder{}
+	for i := range 20000 {
+		buffer.WriteString(fmt.Sprintf("%d\t%d\n", i, i))
+	}
+
+	var compressed bytes.Buffer
+
+	writer := blocks.NewWriter(&compressed)
+
+	result, err := processOneEdgesFile(bufio.NewScanner(strings.NewReader(buffer.String())), "edges.txt", edgesChunking{size: edges.FileChunkSize}, writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	// every offset starts a block
+	written := writer.Blocks()
+	require.Len(t, written, len(result))
+
+	for i, offset := range result {
+		assert.Equal(t, offset.Offset(), written[i].Offset)
+	}
+
+	reader, err := gzip.NewReader(&compressed)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, buffer.String(), string(data))
+}
@@ -2,92 +2,363 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/dharnitski/cc-hosts/access/file"
 	"github.com/dharnitski/cc-hosts/edges"
 	"github.com/dharnitski/cc-hosts/offsets"
 	"github.com/dharnitski/cc-hosts/vertices"
 )
 
 const (
-	dataFolder = "data"
+	indexVertices      = "vertices"
+	indexEdges         = "edges"
+	indexEdgesReversed = "edges_reversed"
+	indexRanks         = "ranks"
 )
 
+// allIndexes is the order indexes are built in.
+//
 //nolint:gochecknoglobals
-var (
-	edgesForwardFolder  = path.Join(dataFolder, edges.EdgesFolder)
-	edgesReversedFolder = path.Join(dataFolder, edges.EdgesReversedFolder)
-	verticesFolder      = path.Join(dataFolder, vertices.Folder)
-)
+var allIndexes = []string{indexVertices, indexEdges, indexEdgesReversed, indexRanks}
+
+// dataIndexes are indexes described by manifest, ranks are not compressed and have no format.
+//
+//nolint:gochecknoglobals
+var dataIndexes = []string{indexVertices, indexEdges, indexEdgesReversed}
+
+// config has input and output folders and settings of one indexer run.
+type config struct {
+	dataFolder   string
+	outFolder    string
+	blocksFolder string
+	indexes      map[string]bool
+	// minimal distance between offsets in bytes
+	verticesChunk int
+	edges         edgesChunking
+	// number of files indexed in parallel
+	workers int
+}
 
 func main() {
-	err := createVerticesIndex()
+	dataFolder := flag.String("data", "data", "folder with vertices, edges, edges_reversed and ranks files")
+	outFolder := flag.String("out", offsets.Folder, "folder for offsets and manifest")
+	blocksFolder := flag.String("blocks", "", "folder for block compressed copy of vertices and edges, not created when empty")
+	indexes := flag.String("index", strings.Join(allIndexes, ","), "comma separated indexes to build")
+	verticesChunk := flag.Int("vertices-chunk", vertices.FileChunkSize, "distance between vertices offsets in bytes")
+	edgesChunk := flag.Int("edges-chunk", edges.FileChunkSize, "distance between edges offsets in bytes")
+	edgesHub := flag.Int("edges-hub", edges.FileChunkSize/4, "size of source run in bytes that gets exact offsets, 0 disables them")
+	edgesDense := flag.Int("edges-dense", 1024, "number of sources that closes edges chunk before its size, 0 disables it")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of files indexed in parallel")
+	flag.Parse()
+
+	selected, err := parseIndexes(*indexes)
+	if err != nil {
+		log.Fatal("Flags Error: ", err)
+	}
+
+	cfg := config{
+		dataFolder:    *dataFolder,
+		outFolder:     *outFolder,
+		blocksFolder:  *blocksFolder,
+		indexes:       selected,
+		verticesChunk: *verticesChunk,
+		edges:         edgesChunking{size: *edgesChunk, hub: *edgesHub, dense: *edgesDense},
+		workers:       *workers,
+	}
+
+	err = run(cfg)
+	if err != nil {
+		log.Fatal("Indexer Error: ", err)
+	}
+}
+
+// parseIndexes returns set of index names from comma separated list.
+func parseIndexes(value string) (map[string]bool, error) {
+	result := make(map[string]bool)
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !slices.Contains(allIndexes, name) {
+			return nil, fmt.Errorf("unknown index %q, expected one of %s", name, strings.Join(allIndexes, ", "))
+		}
+
+		result[name] = true
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("no indexes selected")
+	}
+
+	return result, nil
+}
+
+func (c config) validate() error {
+	if c.workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", c.workers)
+	}
+
+	if c.verticesChunk < 1 || c.edges.size < 1 {
+		return fmt.Errorf("invalid chunk size: vertices %d, edges %d", c.verticesChunk, c.edges.size)
+	}
+
+	if c.edges.hub < 0 || c.edges.dense < 0 {
+		return fmt.Errorf("invalid edges chunking: hub %d, dense %d", c.edges.hub, c.edges.dense)
+	}
+
+	// compression is set for the whole snapshot in manifest
+	if c.blocksFolder != "" && !c.allData() {
+		return fmt.Errorf("-blocks needs %s indexes", strings.Join(dataIndexes, ", "))
+	}
+
+	return nil
+}
+
+// someData reports whether any index described by manifest is selected.
+func (c config) someData() bool {
+	return slices.ContainsFunc(dataIndexes, func(name string) bool { return c.indexes[name] })
+}
+
+// allData reports whether all indexes described by manifest are selected.
+func (c config) allData() bool {
+	return !slices.ContainsFunc(dataIndexes, func(name string) bool { return !c.indexes[name] })
+}
+
+func (c config) verticesFolder() string {
+	return path.Join(c.dataFolder, vertices.Folder)
+}
+
+func run(cfg config) error {
+	err := cfg.validate()
+	if err != nil {
+		return fmt.Errorf("invalid flags: %w", err)
+	}
+
+	err = os.MkdirAll(cfg.outFolder, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating folder %q: %w", cfg.outFolder, err)
+	}
+
+	graph, err := detectGraph(cfg.verticesFolder())
 	if err != nil {
-		log.Fatal("Vertices Error: ", err)
+		return fmt.Errorf("graph: %w", err)
 	}
 
-	err = createForwardEdgesIndex()
+	schema, err := graph.Schema()
 	if err != nil {
-		log.Fatal("Edges Forward Error: ", err)
+		return fmt.Errorf("graph: %w", err)
+	}
+
+	// checked before indexes are built, so mismatched run does not overwrite offsets
+	manifest, err := newManifest(cfg, graph)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	if cfg.indexes[indexVertices] {
+		err = createVerticesIndex(cfg, schema)
+		if err != nil {
+			return fmt.Errorf("vertices: %w", err)
+		}
+	}
+
+	if cfg.indexes[indexEdges] {
+		err = createEdgesIndex(cfg, edges.EdgesFolder, offsets.EdgesOffsetsFile, offsets.EdgesBlocksFile)
+		if err != nil {
+			return fmt.Errorf("edges: %w", err)
+		}
 	}
 
-	err = createBackwardEdgesIndex()
+	if cfg.indexes[indexEdgesReversed] {
+		err = createEdgesIndex(cfg, edges.EdgesReversedFolder, offsets.EdgesReversedOffsetFile, offsets.EdgesReversedBlocksFile)
+		if err != nil {
+			return fmt.Errorf("edges reversed: %w", err)
+		}
+	}
+
+	if cfg.indexes[indexRanks] {
+		err = createRanksIndex(cfg.dataFolder, graph, schema)
+		if err != nil {
+			return fmt.Errorf("ranks: %w", err)
+		}
+	}
+
+	if manifest == nil {
+		log.Printf("Manifest is not changed, no vertices or edges indexes are built\n")
+
+		return nil
+	}
+
+	err = saveManifest(cfg.outFolder, manifest)
 	if err != nil {
-		log.Fatal("Edges Backward Error: ", err)
+		return fmt.Errorf("manifest: %w", err)
 	}
+
+	return nil
 }
 
-func createVerticesIndex() error {
-	log.Printf("Loading  Vertices from %s folder\n", verticesFolder)
-	// entries are sorted by filename
-	entries, err := os.ReadDir(verticesFolder)
+// detectGraph returns host or domain graph by columns of the first vertices line.
+func detectGraph(verticesFolder string) (vertices.Graph, error) {
+	// names are sorted by filename
+	names, err := file.ReadDir(verticesFolder)
 	if err != nil {
-		return fmt.Errorf("error reading directory %q: %w", verticesFolder, err)
+		return "", err
 	}
 
-	results := vertices.Offsets{}
+	for _, name := range names {
+		filePath := filepath.Join(verticesFolder, name)
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+		line, err := readFirstLine(filePath)
+		if err != nil {
+			return "", err
 		}
 
-		filePath := filepath.Join(verticesFolder, entry.Name())
-		log.Printf("Processing Vertices file: %s\n", filePath)
+		if line == "" {
+			continue
+		}
 
-		file, err := os.Open(filePath) //nolint:gosec
+		graph, err := vertices.DetectGraph(line)
 		if err != nil {
-			return fmt.Errorf("error opening file %q: %w", filePath, err)
+			return "", fmt.Errorf("error detecting graph in file %q: %w", filePath, err)
 		}
 
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("error closing file %s: %v", filePath, err)
-			}
-		}()
+		log.Printf("Detected %s graph\n", graph)
+
+		return graph, nil
+	}
+
+	return vertices.GraphHost, nil
+}
+
+func readFirstLine(filePath string) (string, error) {
+	reader, err := file.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Scan()
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading file %q: %w", filePath, err)
+	}
+
+	return scanner.Text(), nil
+}
+
+// newManifest returns manifest of snapshot after the run, it is nil when no indexes described by manifest are selected.
+// Full rebuild sets compression by -blocks and TSV edges format.
+// Partial rebuild keeps fields of existing manifest and fails when built offsets do not match them.
+func newManifest(cfg config, graph vertices.Graph) (*vertices.Manifest, error) {
+	if !cfg.someData() {
+		return nil, nil //nolint:nilnil
+	}
+
+	if cfg.allData() {
+		manifest := &vertices.Manifest{Graph: graph}
+		if cfg.blocksFolder != "" {
+			manifest.Compression = vertices.CompressionBlocks
+		}
+
+		return manifest, nil
+	}
+
+	manifest, err := vertices.LoadManifest(path.Join(cfg.outFolder, offsets.ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Compression != "" {
+		return nil, fmt.Errorf("snapshot is %s compressed, %s indexes have to be rebuilt together with -blocks",
+			manifest.Compression, strings.Join(dataIndexes, ", "))
+	}
+
+	format := edges.Format(manifest.EdgesFormat)
+	if format != "" && format != edges.FormatTSV && (cfg.indexes[indexEdges] || cfg.indexes[indexEdgesReversed]) {
+		return nil, fmt.Errorf("snapshot has %s edges, indexer builds offsets of TSV edges", format)
+	}
+
+	manifest.Graph = graph
+
+	return manifest, nil
+}
+
+func saveManifest(outFolder string, manifest *vertices.Manifest) error {
+	saveFile := path.Join(outFolder, offsets.ManifestFile)
+
+	err := manifest.Save(saveFile)
+	if err != nil {
+		return fmt.Errorf("error saving manifest: %w", err)
+	}
+
+	log.Printf("Saved manifest to %s\n", saveFile)
+
+	return nil
+}
 
-		scanner := bufio.NewScanner(file)
+// chunkWriter receives lines of data file, it is flushed when the next offset is started.
+type chunkWriter interface {
+	io.Writer
+	Flush() error
+}
+
+func createVerticesIndex(cfg config, schema vertices.Schema) error {
+	verticesFolder := cfg.verticesFolder()
+	log.Printf("Loading  Vertices from %s folder\n", verticesFolder)
+
+	var (
+		output *blockOutput
+		err    error
+	)
 
-		items, err := processOneVerticesFile(scanner, entry.Name())
+	if cfg.blocksFolder != "" {
+		output, err = newBlockOutput(cfg.blocksFolder, verticesFolder)
 		if err != nil {
-			return fmt.Errorf("error processing file %q: %w", filePath, err)
+			return err
 		}
+	}
 
-		results.Append(items)
+	items, err := indexFiles(verticesFolder, "Vertices", cfg.workers, output,
+		func(scanner *bufio.Scanner, name string, chunks chunkWriter) ([]vertices.Offset, error) {
+			return processOneVerticesFile(scanner, name, schema, cfg.verticesChunk, chunks)
+		})
+	if err != nil {
+		return err
 	}
 
+	results := vertices.Offsets{}
+	results.Append(items)
+
 	if results.Len() > 0 {
 		err := results.Validate()
 		if err != nil {
 			return fmt.Errorf("error validating offsets: %w", err)
 		}
 
-		saveFile := fmt.Sprintf("%s/%s", offsets.Folder, offsets.VerticesOffsetsFile)
+		saveFile := path.Join(cfg.outFolder, offsets.VerticesOffsetsFile)
 
 		err = results.Save(saveFile)
 		if err != nil {
@@ -97,10 +368,146 @@ func createVerticesIndex() error {
 		log.Printf("Saved %d Vertices offsets to %s\n", results.Len(), saveFile)
 	}
 
+	if output != nil {
+		return output.save(cfg.outFolder, offsets.VerticesBlocksFile)
+	}
+
 	return nil
 }
 
-func processOneVerticesFile(scanner *bufio.Scanner, fileName string) ([]vertices.Offset, error) {
+// indexFiles runs process for every file of folder by workers.
+// Offsets are merged in order of file names, so result does not depend on number of workers.
+func indexFiles[T any](
+	folder string, kind string, workers int, output *blockOutput,
+	process func(scanner *bufio.Scanner, name string, chunks chunkWriter) ([]T, error),
+) ([]T, error) {
+	// names are sorted by filename
+	names, err := file.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make([]int64, len(names))
+
+	for i, name := range names {
+		info, err := os.Stat(filepath.Join(folder, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %q: %w", name, err)
+		}
+
+		sizes[i] = info.Size()
+	}
+
+	status := newProgress(kind, sizes)
+	results := make([][]T, len(names))
+	errs := make([]error, len(names))
+	jobs := make(chan int)
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+
+	for range min(workers, len(names)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				// the rest of files is skipped after the first error
+				if failed.Load() {
+					continue
+				}
+
+				results[i], errs[i] = indexOneFile(filepath.Join(folder, names[i]), output, process)
+				if errs[i] != nil {
+					failed.Store(true)
+
+					continue
+				}
+
+				status.done(i)
+			}
+		}()
+	}
+
+	for i := range names {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	result := make([]T, 0)
+
+	for i := range names {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		result = append(result, results[i]...)
+	}
+
+	return result, nil
+}
+
+// indexOneFile indexes one data file, file is closed before return.
+func indexOneFile[T any](
+	filePath string, output *blockOutput,
+	process func(scanner *bufio.Scanner, name string, chunks chunkWriter) ([]T, error),
+) ([]T, error) {
+	reader, err := file.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	// offsets point into decompressed file served by getters
+	name := file.DataName(filepath.Base(filePath))
+
+	items, err := indexFile(output, name, func(chunks chunkWriter) ([]T, error) {
+		return process(scanner, name, chunks)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error processing file %q: %w", filePath, err)
+	}
+
+	return items, nil
+}
+
+// indexFile runs process with compressed copy of data file when output is set.
+func indexFile[T any](output *blockOutput, name string, process func(chunks chunkWriter) ([]T, error)) ([]T, error) {
+	if output == nil {
+		return process(nil)
+	}
+
+	out, err := output.create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := process(out)
+	if err != nil {
+		if closeErr := out.file.Close(); closeErr != nil {
+			log.Printf("error closing file %s: %v", out.file.Name(), closeErr)
+		}
+
+		return nil, err
+	}
+
+	return items, output.finish(out)
+}
+
+func processOneVerticesFile(
+	scanner *bufio.Scanner, fileName string, schema vertices.Schema, chunkSize int, chunks chunkWriter,
+) ([]vertices.Offset, error) {
 	result := make([]vertices.Offset, 0)
 	// bytes offset in file
 	offset := 0
@@ -116,7 +523,7 @@ func processOneVerticesFile(scanner *bufio.Scanner, fileName string) ([]vertices
 		tokenLength := len(bytes) + 1
 
 		line := string(bytes)
-		vertice, err := vertices.LoadVertice(line)
+		vertice, err := schema.Load(line)
 
 		if err != nil {
 			return nil, fmt.Errorf("invalid line: %q: %w", line, err)
@@ -137,9 +544,19 @@ func processOneVerticesFile(scanner *bufio.Scanner, fileName string) ([]vertices
 			lastSavedOffset = offset
 		}
 
-		if offset-lastSavedOffset >= vertices.FileChunkSize {
+		if offset-lastSavedOffset >= chunkSize {
 			result = append(result, vertices.NewOffset(offset, domain, id, fileName))
 			lastSavedOffset = offset
+
+			err = flushChunk(chunks)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = writeLine(chunks, bytes)
+		if err != nil {
+			return nil, err
 		}
 
 		offset += tokenLength
@@ -154,60 +571,40 @@ func processOneVerticesFile(scanner *bufio.Scanner, fileName string) ([]vertices
 	return result, nil
 }
 
-func createForwardEdgesIndex() error {
-	return createEdgesIndex(edgesForwardFolder, offsets.EdgesOffsetsFile)
-}
-
-func createBackwardEdgesIndex() error {
-	return createEdgesIndex(edgesReversedFolder, offsets.EdgesReversedOffsetFile)
-}
-
-func createEdgesIndex(edgesFolder string, outFile string) error {
+func createEdgesIndex(cfg config, folder string, outFile string, blocksFile string) error {
+	edgesFolder := path.Join(cfg.dataFolder, folder)
 	log.Printf("Loading  Edges from %s folder\n", edgesFolder)
-	// entries are sorted by filename
-	entries, err := os.ReadDir(edgesFolder)
-	if err != nil {
-		return fmt.Errorf("error reading directory %q: %w", edgesFolder, err)
-	}
 
-	results := edges.Offsets{}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+	var (
+		output *blockOutput
+		err    error
+	)
 
-		filePath := filepath.Join(edgesFolder, entry.Name())
-		log.Printf("Processing Edges file: %s\n", filePath)
-
-		file, err := os.Open(filePath) //nolint:gosec
+	if cfg.blocksFolder != "" {
+		output, err = newBlockOutput(cfg.blocksFolder, edgesFolder)
 		if err != nil {
-			return fmt.Errorf("error opening file %q: %w", filePath, err)
-		}
-
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("error closing file %s: %v", filePath, err)
-			}
-		}()
-
-		scanner := bufio.NewScanner(file)
-
-		items, err := processOneEdgesFile(scanner, entry.Name())
-		if err != nil {
-			return fmt.Errorf("error processing file %q: %w", filePath, err)
+			return err
 		}
+	}
 
-		results.Append(items)
+	items, err := indexFiles(edgesFolder, "Edges", cfg.workers, output,
+		func(scanner *bufio.Scanner, name string, chunks chunkWriter) ([]edges.Offset, error) {
+			return processOneEdgesFile(scanner, name, cfg.edges, chunks)
+		})
+	if err != nil {
+		return err
 	}
 
+	results := edges.Offsets{}
+	results.Append(items)
+
 	if results.Len() > 0 {
 		err := results.Validate()
 		if err != nil {
 			return fmt.Errorf("error validating offsets: %w", err)
 		}
 
-		saveFile := fmt.Sprintf("%s/%s", offsets.Folder, outFile)
+		saveFile := path.Join(cfg.outFolder, outFile)
 
 		err = results.Save(saveFile)
 		if err != nil {
@@ -217,16 +614,56 @@ func createEdgesIndex(edgesFolder string, outFile string) error {
 		log.Printf("Saved %d edges offsets to %s\n", results.Len(), saveFile)
 	}
 
+	if output != nil {
+		return output.save(cfg.outFolder, blocksFile)
+	}
+
 	return nil
 }
 
-func processOneEdgesFile(scanner *bufio.Scanner, fileName string) ([]edges.Offset, error) {
+func flushChunk(chunks chunkWriter) error {
+	if chunks == nil {
+		return nil
+	}
+
+	return chunks.Flush()
+}
+
+func writeLine(chunks chunkWriter, line []byte) error {
+	if chunks == nil {
+		return nil
+	}
+
+	_, err := chunks.Write(line)
+	if err == nil {
+		_, err = chunks.Write([]byte{'\n'})
+	}
+
+	return err
+}
+
+// edgesChunking decides where offsets of edges file are saved.
+// Offsets start runs of source ID, so lookup of source reads from the previous offset to the next greater ID.
+type edgesChunking struct {
+	// distance in bytes after which the next run starts a new chunk
+	size int
+	// run of this size in bytes gets exact start and end offsets, 0 disables exact offsets
+	hub int
+	// number of sources after which the next run starts a new chunk, 0 disables it
+	dense int
+}
+
+func processOneEdgesFile(scanner *bufio.Scanner, fileName string, chunking edgesChunking, chunks chunkWriter) ([]edges.Offset, error) { //nolint:cyclop
 	result := make([]edges.Offset, 0)
 	// bytes offset in file
 	offset := 0
-	firstLine := true
 	lastSavedOffset := 0
 	id := ""
+	// start of the current run of id
+	runStart := 0
+	hub := false
+	// number of sources in current chunk
+	sources := 0
 
 	for scanner.Scan() {
 		// read bytes to properly calculate offset
@@ -241,18 +678,58 @@ func processOneEdgesFile(scanner *bufio.Scanner, fileName string) ([]edges.Offse
 			return nil, fmt.Errorf("invalid line: %q: %w", line, err)
 		}
 
-		id = edge.FromID()
+		save := false
+		// offset at the first line of a run is its true start
+		start := edge.FromID() != id
 
-		if firstLine {
-			firstLine = false
+		switch {
+		case start:
+			// hub ends at the next run, so its lookup does not read the next chunk
+			save = len(result) == 0 || hub || offset-lastSavedOffset >= chunking.size ||
+				(chunking.dense > 0 && sources >= chunking.dense)
 
-			result = append(result, edges.NewOffset(offset, id, fileName))
-			lastSavedOffset = offset
+			if save {
+				sources = 0
+			}
+
+			id = edge.FromID()
+			runStart = offset
+			hub = false
+			sources++
+		case !hub && chunking.hub > 0 && offset-runStart >= chunking.hub:
+			// run is found to be hub after its start is written, exact offset is added unless run starts a chunk.
+			// It is added inside the current chunk, so compressed block is read from its start.
+			hub = true
+
+			if result[len(result)-1].Offset() != runStart {
+				result = append(result, edges.NewExactOffset(runStart, id, fileName))
+				lastSavedOffset = runStart
+			}
+
+			save = offset-lastSavedOffset >= chunking.size
+		case hub:
+			// chunks inside hub keep compressed blocks small
+			save = offset-lastSavedOffset >= chunking.size
 		}
 
-		if offset-lastSavedOffset >= edges.FileChunkSize {
-			result = append(result, edges.NewOffset(offset, id, fileName))
+		if save {
+			if start {
+				result = append(result, edges.NewExactOffset(offset, id, fileName))
+			} else {
+				result = append(result, edges.NewOffset(offset, id, fileName))
+			}
+
 			lastSavedOffset = offset
+
+			err = flushChunk(chunks)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = writeLine(chunks, bytes)
+		if err != nil {
+			return nil, err
 		}
 
 		offset += tokenLength
@@ -2,11 +2,21 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/vertices"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,7 +32,7 @@ func TestProcessOneVerticesFile(t *testing.T) {
 	fileLength := buffer.Len()
 	scanner := bufio.NewScanner(strings.NewReader(buffer.String()))
 
-	result, err := processOneVerticesFile(scanner, "vertices.txt")
+	result, err := processOneVerticesFile(scanner, "vertices.txt", vertices.Schema{}, vertices.FileChunkSize, nil)
 	require.NoError(t, err)
 	assert.Len(t, result, 3)
 	assert.Equal(t, "0.example.com\t0\t0\tvertices.txt", result[0].String())
@@ -31,13 +41,69 @@ func TestProcessOneVerticesFile(t *testing.T) {
 	assert.Equal(t, fileLength, result[2].Offset())
 }
 
+func TestProcessOneVerticesFile_DomainGraph(t *testing.T) {
+	t.Parallel()
+
+	schema, err := vertices.GraphDomain.Schema()
+	require.NoError(t, err)
+
+	data := "0\tcom.example\t3\n1\torg.example\t1\n"
+
+	result, err := processOneVerticesFile(bufio.NewScanner(strings.NewReader(data)), "vertices.txt", schema, vertices.FileChunkSize, nil)
+	require.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "com.example\t0\t0\tvertices.txt", result[0].String())
+
+	_, err = processOneVerticesFile(bufio.NewScanner(strings.NewReader(data)), "vertices.txt", vertices.Schema{}, vertices.FileChunkSize, nil)
+	require.Error(t, err)
+}
+
+func TestDetectGraph(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-0.txt"), []byte("0\tcom.example\t3\n"), 0o644))
+
+	graph, err := detectGraph(folder)
+	require.NoError(t, err)
+	assert.Equal(t, vertices.GraphDomain, graph)
+
+	graph, err = detectGraph(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, vertices.GraphHost, graph)
+}
+
+func writeGzip(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	file, err := os.Create(fileName)
+	require.NoError(t, err)
+
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+}
+
+func TestDetectGraph_Gzip(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	writeGzip(t, filepath.Join(folder, "part-0.txt.gz"), "0\tcom.example\t3\n")
+
+	graph, err := detectGraph(folder)
+	require.NoError(t, err)
+	assert.Equal(t, vertices.GraphDomain, graph)
+}
+
 func TestProcessOneVerticesFile_InvalidLine(t *testing.T) {
 	t.Parallel()
 
 	data := "domain1\tvalue1\ninvalid_line\ndomain3\tvalue3\n"
 	scanner := bufio.NewScanner(strings.NewReader(data))
 
-	_, err := processOneVerticesFile(scanner, "vertices.txt")
+	_, err := processOneVerticesFile(scanner, "vertices.txt", vertices.Schema{}, vertices.FileChunkSize, nil)
 	require.Error(t, err)
 }
 
@@ -51,7 +117,7 @@ func TestProcessOneVerticesFile_ScannerError(t *testing.T) {
 		return 0, nil, errors.New("scanner error")
 	})
 
-	_, err := processOneVerticesFile(scanner, "vertices.txt")
+	_, err := processOneVerticesFile(scanner, "vertices.txt", vertices.Schema{}, vertices.FileChunkSize, nil)
 	require.Error(t, err)
 }
 
@@ -64,14 +130,14 @@ func TestProcessOneEdgesFile(t *testing.T) {
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(buffer.String()))
-	result, err := processOneEdgesFile(scanner, "edges.txt")
+	result, err := processOneEdgesFile(scanner, "edges.txt", edgesChunking{size: edges.FileChunkSize}, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Len(t, result, 3)
 
-	assert.Equal(t, "0\t0\tedges.txt", result[0].String())
-	assert.Equal(t, "12775\t131080\tedges.txt", result[1].String())
+	assert.Equal(t, "0\t0\tedges.txt\texact", result[0].String())
+	assert.Equal(t, "12775\t131080\tedges.txt\texact", result[1].String())
 	assert.Equal(t, "19999\t217780\tedges.txt", result[2].String())
 }
 
@@ -81,7 +147,7 @@ func TestProcessOneEdgesFile_InvalidLine(t *testing.T) {
 	data := "bad_data\n"
 	scanner := bufio.NewScanner(strings.NewReader(data))
 
-	_, err := processOneEdgesFile(scanner, "edges.txt")
+	_, err := processOneEdgesFile(scanner, "edges.txt", edgesChunking{size: edges.FileChunkSize}, nil)
 	require.Error(t, err)
 }
 
@@ -95,6 +161,264 @@ func TestProcessOneEdgesFile_ScannerError(t *testing.T) {
 		return 0, nil, errors.New("scanner error")
 	})
 
-	_, err := processOneEdgesFile(scanner, "vertices.txt")
+	_, err := processOneEdgesFile(scanner, "vertices.txt", edgesChunking{size: edges.FileChunkSize}, nil)
 	require.Error(t, err)
 }
+
+func TestProcessOneEdgesFile_ChunkSize(t *testing.T) {
+	t.Parallel()
+
+	data := "1\t2\n1\t3\n2\t3\n3\t4\n"
+
+	result, err := processOneEdgesFile(bufio.NewScanner(strings.NewReader(data)), "edges.txt", edgesChunking{size: 8}, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	assert.Equal(t, "2\t8\tedges.txt\texact", result[1].String())
+}
+
+func TestParseIndexes(t *testing.T) {
+	t.Parallel()
+
+	indexes, err := parseIndexes("vertices, edges")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{indexVertices: true, indexEdges: true}, indexes)
+
+	_, err = parseIndexes("vertices,unknown")
+	require.ErrorContains(t, err, "unknown")
+
+	_, err = parseIndexes("")
+	require.Error(t, err)
+}
+
+// writeTestData writes vertices and edges split into several files.
+func writeTestData(t *testing.T) string {
+	t.Helper()
+
+	dataFolder := t.TempDir()
+
+	for _, folder := range []string{vertices.Folder, edges.EdgesFolder, edges.EdgesReversedFolder} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dataFolder, folder), 0o755))
+
+		for part := range 4 {
+			buffer := strings.Builder{}
+
+			for i := part * 100; i < (part+1)*100; i++ {
+				if folder == vertices.Folder {
+					buffer.WriteString(fmt.Sprintf("%d\tcom.example.%05d\n", i, i))
+				} else {
+					buffer.WriteString(fmt.Sprintf("%d\t%d\n%d\t%d\n", i, i+1, i, i+2))
+				}
+			}
+
+			name := filepath.Join(dataFolder, folder, fmt.Sprintf("part-%05d.txt", part))
+			require.NoError(t, os.WriteFile(name, []byte(buffer.String()), 0o644))
+		}
+	}
+
+	return dataFolder
+}
+
+func TestRun_Workers(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := writeTestData(t)
+	indexes, err := parseIndexes(strings.Join(allIndexes, ","))
+	require.NoError(t, err)
+
+	outputs := make([]string, 0)
+
+	for _, workers := range []int{1, 3} {
+		outFolder := filepath.Join(t.TempDir(), "offsets")
+		cfg := config{
+			dataFolder:    dataFolder,
+			outFolder:     outFolder,
+			indexes:       indexes,
+			verticesChunk: 256,
+			edges:         edgesChunking{size: 256, hub: 64, dense: 16},
+			workers:       workers,
+		}
+		require.NoError(t, run(cfg))
+		outputs = append(outputs, outFolder)
+	}
+
+	// offsets do not depend on number of workers
+	for _, name := range []string{offsets.VerticesOffsetsFile, offsets.EdgesOffsetsFile, offsets.EdgesReversedOffsetFile, offsets.ManifestFile} {
+		first, err := os.ReadFile(filepath.Join(outputs[0], name))
+		require.NoError(t, err)
+
+		second, err := os.ReadFile(filepath.Join(outputs[1], name))
+		require.NoError(t, err)
+
+		assert.Equal(t, string(first), string(second), name)
+	}
+
+	items := edges.Offsets{}
+	require.NoError(t, items.Load(filepath.Join(outputs[1], offsets.EdgesOffsetsFile)))
+	// small chunks give several offsets per file
+	assert.Greater(t, items.Len(), 8)
+}
+
+func TestRun_Indexes(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := writeTestData(t)
+	outFolder := t.TempDir()
+
+	cfg := config{
+		dataFolder:    dataFolder,
+		outFolder:     outFolder,
+		indexes:       map[string]bool{indexEdges: true},
+		verticesChunk: vertices.FileChunkSize,
+		edges:         edgesChunking{size: edges.FileChunkSize},
+		workers:       2,
+	}
+	require.NoError(t, run(cfg))
+
+	_, err := os.Stat(filepath.Join(outFolder, offsets.EdgesOffsetsFile))
+	require.NoError(t, err)
+
+	// not selected indexes are not built
+	_, err = os.Stat(filepath.Join(outFolder, offsets.VerticesOffsetsFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = os.Stat(filepath.Join(outFolder, offsets.EdgesReversedOffsetFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRun_Manifest(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := writeTestData(t)
+	outFolder := t.TempDir()
+	manifestFile := filepath.Join(outFolder, offsets.ManifestFile)
+
+	newConfig := func(blocksFolder string, names ...string) config {
+		selected := make(map[string]bool, len(names))
+		for _, name := range names {
+			selected[name] = true
+		}
+
+		return config{
+			dataFolder:    dataFolder,
+			outFolder:     outFolder,
+			blocksFolder:  blocksFolder,
+			indexes:       selected,
+			verticesChunk: vertices.FileChunkSize,
+			edges:         edgesChunking{size: edges.FileChunkSize},
+			workers:       2,
+		}
+	}
+
+	require.NoError(t, run(newConfig(t.TempDir(), allIndexes...)))
+
+	manifest, err := vertices.LoadManifest(manifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, vertices.CompressionBlocks, manifest.Compression)
+
+	// ranks are not described by manifest
+	require.NoError(t, run(newConfig("", indexRanks)))
+
+	manifest, err = vertices.LoadManifest(manifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, vertices.CompressionBlocks, manifest.Compression)
+
+	// compression covers all vertices and edges files
+	require.ErrorContains(t, run(newConfig(t.TempDir(), indexVertices)), "-blocks needs")
+	require.ErrorContains(t, run(newConfig("", indexVertices)), "blocks compressed")
+
+	require.NoError(t, (&vertices.Manifest{Graph: vertices.GraphHost, EdgesFormat: string(edges.FormatAdjacency)}).Save(manifestFile))
+	require.NoError(t, run(newConfig("", indexVertices)))
+
+	manifest, err = vertices.LoadManifest(manifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(edges.FormatAdjacency), manifest.EdgesFormat)
+
+	require.ErrorContains(t, run(newConfig("", indexEdges)), "adjacency edges")
+}
+
+func TestRun_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	dataFolder := writeTestData(t)
+	name := filepath.Join(dataFolder, edges.EdgesFolder, "part-00002.txt")
+	require.NoError(t, os.WriteFile(name, []byte("bad_data\n"), 0o644))
+
+	cfg := config{
+		dataFolder:    dataFolder,
+		outFolder:     t.TempDir(),
+		indexes:       map[string]bool{indexEdges: true},
+		verticesChunk: vertices.FileChunkSize,
+		edges:         edgesChunking{size: edges.FileChunkSize},
+		workers:       4,
+	}
+
+	err := run(cfg)
+	require.ErrorContains(t, err, "part-00002.txt")
+
+	cfg.workers = 0
+	require.ErrorContains(t, run(cfg), "invalid number of workers")
+}
+
+func TestProcessOneEdgesFile_Hubs(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	buffer := strings.Builder{}
+	expected := make(map[string][]string)
+	// start and end of hub runs in file
+	runs := make(map[string][2]int)
+
+	for from := 1; from <= 50; from++ {
+		degree := 1 + from%3
+		if from == 10 || from == 30 {
+			degree = 500
+		}
+
+		id := strconv.Itoa(from)
+		start := buffer.Len()
+
+		for to := range degree {
+			buffer.WriteString(fmt.Sprintf("%d\t%d\n", from, to))
+			expected[id] = append(expected[id], strconv.Itoa(to))
+		}
+
+		runs[id] = [2]int{start, buffer.Len()}
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "edges.txt"), []byte(buffer.String()), 0o644))
+
+	chunking := edgesChunking{size: 256, hub: 128, dense: 4}
+	result, err := processOneEdgesFile(bufio.NewScanner(strings.NewReader(buffer.String())), "edges.txt", chunking, nil)
+	require.NoError(t, err)
+
+	items := edges.Offsets{}
+	items.Append(result)
+	require.NoError(t, items.Validate())
+
+	// hubs are read without neighbour runs
+	for _, id := range []string{"10", "30"} {
+		offset := items.FindForFromID(id)["edges.txt"]
+		assert.Equal(t, runs[id][0], offset.From.Offset(), id)
+		assert.Equal(t, runs[id][1], offset.To.Offset(), id)
+	}
+
+	// runs after hubs are read without the end of hub
+	for _, id := range []string{"11", "31"} {
+		offset := items.FindForFromID(id)["edges.txt"]
+		assert.Equal(t, runs[id][0], offset.From.Offset(), id)
+	}
+
+	// dense chunks have at most 4 sources
+	offset := items.FindForFromID("45")["edges.txt"]
+	assert.LessOrEqual(t, offset.To.Offset()-offset.From.Offset(), runs["49"][0]-runs["41"][0])
+
+	reader := edges.NewEdges(file.NewGetter(folder), items)
+
+	for id, targets := range expected {
+		actual, err := reader.Get(context.Background(), id)
+		require.NoError(t, err)
+
+		slices.Sort(targets)
+		assert.Equal(t, targets, actual, id)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// progress logs indexed files with throughput and estimated time left.
+// Sizes are sizes of files on disk, so compressed files are measured compressed.
+type progress struct {
+	kind  string
+	sizes []int64
+	total int64
+	start time.Time
+
+	mu    sync.Mutex
+	files int
+	bytes int64
+}
+
+func newProgress(kind string, sizes []int64) *progress {
+	total := int64(0)
+	for _, size := range sizes {
+		total += size
+	}
+
+	return &progress{kind: kind, sizes: sizes, total: total, start: time.Now()}
+}
+
+// done marks file i indexed and logs the progress.
+func (p *progress) done(i int) {
+	log.Println(p.add(i))
+}
+
+func (p *progress) add(i int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files++
+	p.bytes += p.sizes[i]
+
+	return p.report(time.Since(p.start))
+}
+
+func (p *progress) report(elapsed time.Duration) string {
+	const mb = 1024 * 1024
+
+	eta := "unknown"
+	speed := 0.0
+
+	if elapsed > 0 && p.bytes > 0 {
+		speed = float64(p.bytes) / elapsed.Seconds()
+		left := time.Duration(float64(p.total-p.bytes) / speed * float64(time.Second))
+		eta = left.Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("%s: %d/%d files, %.1f/%.1f MB, %.1f MB/s, ETA %s",
+		p.kind, p.files, len(p.sizes), float64(p.bytes)/mb, float64(p.total)/mb, speed/mb, eta)
+}
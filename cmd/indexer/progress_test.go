@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	const mb = 1024 * 1024
+
+	status := newProgress("Edges", []int64{10 * mb, 30 * mb, 60 * mb})
+	assert.Equal(t, "Edges: 0/3 files, 0.0/100.0 MB, 0.0 MB/s, ETA unknown", status.report(time.Second))
+
+	status.add(1)
+	status.add(0)
+	assert.Equal(t, "Edges: 2/3 files, 40.0/100.0 MB, 10.0 MB/s, ETA 6s", status.report(4*time.Second))
+}
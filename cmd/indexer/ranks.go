@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+const (
+	// Common Crawl host ranks file, sample line:
+	// #harmonicc_pos #harmonicc_val #pr_pos #pr_val #host_rev
+	// 1	3.4622028E7	4	0.0044	com.facebook
+	hostRanksFile = "host-ranks.txt"
+	// Common Crawl domain ranks file, it has extra number of hosts column:
+	// #harmonicc_pos #harmonicc_val #pr_pos #pr_val #host_rev #n_hosts
+	// 1	3.4622028E7	4	0.0044	com.facebook	1053
+	domainRanksFile = "domain-ranks.txt"
+)
+
+// ranksPath returns Common Crawl ranks file for the graph.
+func ranksPath(dataFolder string, graph vertices.Graph) string {
+	if graph == vertices.GraphDomain {
+		return path.Join(dataFolder, domainRanksFile)
+	}
+
+	return path.Join(dataFolder, hostRanksFile)
+}
+
+// domainIndex finds vertice id by reversed domain.
+// It keeps 64 bit hash per vertice and ids sorted by hash, 12 bytes per vertice,
+// and domains in one buffer, 8 bytes per vertice and the domain itself.
+// Hash hit is checked against the domain, so host missed in vertices never gets id of colliding vertice.
+type domainIndex struct {
+	// hash of domain for every vertice id
+	hashes []uint64
+	// vertice ids sorted by hash
+	sorted []uint32
+	// domains of all vertices one after another
+	domains []byte
+	// end of domain in domains for every vertice id
+	ends []uint64
+}
+
+// add registers the next vertice, ids have to start from 0 and grow by 1.
+func (d *domainIndex) add(id int, domain string) error {
+	if id != len(d.hashes) {
+		return fmt.Errorf("unexpected vertice ID: %d, expected %d", id, len(d.hashes))
+	}
+
+	d.hashes = append(d.hashes, hashDomain(domain))
+	d.domains = append(d.domains, domain...)
+	d.ends = append(d.ends, uint64(len(d.domains)))
+
+	return nil
+}
+
+// domain returns domain of vertice id.
+func (d *domainIndex) domain(id uint32) string {
+	start := uint64(0)
+	if id > 0 {
+		start = d.ends[id-1]
+	}
+
+	return string(d.domains[start:d.ends[id]])
+}
+
+// finish sorts the index, it has to be called after all vertices are added.
+func (d *domainIndex) finish() error {
+	d.sorted = make([]uint32, len(d.hashes))
+	for i := range d.sorted {
+		d.sorted[i] = uint32(i) //nolint:gosec
+	}
+
+	slices.SortFunc(d.sorted, func(a, b uint32) int {
+		switch {
+		case d.hashes[a] < d.hashes[b]:
+			return -1
+		case d.hashes[a] > d.hashes[b]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for i := 1; i < len(d.sorted); i++ {
+		if d.hashes[d.sorted[i]] == d.hashes[d.sorted[i-1]] {
+			return fmt.Errorf("hash collision for vertices %d and %d", d.sorted[i-1], d.sorted[i])
+		}
+	}
+
+	return nil
+}
+
+func (d *domainIndex) len() int {
+	return len(d.hashes)
+}
+
+func (d *domainIndex) find(domain string) (int, bool) {
+	hash := hashDomain(domain)
+
+	i, found := slices.BinarySearchFunc(d.sorted, hash, func(id uint32, hash uint64) int {
+		switch {
+		case d.hashes[id] < hash:
+			return -1
+		case d.hashes[id] > hash:
+			return 1
+		default:
+			return 0
+		}
+	})
+	// domain with the same hash is not in vertices
+	if !found || d.domain(d.sorted[i]) != domain {
+		return 0, false
+	}
+
+	return int(d.sorted[i]), true
+}
+
+// hashDomain is 64 bit FNV-1a hash.
+func hashDomain(domain string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := range len(domain) {
+		hash ^= uint64(domain[i])
+		hash *= 1099511628211
+	}
+
+	return hash
+}
+
+// createRanksIndex converts Common Crawl host or domain ranks into ID addressable rank tables.
+// It is skipped when ranks file is not downloaded.
+// Rank tables are saved to ranks folder of data folder.
+func createRanksIndex(dataFolder string, graph vertices.Graph, schema vertices.Schema) error {
+	hostRanksPath := ranksPath(dataFolder, graph)
+
+	// ranks can be kept compressed as downloaded
+	_, err := os.Stat(hostRanksPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		hostRanksPath += file.GzipExt
+		_, err = os.Stat(hostRanksPath)
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Ranks file %s not found, skipping ranks\n", ranksPath(dataFolder, graph))
+
+		return nil
+	}
+
+	index, err := loadDomainIndex(path.Join(dataFolder, vertices.Folder), schema)
+	if err != nil {
+		return err
+	}
+
+	harmonic := make([]float32, index.len())
+	pagerank := make([]float32, index.len())
+
+	log.Printf("Loading ranks from %s\n", hostRanksPath)
+
+	reader, err := file.Open(hostRanksPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", hostRanksPath, err)
+		}
+	}()
+
+	missed, err := processHostRanks(bufio.NewScanner(reader), index, harmonic, pagerank)
+	if err != nil {
+		return fmt.Errorf("error processing file %q: %w", hostRanksPath, err)
+	}
+
+	if missed > 0 {
+		log.Printf("%d hosts from ranks are not in vertices\n", missed)
+	}
+
+	ranksFolder := path.Join(dataFolder, ranks.Folder)
+
+	err = os.MkdirAll(ranksFolder, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating folder %q: %w", ranksFolder, err)
+	}
+
+	for name, values := range map[string][]float32{ranks.CCHarmonic: harmonic, ranks.CCPageRank: pagerank} {
+		saveFile := path.Join(ranksFolder, ranks.FileName(name))
+
+		err := ranks.Save(saveFile, values)
+		if err != nil {
+			return fmt.Errorf("error saving ranks: %w", err)
+		}
+
+		log.Printf("Saved %d ranks to %s\n", len(values), saveFile)
+	}
+
+	return nil
+}
+
+func loadDomainIndex(verticesFolder string, schema vertices.Schema) (*domainIndex, error) {
+	log.Printf("Loading  Vertices from %s folder\n", verticesFolder)
+	// names are sorted and vertices files are continuation of each other
+	names, err := file.ReadDir(verticesFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &domainIndex{}
+
+	for _, name := range names {
+		filePath := filepath.Join(verticesFolder, name)
+
+		err := addVerticesFile(filePath, index, schema)
+		if err != nil {
+			return nil, fmt.Errorf("error processing file %q: %w", filePath, err)
+		}
+	}
+
+	err = index.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+func addVerticesFile(filePath string, index *domainIndex, schema vertices.Schema) error {
+	reader, err := file.Open(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		vertice, err := schema.Load(scanner.Text())
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.Atoi(vertice.ID())
+		if err != nil {
+			return fmt.Errorf("invalid ID: %q: %w", vertice.ID(), err)
+		}
+
+		err = index.add(id, vertice.Domain())
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	return nil
+}
+
+// processHostRanks puts ranks from host or domain ranks file into tables by vertice id.
+// Columns after reversed host are ignored. It returns number of hosts not found in vertices.
+func processHostRanks(scanner *bufio.Scanner, index *domainIndex, harmonic []float32, pagerank []float32) (int, error) {
+	missed := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+
+		parts := strings.Split(line, "\t")
+		if len(parts) < 5 {
+			return missed, fmt.Errorf("invalid line: %q, %d parts", line, len(parts))
+		}
+
+		harmonicValue, err := strconv.ParseFloat(parts[1], 32)
+		if err != nil {
+			return missed, fmt.Errorf("invalid harmonic centrality: %q: %w", line, err)
+		}
+
+		pagerankValue, err := strconv.ParseFloat(parts[3], 32)
+		if err != nil {
+			return missed, fmt.Errorf("invalid PageRank: %q: %w", line, err)
+		}
+
+		id, ok := index.find(parts[4])
+		if !ok {
+			missed++
+
+			continue
+		}
+
+		harmonic[id] = float32(harmonicValue)
+		pagerank[id] = float32(pagerankValue)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return missed, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return missed, nil
+}
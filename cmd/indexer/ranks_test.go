@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDomainIndex(t *testing.T, domains ...string) *domainIndex {
+	t.Helper()
+
+	index := &domainIndex{}
+	for id, domain := range domains {
+		require.NoError(t, index.add(id, domain))
+	}
+
+	require.NoError(t, index.finish())
+
+	return index
+}
+
+func TestDomainIndex(t *testing.T) {
+	t.Parallel()
+
+	index := newTestDomainIndex(t, "com.example", "com.facebook", "org.example")
+
+	for id, domain := range []string{"com.example", "com.facebook", "org.example"} {
+		found, ok := index.find(domain)
+		assert.True(t, ok, domain)
+		assert.Equal(t, id, found, domain)
+	}
+
+	_, ok := index.find("net.example")
+	assert.False(t, ok)
+}
+
+func TestDomainIndex_Collision(t *testing.T) {
+	t.Parallel()
+
+	index := &domainIndex{}
+	require.NoError(t, index.add(0, "com.example"))
+	require.NoError(t, index.add(1, "org.example"))
+	// hash of com.example collides with host missed in vertices
+	index.hashes[0] = hashDomain("net.missed")
+	require.NoError(t, index.finish())
+
+	_, ok := index.find("net.missed")
+	assert.False(t, ok)
+
+	found, ok := index.find("org.example")
+	assert.True(t, ok)
+	assert.Equal(t, 1, found)
+}
+
+func TestLoadDomainIndex_Gzip(t *testing.T) {
+	t.Parallel()
+
+	// compressed and decompressed files are continuation of each other
+	folder := t.TempDir()
+	writeGzip(t, filepath.Join(folder, "part-00000.txt.gz"), "0\tcom.example\n1\tcom.facebook\n")
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00001.txt"), []byte("2\torg.example\n"), 0o644))
+
+	index, err := loadDomainIndex(folder, vertices.Schema{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, index.len())
+
+	found, ok := index.find("org.example")
+	assert.True(t, ok)
+	assert.Equal(t, 2, found)
+}
+
+func TestDomainIndex_InvalidID(t *testing.T) {
+	t.Parallel()
+
+	index := &domainIndex{}
+	require.NoError(t, index.add(0, "com.example"))
+	require.Error(t, index.add(2, "org.example"))
+}
+
+func TestProcessHostRanks(t *testing.T) {
+	t.Parallel()
+
+	index := newTestDomainIndex(t, "com.example", "com.facebook", "org.example")
+	data := "#harmonicc_pos\t#harmonicc_val\t#pr_pos\t#pr_val\t#host_rev\n" +
+		"1\t3.4622028E7\t4\t0.0044\tcom.facebook\n" +
+		"2\t1.5E7\t1\t0.01\tnet.missed\n" +
+		"3\t100.5\t7\t1.0E-8\torg.example\n"
+
+	harmonic := make([]float32, 3)
+	pagerank := make([]float32, 3)
+
+	missed, err := processHostRanks(bufio.NewScanner(strings.NewReader(data)), index, harmonic, pagerank)
+	require.NoError(t, err)
+	assert.Equal(t, 1, missed)
+	assert.Equal(t, []float32{0, 3.4622028e7, 100.5}, harmonic)
+	assert.Equal(t, []float32{0, 0.0044, 1e-8}, pagerank)
+}
+
+func TestProcessHostRanks_DomainRanks(t *testing.T) {
+	t.Parallel()
+
+	index := newTestDomainIndex(t, "com.example", "com.facebook")
+	data := "#harmonicc_pos\t#harmonicc_val\t#pr_pos\t#pr_val\t#host_rev\t#n_hosts\n" +
+		"1\t3.4622028E7\t4\t0.0044\tcom.facebook\t1053\n"
+
+	harmonic := make([]float32, 2)
+	pagerank := make([]float32, 2)
+
+	missed, err := processHostRanks(bufio.NewScanner(strings.NewReader(data)), index, harmonic, pagerank)
+	require.NoError(t, err)
+	assert.Equal(t, 0, missed)
+	assert.Equal(t, []float32{0, 3.4622028e7}, harmonic)
+	assert.Equal(t, []float32{0, 0.0044}, pagerank)
+}
+
+func TestProcessHostRanks_InvalidLine(t *testing.T) {
+	t.Parallel()
+
+	index := newTestDomainIndex(t, "com.example")
+
+	for _, data := range []string{"1\t2\tcom.example\n", "1\tx\t4\t0.1\tcom.example\n", "1\t2\t4\tx\tcom.example\n"} {
+		_, err := processHostRanks(bufio.NewScanner(strings.NewReader(data)), index, make([]float32, 1), make([]float32, 1))
+		require.Error(t, err, data)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"math/bits"
+)
+
+const (
+	minRegisters = 16
+	maxRegisters = 256
+)
+
+// harmonicMemory returns bytes used by HyperBall arrays.
+// current and next counters with one byte per register and float32 result per vertice.
+func harmonicMemory(n int, registers int) int {
+	return n * (2*registers + 4)
+}
+
+// registersForBudget returns the biggest number of registers fitting memory budget or 0.
+func registersForBudget(n int, budget int) int {
+	result := 0
+
+	for registers := minRegisters; registers <= maxRegisters; registers *= 2 {
+		if harmonicMemory(n, registers) <= budget {
+			result = registers
+		}
+	}
+
+	return result
+}
+
+// harmonic approximates harmonic centrality with HyperBall algorithm.
+// Every vertice keeps HyperLogLog counter of vertices it is reachable from.
+// After iteration t counter has vertices at distance t or less,
+// so growth of the counter adds growth / t to harmonic centrality.
+func harmonic(source edgeSource, n int, registers int, maxDistance int) ([]float32, error) {
+	cur := make([]uint8, n*registers)
+	next := make([]uint8, n*registers)
+	result := make([]float32, n)
+
+	for id := range n {
+		addToCounter(cur[id*registers:(id+1)*registers], id)
+	}
+
+	for distance := 1; distance <= maxDistance; distance++ {
+		copy(next, cur)
+
+		err := source(func(from int, to int) error {
+			if from >= n || to >= n {
+				return fmt.Errorf("vertice ID out of range: %d -> %d, vertices %d", from, to, n)
+			}
+
+			// union of counters is max of registers
+			src := cur[from*registers : (from+1)*registers]
+			dst := next[to*registers : (to+1)*registers]
+
+			for i, value := range src {
+				if value > dst[i] {
+					dst[i] = value
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		changed := 0
+
+		for id := range n {
+			before := cur[id*registers : (id+1)*registers]
+			after := next[id*registers : (id+1)*registers]
+
+			if bytes.Equal(before, after) {
+				continue
+			}
+
+			changed++
+
+			growth := estimate(after) - estimate(before)
+			if growth > 0 {
+				result[id] += float32(growth / float64(distance))
+			}
+		}
+
+		cur, next = next, cur
+
+		log.Printf("Harmonic distance %d, changed counters %d\n", distance, changed)
+
+		if changed == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// addToCounter adds vertice id to HyperLogLog counter.
+func addToCounter(counter []uint8, id int) {
+	hash := splitMix64(uint64(id)) //nolint:gosec
+	p := bits.TrailingZeros(uint(len(counter)))
+	index := hash & uint64(len(counter)-1)
+	rest := hash >> p
+	rho := uint8(bits.LeadingZeros64(rest) - p + 1) //nolint:gosec
+
+	if rho > counter[index] {
+		counter[index] = rho
+	}
+}
+
+// estimate returns HyperLogLog cardinality estimation for the counter.
+func estimate(counter []uint8) float64 {
+	m := float64(len(counter))
+	sum := 0.0
+	zeros := 0
+
+	for _, value := range counter {
+		sum += math.Ldexp(1, -int(value))
+
+		if value == 0 {
+			zeros++
+		}
+	}
+
+	result := alpha(len(counter)) * m * m / sum
+	// small range correction
+	if result <= 2.5*m && zeros > 0 {
+		result = m * math.Log(m/float64(zeros))
+	}
+
+	return result
+}
+
+func alpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(m))
+	}
+}
+
+// splitMix64 is a fast 64 bit hash with good bits distribution.
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+}
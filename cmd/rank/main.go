@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+const (
+	mb = 1024 * 1024
+)
+
+// edgeSource calls fn for every edge of the graph.
+type edgeSource func(fn func(from int, to int) error) error
+
+func main() {
+	dataFolder := flag.String("data", "data", "folder with vertices and edges")
+	memory := flag.Int("memory", 16*1024, "memory budget in MB")
+	iterations := flag.Int("pagerank-iterations", 30, "number of PageRank iterations")
+	damping := flag.Float64("damping", 0.85, "PageRank damping factor")
+	maxDistance := flag.Int("max-distance", 50, "max path length for harmonic centrality")
+	registers := flag.Int("registers", 0, "HyperLogLog registers per vertice, power of two, picked by memory budget when 0")
+	flag.Parse()
+
+	n, err := vertices.Count(path.Join(*dataFolder, vertices.Folder))
+	if err != nil {
+		log.Fatal("Rank Error: ", err)
+	}
+
+	source := folderSource(path.Join(*dataFolder, edges.EdgesFolder))
+	budget := *memory * mb
+	ranksFolder := path.Join(*dataFolder, ranks.Folder)
+
+	err = os.MkdirAll(ranksFolder, 0o755)
+	if err != nil {
+		log.Fatal("Rank Error: ", err)
+	}
+
+	err = runPageRank(source, n, budget, *iterations, *damping, path.Join(ranksFolder, ranks.FileName(ranks.PageRank)))
+	if err != nil {
+		log.Fatal("PageRank Error: ", err)
+	}
+
+	err = runHarmonic(source, n, budget, *registers, *maxDistance, path.Join(ranksFolder, ranks.FileName(ranks.Harmonic)))
+	if err != nil {
+		log.Fatal("Harmonic Error: ", err)
+	}
+}
+
+func runPageRank(source edgeSource, n int, budget int, iterations int, damping float64, saveFile string) error {
+	if need := pageRankMemory(n); need > budget {
+		return fmt.Errorf("not enough memory: need %d MB, budget %d MB", need/mb, budget/mb)
+	}
+
+	log.Printf("Computing PageRank for %d vertices\n", n)
+
+	values, err := pageRank(source, n, iterations, float32(damping))
+	if err != nil {
+		return err
+	}
+
+	err = ranks.Save(saveFile, values)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Saved PageRank to %s\n", saveFile)
+
+	return nil
+}
+
+func runHarmonic(source edgeSource, n int, budget int, registers int, maxDistance int, saveFile string) error {
+	if registers == 0 {
+		registers = registersForBudget(n, budget)
+		if registers == 0 {
+			return fmt.Errorf("not enough memory: need %d MB, budget %d MB", harmonicMemory(n, minRegisters)/mb, budget/mb)
+		}
+	}
+
+	if registers < minRegisters || registers&(registers-1) != 0 {
+		return fmt.Errorf("invalid number of registers: %d", registers)
+	}
+
+	if need := harmonicMemory(n, registers); need > budget {
+		return fmt.Errorf("not enough memory: need %d MB, budget %d MB", need/mb, budget/mb)
+	}
+
+	log.Printf("Computing harmonic centrality for %d vertices with %d registers\n", n, registers)
+
+	values, err := harmonic(source, n, registers, maxDistance)
+	if err != nil {
+		return err
+	}
+
+	err = ranks.Save(saveFile, values)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Saved harmonic centrality to %s\n", saveFile)
+
+	return nil
+}
+
+// folderSource streams edges from all files in the folder.
+func folderSource(edgesFolder string) edgeSource {
+	return func(fn func(from int, to int) error) error {
+		entries, err := os.ReadDir(edgesFolder)
+		if err != nil {
+			return fmt.Errorf("error reading directory %q: %w", edgesFolder, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			filePath := filepath.Join(edgesFolder, entry.Name())
+
+			err := readEdgesFile(filePath, fn)
+			if err != nil {
+				return fmt.Errorf("error processing file %q: %w", filePath, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func readEdgesFile(filePath string, fn func(from int, to int) error) error {
+	file, err := os.Open(filePath) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error opening file %q: %w", filePath, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		from, to, err := parseEdge(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+
+		err = fn(from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	return nil
+}
+
+// parseEdge parses "from \t to" line without allocations.
+func parseEdge(line []byte) (int, int, error) {
+	from, to, column := 0, 0, 0
+
+	for _, c := range line {
+		switch {
+		case c == '\t' && column == 0:
+			column = 1
+		case c >= '0' && c <= '9' && column == 0:
+			from = from*10 + int(c-'0')
+		case c >= '0' && c <= '9' && column == 1:
+			to = to*10 + int(c-'0')
+		default:
+			return 0, 0, fmt.Errorf("invalid line: %q", line)
+		}
+	}
+
+	if column != 1 || line[0] == '\t' || line[len(line)-1] == '\t' {
+		return 0, 0, fmt.Errorf("invalid line: %q", line)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memorySource(links [][2]int) edgeSource {
+	return func(fn func(from int, to int) error) error {
+		for _, link := range links {
+			err := fn(link[0], link[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestParseEdge(t *testing.T) {
+	t.Parallel()
+
+	from, to, err := parseEdge([]byte("75\t63216723"))
+	require.NoError(t, err)
+	assert.Equal(t, 75, from)
+	assert.Equal(t, 63216723, to)
+
+	for _, line := range []string{"", "75", "75\t", "\t75", "75\t1\t2", "a\t1", "-1\t2"} {
+		_, _, err := parseEdge([]byte(line))
+		require.Error(t, err, line)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	t.Parallel()
+
+	// 1 and 2 link 0, 0 links 1, 3 has no links
+	values, err := pageRank(memorySource([][2]int{{1, 0}, {2, 0}, {0, 1}}), 4, 50, 0.85)
+	require.NoError(t, err)
+	require.Len(t, values, 4)
+
+	sum := float32(0)
+	for _, value := range values {
+		sum += value
+	}
+
+	assert.InDelta(t, 1, sum, 0.001)
+	assert.Greater(t, values[0], values[1])
+	assert.Greater(t, values[1], values[2])
+	assert.InDelta(t, values[2], values[3], 0.0001)
+}
+
+func TestPageRank_OutOfRange(t *testing.T) {
+	t.Parallel()
+
+	_, err := pageRank(memorySource([][2]int{{1, 5}}), 2, 1, 0.85)
+	require.Error(t, err)
+}
+
+func TestHarmonic(t *testing.T) {
+	t.Parallel()
+
+	// chain 0 -> 1 -> 2 -> 3
+	values, err := harmonic(memorySource([][2]int{{0, 1}, {1, 2}, {2, 3}}), 4, 256, 10)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0, values[0], 0.05)
+	assert.InDelta(t, 1, values[1], 0.05)
+	assert.InDelta(t, 1.5, values[2], 0.05)
+	assert.InDelta(t, 1+1.0/2+1.0/3, values[3], 0.05)
+}
+
+func TestRegistersForBudget(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, registersForBudget(100, 100))
+	assert.Equal(t, 16, registersForBudget(100, harmonicMemory(100, 16)))
+	assert.Equal(t, 64, registersForBudget(100, harmonicMemory(100, 64)+100))
+	assert.Equal(t, 256, registersForBudget(100, 1<<30))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// pageRankMemory returns bytes used by PageRank arrays.
+// out degree, current and next ranks, 4 bytes each.
+func pageRankMemory(n int) int {
+	return n * 4 * 3
+}
+
+// pageRank computes PageRank with power iterations.
+// Rank of vertices without out links is spread evenly over all vertices.
+func pageRank(source edgeSource, n int, iterations int, damping float32) ([]float32, error) {
+	outDegree := make([]uint32, n)
+
+	err := source(func(from int, to int) error {
+		if from >= n || to >= n {
+			return fmt.Errorf("vertice ID out of range: %d -> %d, vertices %d", from, to, n)
+		}
+
+		outDegree[from]++
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rank := make([]float32, n)
+	next := make([]float32, n)
+
+	for i := range rank {
+		rank[i] = 1 / float32(n)
+	}
+
+	for iteration := range iterations {
+		clear(next)
+
+		err := source(func(from int, to int) error {
+			next[to] += rank[from] / float32(outDegree[from])
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// float64 sums do not lose small ranks of millions vertices
+		dangling := float64(0)
+
+		for i, degree := range outDegree {
+			if degree == 0 {
+				dangling += float64(rank[i])
+			}
+		}
+
+		base := (1-damping)/float32(n) + damping*float32(dangling/float64(n))
+		delta := float64(0)
+
+		for i := range next {
+			next[i] = base + damping*next[i]
+			delta += math.Abs(float64(next[i] - rank[i]))
+		}
+
+		rank, next = next, rank
+
+		log.Printf("PageRank iteration %d, delta %g\n", iteration+1, delta)
+	}
+
+	return rank, nil
+}
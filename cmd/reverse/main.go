@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+)
+
+const (
+	mb = 1024 * 1024
+)
+
+// reverse builds edges_reversed folder from edges folder.
+// Every edges file is reversed into file with the same name sorted by both columns as numbers.
+func main() {
+	dataFolder := flag.String("data", "data", "folder with edges")
+	memory := flag.Int("memory", 1024, "memory budget in MB for sorted runs")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of runs sorted in parallel")
+	tempFolder := flag.String("temp", "", "folder for run files, system temp folder when empty")
+	flag.Parse()
+
+	if *memory <= 0 || *workers <= 0 {
+		log.Fatal("Reverse Error: memory and workers must be positive")
+	}
+
+	tempDir, err := os.MkdirTemp(*tempFolder, "reverse-")
+	if err != nil {
+		log.Fatal("Reverse Error: ", err)
+	}
+
+	s := &sorter{
+		tempDir: tempDir,
+		// chunk is filled by reader while every worker sorts own chunk
+		chunkSize: max(*memory*mb/edgeSize/(*workers+1), 1),
+		workers:   *workers,
+	}
+
+	err = reverseFolder(s, path.Join(*dataFolder, edges.EdgesFolder), path.Join(*dataFolder, edges.EdgesReversedFolder))
+
+	if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+		log.Printf("error removing folder %s: %v", tempDir, removeErr)
+	}
+
+	if err != nil {
+		log.Fatal("Reverse Error: ", err)
+	}
+}
+
+func reverseFolder(s *sorter, edgesFolder, reversedFolder string) error {
+	log.Printf("Loading  Edges from %s folder\n", edgesFolder)
+	// names are sorted by filename
+	names, err := file.ReadDir(edgesFolder)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(reversedFolder, 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating directory %q: %w", reversedFolder, err)
+	}
+
+	for _, name := range names {
+		start := time.Now()
+		inFile := filepath.Join(edgesFolder, name)
+		// compressed input is saved decompressed to be served by getters
+		outFile := filepath.Join(reversedFolder, file.DataName(name))
+		log.Printf("Reversing Edges file: %s\n", inFile)
+
+		count, err := reverseFile(s, inFile, outFile)
+		if err != nil {
+			return fmt.Errorf("error processing file %q: %w", inFile, err)
+		}
+
+		err = validateFile(outFile, count)
+		if err != nil {
+			return fmt.Errorf("error validating file %q: %w", outFile, err)
+		}
+
+		log.Printf("Saved %d reversed edges to %s in %s\n", count, outFile, time.Since(start).Round(time.Second))
+	}
+
+	return nil
+}
+
+// reverseFile writes sorted reversed edges into temp file renamed to outFile when all edges are written.
+// It returns number of edges.
+func reverseFile(s *sorter, inFile, outFile string) (int, error) {
+	runs, count, err := readRuns(s, inFile)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(outFile), filepath.Base(outFile)+".*.tmp")
+	if err != nil {
+		removeRuns(runs)
+
+		return 0, fmt.Errorf("error creating file: %w", err)
+	}
+
+	writer := bufio.NewWriter(out)
+	line := make([]byte, 0, 32)
+
+	err = s.merge(runs, func(e edge) error {
+		line = e.appendLine(line[:0])
+		_, err := writer.Write(line)
+
+		return err
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(out.Name(), outFile)
+	}
+
+	if err != nil {
+		removeRuns([]string{out.Name()})
+
+		return 0, fmt.Errorf("error writing file %q: %w", outFile, err)
+	}
+
+	return count, nil
+}
+
+func readRuns(s *sorter, fileName string) ([]string, int, error) {
+	reader, err := file.Open(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	return s.runs(reader)
+}
+
+// validateFile checks that file has count edges sorted by both columns.
+func validateFile(fileName string, count int) error {
+	reader, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	return validateSorted(bufio.NewScanner(reader), count)
+}
+
+func validateSorted(scanner *bufio.Scanner, count int) error {
+	lines := 0
+
+	var previous edge
+
+	for scanner.Scan() {
+		lines++
+
+		from, to, err := parseEdge(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lines, err)
+		}
+
+		current := newEdge(from, to)
+		if current < previous {
+			return fmt.Errorf("line %d: edge %d-%d is not sorted", lines, from, to)
+		}
+
+		previous = current
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	if lines != count {
+		return fmt.Errorf("expected %d edges, found %d", count, lines)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseFolder(t *testing.T) {
+	t.Parallel()
+
+	edgesFolder := t.TempDir()
+	reversedFolder := filepath.Join(t.TempDir(), "edges_reversed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(edgesFolder, "part-00000.txt"), []byte("75\t63\n75\t229\n77\t47\n84\t63\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(edgesFolder, "part-00001.txt"), []byte("90\t47\n111\t138\n"), 0o644))
+
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 2, workers: 2}
+	require.NoError(t, reverseFolder(s, edgesFolder, reversedFolder))
+
+	// every file is reversed into file with the same name
+	data, err := os.ReadFile(filepath.Join(reversedFolder, "part-00000.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "47\t77\n63\t75\n63\t84\n229\t75\n", string(data))
+
+	data, err = os.ReadFile(filepath.Join(reversedFolder, "part-00001.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "47\t90\n138\t111\n", string(data))
+
+	entries, err := os.ReadDir(reversedFolder)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestReverseFolder_Gzip(t *testing.T) {
+	t.Parallel()
+
+	edgesFolder := t.TempDir()
+	reversedFolder := t.TempDir()
+
+	file, err := os.Create(filepath.Join(edgesFolder, "part-00000.txt.gz"))
+	require.NoError(t, err)
+
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte("75\t63\n77\t47\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 2, workers: 1}
+	require.NoError(t, reverseFolder(s, edgesFolder, reversedFolder))
+
+	// output is decompressed
+	data, err := os.ReadFile(filepath.Join(reversedFolder, "part-00000.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "47\t77\n63\t75\n", string(data))
+}
+
+func TestReverseFolder_InvalidLine(t *testing.T) {
+	t.Parallel()
+
+	edgesFolder := t.TempDir()
+	reversedFolder := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(edgesFolder, "part-00000.txt"), []byte("75\t63\n75\n"), 0o644))
+
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 2, workers: 2}
+	err := reverseFolder(s, edgesFolder, reversedFolder)
+	require.ErrorContains(t, err, "part-00000.txt")
+	require.ErrorContains(t, err, "line 2")
+
+	// malformed input leaves no output
+	entries, err := os.ReadDir(reversedFolder)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestValidateSorted(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, validateSorted(bufio.NewScanner(strings.NewReader("1\t5\n1\t10\n2\t3\n")), 3))
+
+	// targets are compared as numbers
+	require.Error(t, validateSorted(bufio.NewScanner(strings.NewReader("1\t10\n1\t5\n")), 2))
+	require.Error(t, validateSorted(bufio.NewScanner(strings.NewReader("10\t1\n9\t1\n")), 2))
+	require.Error(t, validateSorted(bufio.NewScanner(strings.NewReader("1\t5\n")), 2))
+	require.Error(t, validateSorted(bufio.NewScanner(strings.NewReader("1\t5\nbad\n")), 2))
+}
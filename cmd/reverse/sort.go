@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// edgeSize is the size of packed edge in memory and in run files
+	edgeSize = 8
+	// maxFanIn limits number of run files opened by one merge
+	maxFanIn = 128
+)
+
+// edge packs source vertice into high bits and target into low bits,
+// so numeric order of values is the order of sorted edges file.
+type edge uint64
+
+func newEdge(from, to uint32) edge {
+	return edge(uint64(from)<<32 | uint64(to))
+}
+
+func (e edge) from() uint32 {
+	return uint32(e >> 32) //nolint:gosec
+}
+
+func (e edge) to() uint32 {
+	return uint32(e) //nolint:gosec
+}
+
+// appendLine appends edge in "from \t to \n" format.
+func (e edge) appendLine(buf []byte) []byte {
+	buf = strconv.AppendUint(buf, uint64(e.from()), 10)
+	buf = append(buf, '\t')
+	buf = strconv.AppendUint(buf, uint64(e.to()), 10)
+
+	return append(buf, '\n')
+}
+
+// parseEdge reads two vertice IDs delimited by tab.
+func parseEdge(line []byte) (uint32, uint32, error) {
+	first, second, found := bytes.Cut(line, []byte{'\t'})
+	if !found || bytes.IndexByte(second, '\t') >= 0 {
+		return 0, 0, fmt.Errorf("invalid line: %q", line)
+	}
+
+	from, err := strconv.ParseUint(string(first), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ID: %q", first)
+	}
+
+	to, err := strconv.ParseUint(string(second), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ID: %q", second)
+	}
+
+	return uint32(from), uint32(to), nil
+}
+
+// sorter sorts reversed edges with external merge sort.
+// Chunks of chunkSize edges are sorted in memory by workers and saved as run files in tempDir.
+type sorter struct {
+	tempDir   string
+	chunkSize int
+	workers   int
+}
+
+// runs reads edges, swaps columns and saves sorted run files.
+// It returns run file names and number of edges.
+func (s *sorter) runs(reader io.Reader) ([]string, int, error) {
+	chunks := make(chan []edge)
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		names []string
+		errs  []error
+	)
+
+	for range s.workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for chunk := range chunks {
+				slices.Sort(chunk)
+				name, err := s.writeRun(chunk)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					names = append(names, name)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	count, readErr := s.read(reader, chunks)
+
+	close(chunks)
+	wg.Wait()
+
+	if readErr != nil {
+		errs = append(errs, readErr)
+	}
+
+	if len(errs) > 0 {
+		removeRuns(names)
+
+		return nil, 0, fmt.Errorf("errors: %v", errs)
+	}
+
+	return names, count, nil
+}
+
+// read sends chunks of reversed edges to workers.
+func (s *sorter) read(reader io.Reader, chunks chan<- []edge) (int, error) {
+	scanner := bufio.NewScanner(reader)
+	chunk := make([]edge, 0, s.chunkSize)
+	count := 0
+
+	for scanner.Scan() {
+		count++
+
+		from, to, err := parseEdge(scanner.Bytes())
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", count, err)
+		}
+
+		chunk = append(chunk, newEdge(to, from))
+
+		if len(chunk) == s.chunkSize {
+			chunks <- chunk
+			chunk = make([]edge, 0, s.chunkSize)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading file: %w", err)
+	}
+
+	if len(chunk) > 0 {
+		chunks <- chunk
+	}
+
+	return count, nil
+}
+
+func (s *sorter) writeRun(chunk []edge) (string, error) {
+	file, err := os.CreateTemp(s.tempDir, "run-*.bin")
+	if err != nil {
+		return "", fmt.Errorf("error creating run file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+
+	var buf [edgeSize]byte
+
+	for _, e := range chunk {
+		binary.LittleEndian.PutUint64(buf[:], uint64(e))
+
+		_, err = writer.Write(buf[:])
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error writing run file %q: %w", file.Name(), err)
+	}
+
+	return file.Name(), nil
+}
+
+// merge merges run files in sorted order into emit and removes them.
+// Runs over maxFanIn are merged in several passes.
+func (s *sorter) merge(names []string, emit func(edge) error) error {
+	for len(names) > maxFanIn {
+		merged := make([]string, 0, len(names)/maxFanIn+1)
+
+		for start := 0; start < len(names); start += maxFanIn {
+			group := names[start:min(start+maxFanIn, len(names))]
+
+			name, err := s.mergeRun(group)
+			if err != nil {
+				removeRuns(merged)
+				removeRuns(names[start:])
+
+				return err
+			}
+
+			merged = append(merged, name)
+		}
+
+		names = merged
+	}
+
+	defer removeRuns(names)
+
+	return mergeRuns(names, emit)
+}
+
+// mergeRun merges group of runs into new run file.
+func (s *sorter) mergeRun(names []string) (string, error) {
+	file, err := os.CreateTemp(s.tempDir, "run-*.bin")
+	if err != nil {
+		return "", fmt.Errorf("error creating run file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+
+	var buf [edgeSize]byte
+
+	err = mergeRuns(names, func(e edge) error {
+		binary.LittleEndian.PutUint64(buf[:], uint64(e))
+		_, err := writer.Write(buf[:])
+
+		return err
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	removeRuns(names)
+
+	if err != nil {
+		removeRuns([]string{file.Name()})
+
+		return "", fmt.Errorf("error merging runs into %q: %w", file.Name(), err)
+	}
+
+	return file.Name(), nil
+}
+
+// runReader reads packed edges from run file.
+type runReader struct {
+	reader *bufio.Reader
+	head   edge
+}
+
+func (r *runReader) next() (bool, error) {
+	var buf [edgeSize]byte
+
+	_, err := io.ReadFull(r.reader, buf[:])
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("error reading run: %w", err)
+	}
+
+	r.head = edge(binary.LittleEndian.Uint64(buf[:]))
+
+	return true, nil
+}
+
+// runHeap keeps run with the smallest head on top.
+type runHeap []*runReader
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].head < h[j].head }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)        { *h = append(*h, x.(*runReader)) } //nolint:forcetypeassert
+func (h *runHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return last
+}
+
+func mergeRuns(names []string, emit func(edge) error) error {
+	runs := make(runHeap, 0, len(names))
+
+	for _, name := range names {
+		file, err := os.Open(name) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("error opening run file %q: %w", name, err)
+		}
+
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("error closing file %s: %v", name, err)
+			}
+		}()
+
+		run := &runReader{reader: bufio.NewReader(file)}
+
+		ok, err := run.next()
+		if err != nil {
+			return fmt.Errorf("error reading run file %q: %w", name, err)
+		}
+
+		if ok {
+			runs = append(runs, run)
+		}
+	}
+
+	heap.Init(&runs)
+
+	for runs.Len() > 0 {
+		run := runs[0]
+
+		err := emit(run.head)
+		if err != nil {
+			return err
+		}
+
+		ok, err := run.next()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			heap.Fix(&runs, 0)
+		} else {
+			heap.Pop(&runs)
+		}
+	}
+
+	return nil
+}
+
+func removeRuns(names []string) {
+	for _, name := range names {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("error removing run file %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEdge(t *testing.T) {
+	t.Parallel()
+
+	from, to, err := parseEdge([]byte("75\t63216723"))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(75), from)
+	assert.Equal(t, uint32(63216723), to)
+
+	for _, line := range []string{"", "75", "75\t", "75\t1\t2", "x\t1", "1\t-1", "1\t4294967296", "1 2"} {
+		_, _, err := parseEdge([]byte(line))
+		require.Error(t, err, line)
+	}
+}
+
+func TestEdge(t *testing.T) {
+	t.Parallel()
+
+	e := newEdge(4294967295, 7)
+	assert.Equal(t, uint32(4294967295), e.from())
+	assert.Equal(t, uint32(7), e.to())
+	assert.Equal(t, "4294967295\t7\n", string(e.appendLine(nil)))
+
+	// numeric order of both columns
+	assert.Less(t, newEdge(9, 100), newEdge(10, 2))
+	assert.Less(t, newEdge(10, 2), newEdge(10, 10))
+}
+
+func collect(t *testing.T, s *sorter, input string) ([]edge, int) {
+	t.Helper()
+
+	runs, count, err := s.runs(strings.NewReader(input))
+	require.NoError(t, err)
+
+	result := []edge{}
+	err = s.merge(runs, func(e edge) error {
+		result = append(result, e)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return result, count
+}
+
+func TestSorter(t *testing.T) {
+	t.Parallel()
+
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 2, workers: 3}
+
+	result, count := collect(t, s, "1\t5\n1\t10\n2\t5\n4\t1\n9\t5\n")
+	assert.Equal(t, 5, count)
+	assert.Equal(t, []edge{newEdge(1, 4), newEdge(5, 1), newEdge(5, 2), newEdge(5, 9), newEdge(10, 1)}, result)
+
+	// run files are removed after merge
+	entries, err := os.ReadDir(s.tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSorter_ManyRuns(t *testing.T) {
+	t.Parallel()
+
+	// more runs than maxFanIn are merged in several passes
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 3, workers: 4}
+	random := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+
+	var (
+		input    strings.Builder
+		expected []edge
+	)
+
+	for range maxFanIn * 5 {
+		from, to := random.Uint32N(1000), random.Uint32N(1000)
+		fmt.Fprintf(&input, "%d\t%d\n", from, to)
+		expected = append(expected, newEdge(to, from))
+	}
+
+	slices.Sort(expected)
+
+	result, count := collect(t, s, input.String())
+	assert.Equal(t, len(expected), count)
+	assert.Equal(t, expected, result)
+
+	entries, err := os.ReadDir(s.tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSorter_InvalidLine(t *testing.T) {
+	t.Parallel()
+
+	s := &sorter{tempDir: t.TempDir(), chunkSize: 1, workers: 2}
+
+	_, _, err := s.runs(strings.NewReader("1\t5\n2\t6\nbad_data\n3\t7\n"))
+	require.ErrorContains(t, err, "line 3")
+
+	entries, err := os.ReadDir(s.tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
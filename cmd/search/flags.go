@@ -0,0 +1,179 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/config"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+)
+
+// defaultData is searched when neither flags nor environment name data location.
+const defaultData = "data"
+
+// searchFlags are flags shared by commands searching domains.
+type searchFlags struct {
+	data            string
+	snapshots       string
+	snapshot        string
+	size            int
+	direction       string
+	mutual          bool
+	order           string
+	limit           int
+	ranks           string
+	include         string
+	exclude         string
+	excludeInternal bool
+	deny            string
+}
+
+func (f *searchFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.data, "data", "", "local folder or s3://bucket/prefix with vertices and edges, CC_HOSTS_DATA or data by default")
+	flags.StringVar(&f.snapshots, "snapshots", "", "snapshots config file, CC_HOSTS_SNAPSHOTS by default, -data location is searched when not set")
+	flags.StringVar(&f.snapshot, "snapshot", "", "snapshot name from snapshots config, default snapshot when not set, requires -snapshots")
+	flags.IntVar(&f.size, "size", defaultBatchSize, "number of domains searched together")
+	flags.StringVar(&f.direction, "direction", "", "in or out, both directions by default")
+	flags.BoolVar(&f.mutual, "mutual", false, "include mutual links")
+	flags.StringVar(&f.order, "order", "", "neighbours order: reversed or rank table name, alphabetical by default")
+	flags.IntVar(&f.limit, "limit", 0, "max number of neighbours in every direction")
+	flags.StringVar(&f.ranks, "ranks", "", "comma separated rank tables attached to every domain")
+	flags.StringVar(&f.include, "include", "", "comma separated reversed domain prefixes to keep, sample: gov,com.example")
+	flags.StringVar(&f.exclude, "exclude", "", "comma separated reversed domain prefixes to drop")
+	flags.BoolVar(&f.excludeInternal, "exclude-internal", false, "drop neighbours with the same registered domain")
+	flags.StringVar(&f.deny, "deny", "", "file with hosts to drop, one per line")
+}
+
+// options converts flags into search options, deny list is loaded from file.
+func (f *searchFlags) options() (search.SearchOptions, error) {
+	opts := search.SearchOptions{
+		Mutual:          f.mutual,
+		Direction:       search.Direction(f.direction),
+		Order:           search.Order(f.order),
+		Limit:           f.limit,
+		Ranks:           config.SplitList(f.ranks),
+		Include:         config.SplitList(f.include),
+		Exclude:         config.SplitList(f.exclude),
+		ExcludeInternal: f.excludeInternal,
+	}
+
+	if f.size <= 0 {
+		return opts, fmt.Errorf("invalid size: %d", f.size)
+	}
+
+	if f.deny != "" {
+		file, err := os.Open(f.deny)
+		if err != nil {
+			return opts, fmt.Errorf("error opening file %q: %w", f.deny, err)
+		}
+
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("error closing file %s: %v", f.deny, err)
+			}
+		}()
+
+		opts.Deny, err = search.LoadDenyList(file)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// newSearcher creates Searcher for snapshot from config or for -data location when config is not set.
+func (f *searchFlags) newSearcher(ctx context.Context, opts search.SearchOptions) (*search.Searcher, error) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Snapshots = cmp.Or(f.snapshots, cfg.Snapshots)
+
+	if cfg.Snapshots == "" {
+		if f.snapshot != "" {
+			return nil, errors.New("-snapshot requires -snapshots or CC_HOSTS_SNAPSHOTS")
+		}
+
+		cfg.Data = cmp.Or(f.data, cfg.Data, defaultData)
+
+		cfg.Ranks, err = locationRanks(cfg.Data, cfg.Folders.Ranks, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	registry, err := newRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.Get(ctx, f.snapshot)
+}
+
+// openRegistry opens snapshots config file, CC_HOSTS_SNAPSHOTS is used when file is not set.
+func openRegistry(configFile string) (*snapshots.Registry, error) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Snapshots = cmp.Or(configFile, cfg.Snapshots)
+	if cfg.Snapshots == "" {
+		return nil, errors.New("-snapshots is required")
+	}
+
+	return newRegistry(cfg)
+}
+
+func newRegistry(cfg *config.Config) (*snapshots.Registry, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Registry()
+}
+
+// locationRanks returns all rank tables in local ranks folder.
+// S3 folders are not listed and only tables used by options are attached.
+func locationRanks(location string, folder string, opts search.SearchOptions) ([]string, error) {
+	if strings.HasPrefix(location, "s3://") {
+		names := append([]string{}, opts.Ranks...)
+		if opts.Order != search.OrderAlphabetical && opts.Order != search.OrderReversed {
+			names = append(names, string(opts.Order))
+		}
+
+		return names, nil
+	}
+
+	// rank tables are optional
+	ranksFolder := path.Join(location, folder)
+
+	entries, err := os.ReadDir(ranksFolder)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading directory %q: %w", ranksFolder, err)
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ranks.FileName(""))
+		if ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSearcher_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	// snapshot is not ignored when there is no snapshots config
+	f := &searchFlags{data: t.TempDir(), snapshot: "2024"}
+	_, err := f.newSearcher(t.Context(), search.SearchOptions{})
+	require.ErrorContains(t, err, "-snapshot requires -snapshots")
+
+	f = &searchFlags{snapshots: writeTestSnapshot(t), snapshot: "2024"}
+	searcher, err := f.newSearcher(t.Context(), search.SearchOptions{})
+	require.NoError(t, err)
+
+	result, err := searcher.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+}
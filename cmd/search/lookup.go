@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/search"
+)
+
+// runLookup searches domains from arguments, file or stdin and writes results in requested format.
+func runLookup(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("lookup", flag.ContinueOnError)
+
+	var sf searchFlags
+
+	sf.register(flags)
+	format := flags.String("format", formatTable, "output format: table, json, ndjson or csv")
+	timings := flags.Bool("timings", false, "print timings to stderr for table and csv formats")
+	inputFile := flags.String("file", "", "file with domains one per line, - for stdin")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	// timings are part of JSON results
+	var timingsOut io.Writer
+	if *timings {
+		timingsOut = stderr
+	}
+
+	writer, err := newResultWriter(*format, stdout, timingsOut)
+	if err != nil {
+		return err
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return err
+	}
+
+	searcher, err := sf.newSearcher(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		err = searchDomains(ctx, searcher, opts, sf.size, strings.NewReader(strings.Join(flags.Args(), "\n")), writer)
+	} else {
+		err = withInput(*inputFile, stdin, func(input io.Reader) error {
+			return searchDomains(ctx, searcher, opts, sf.size, input, writer)
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// runBatch reads domains one per line from file or stdin and writes one JSON result per line.
+func runBatch(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+
+	var sf searchFlags
+
+	sf.register(flags)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return err
+	}
+
+	searcher, err := sf.newSearcher(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	writer, err := newResultWriter(formatNDJSON, stdout, nil)
+	if err != nil {
+		return err
+	}
+
+	err = withInput(flags.Arg(0), stdin, func(input io.Reader) error {
+		return searchDomains(ctx, searcher, opts, sf.size, input, writer)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// withInput calls fn with opened file or stdin when file name is empty or -.
+func withInput(fileName string, stdin io.Reader, fn func(input io.Reader) error) error {
+	if fileName == "" || fileName == "-" {
+		return fn(stdin)
+	}
+
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	return fn(file)
+}
+
+// searchDomains reads domains one per line and searches them in batches of size.
+func searchDomains(
+	ctx context.Context, searcher *search.Searcher, opts search.SearchOptions, size int, input io.Reader, writer resultWriter,
+) error {
+	batch := make([]string, 0, size)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		results, err := searcher.GetTargetsBatch(ctx, batch, opts)
+		if err != nil {
+			return err
+		}
+
+		err = writer.Write(results)
+		if err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		domain := strings.TrimSpace(scanner.Text())
+		if domain == "" {
+			continue
+		}
+
+		batch = append(batch, domain)
+		if len(batch) >= size {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+
+	return flush()
+}
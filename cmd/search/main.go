@@ -1,10 +1,54 @@
 package main
 
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+)
+
+const (
+	defaultBatchSize = 1_000
+)
 
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
 
-		
-}
+	ctx := context.Background()
 
+	var err error
 
+	switch os.Args[1] {
+	case "lookup":
+		err = runLookup(ctx, os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+	case "repl":
+		err = runRepl(ctx, os.Args[2:], os.Stdin, os.Stdout)
+	case "batch":
+		err = runBatch(ctx, os.Args[2:], os.Stdin, os.Stdout)
+	case "diff":
+		err = runDiff(ctx, os.Args[2:], os.Stdout)
+	case "history":
+		err = runHistory(ctx, os.Args[2:], os.Stdout)
+	default:
+		usage()
+		os.Exit(2)
+	}
 
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: search lookup [search flags] [-format table|json|ndjson|csv] [-timings] [-file file] [domain...]")
+	fmt.Fprintln(os.Stderr, "       search repl [search flags]")
+	fmt.Fprintln(os.Stderr, "       search batch [search flags] [file]")
+	fmt.Fprintln(os.Stderr, "       search diff [-snapshots file] -from name -to name [-limit n] domain...")
+	fmt.Fprintln(os.Stderr, "       search history [-snapshots file] [-names names] [-format json|csv] host...")
+	fmt.Fprintln(os.Stderr, "search flags: [-data location] [-snapshots file] [-snapshot name] [-size n] [-direction in|out]")
+	fmt.Fprintln(os.Stderr, "              [-mutual] [-order order] [-limit n] [-ranks names] [-include prefixes] [-exclude prefixes]")
+	fmt.Fprintln(os.Stderr, "              [-exclude-internal] [-deny file]")
+}
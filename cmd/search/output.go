@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dharnitski/cc-hosts/search"
+)
+
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// resultWriter writes search results as they come, Close finishes the output.
+type resultWriter interface {
+	Write(results []search.BatchResult) error
+	Close() error
+}
+
+// newResultWriter creates writer for format.
+// Table and CSV formats print timings into timings writer when it is not nil.
+func newResultWriter(format string, w io.Writer, timings io.Writer) (resultWriter, error) {
+	switch format {
+	case formatTable:
+		return &tableWriter{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0), timings: timings}, nil
+	case formatCSV:
+		return &csvWriter{w: csv.NewWriter(w), timings: timings}, nil
+	case formatJSON:
+		return &jsonWriter{w: w}, nil
+	case formatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format: %q", format)
+	}
+}
+
+// rows converts result into domain, direction and host rows.
+// Missed domains and errors are reported as rows with "missed" and "error" direction.
+func rows(result search.BatchResult) [][]string {
+	switch {
+	case result.Error != "":
+		return [][]string{{result.Domain, "error", result.Error}}
+	case result.Result == nil:
+		return [][]string{{result.Domain, "missed", ""}}
+	}
+
+	lines := make([][]string, 0, len(result.Result.Out)+len(result.Result.In)+len(result.Result.Mutual))
+
+	for _, group := range []struct {
+		direction string
+		hosts     []string
+	}{{"out", result.Result.Out}, {"in", result.Result.In}, {"mutual", result.Result.Mutual}} {
+		for _, host := range group.hosts {
+			lines = append(lines, []string{result.Domain, group.direction, host})
+		}
+	}
+
+	// domain without links is still visible in output
+	if len(lines) == 0 {
+		lines = append(lines, []string{result.Domain, "", ""})
+	}
+
+	return lines
+}
+
+// writeTimings prints timings of found domains one line per domain, keys are sorted.
+func writeTimings(w io.Writer, results []search.BatchResult) error {
+	for _, result := range results {
+		if result.Result == nil {
+			continue
+		}
+
+		parts := make([]string, 0, len(result.Result.Timings))
+		for _, key := range slices.Sorted(maps.Keys(result.Result.Timings)) {
+			parts = append(parts, fmt.Sprintf("%s=%dms", key, result.Result.Timings[key]))
+		}
+
+		_, err := fmt.Fprintf(w, "%s: %s\n", result.Domain, strings.Join(parts, " "))
+		if err != nil {
+			return fmt.Errorf("error writing timings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type tableWriter struct {
+	w       *tabwriter.Writer
+	timings io.Writer
+	started bool
+}
+
+func (t *tableWriter) Write(results []search.BatchResult) error {
+	if !t.started {
+		t.started = true
+
+		_, err := fmt.Fprintln(t.w, "DOMAIN\tDIRECTION\tHOST")
+		if err != nil {
+			return fmt.Errorf("error writing table: %w", err)
+		}
+	}
+
+	for _, result := range results {
+		for _, row := range rows(result) {
+			_, err := fmt.Fprintln(t.w, strings.Join(row, "\t"))
+			if err != nil {
+				return fmt.Errorf("error writing table: %w", err)
+			}
+		}
+	}
+
+	if t.timings != nil {
+		return writeTimings(t.timings, results)
+	}
+
+	return nil
+}
+
+func (t *tableWriter) Close() error {
+	return t.w.Flush()
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	timings io.Writer
+	started bool
+}
+
+func (c *csvWriter) Write(results []search.BatchResult) error {
+	if !c.started {
+		c.started = true
+
+		err := c.w.Write([]string{"domain", "direction", "host"})
+		if err != nil {
+			return fmt.Errorf("error writing CSV: %w", err)
+		}
+	}
+
+	for _, result := range results {
+		err := c.w.WriteAll(rows(result))
+		if err != nil {
+			return fmt.Errorf("error writing CSV: %w", err)
+		}
+	}
+
+	if c.timings != nil {
+		return writeTimings(c.timings, results)
+	}
+
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+// jsonWriter streams results as one JSON array.
+type jsonWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonWriter) Write(results []search.BatchResult) error {
+	for _, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+
+		prefix := ",\n"
+		if !j.started {
+			prefix = "[\n"
+			j.started = true
+		}
+
+		_, err = fmt.Fprintf(j.w, "%s%s", prefix, data)
+		if err != nil {
+			return fmt.Errorf("error writing JSON: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	closing := "\n]\n"
+	if !j.started {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(j.w, closing)
+	if err != nil {
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+
+	return nil
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(results []search.BatchResult) error {
+	for _, result := range results {
+		err := n.encoder.Encode(result)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals
+var testResults = []search.BatchResult{
+	{Domain: "a.com", Result: &search.Result{
+		Target:  "a.com",
+		Out:     []string{"b.com", "c.com"},
+		In:      []string{"b.com"},
+		Timings: map[string]int{"get_by_domain": 1, "edges_get_out": 2},
+	}},
+	{Domain: "x.com"},
+	{Domain: "", Error: "domain is empty"},
+}
+
+func writeResults(t *testing.T, format string, timings io.Writer) string {
+	t.Helper()
+
+	out := strings.Builder{}
+
+	writer, err := newResultWriter(format, &out, timings)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write(testResults[:1]))
+	require.NoError(t, writer.Write(testResults[1:]))
+	require.NoError(t, writer.Close())
+
+	return out.String()
+}
+
+func TestResultWriter_Table(t *testing.T) {
+	t.Parallel()
+
+	timings := strings.Builder{}
+	expected := "DOMAIN  DIRECTION  HOST\n" +
+		"a.com   out        b.com\n" +
+		"a.com   out        c.com\n" +
+		"a.com   in         b.com\n" +
+		"x.com   missed     \n" +
+		"        error      domain is empty\n"
+	assert.Equal(t, expected, writeResults(t, formatTable, &timings))
+	assert.Equal(t, "a.com: edges_get_out=2ms get_by_domain=1ms\n", timings.String())
+}
+
+func TestResultWriter_CSV(t *testing.T) {
+	t.Parallel()
+
+	expected := "domain,direction,host\n" +
+		"a.com,out,b.com\n" +
+		"a.com,out,c.com\n" +
+		"a.com,in,b.com\n" +
+		"x.com,missed,\n" +
+		",error,domain is empty\n"
+	assert.Equal(t, expected, writeResults(t, formatCSV, nil))
+}
+
+func TestResultWriter_JSON(t *testing.T) {
+	t.Parallel()
+
+	output := writeResults(t, formatJSON, nil)
+	assert.True(t, strings.HasPrefix(output, "[\n{\"domain\":\"a.com\""), output)
+	assert.True(t, strings.HasSuffix(output, "{\"domain\":\"\",\"error\":\"domain is empty\"}\n]\n"), output)
+
+	empty := strings.Builder{}
+	writer, err := newResultWriter(formatJSON, &empty, nil)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	assert.Equal(t, "[]\n", empty.String())
+}
+
+func TestResultWriter_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	lines := strings.Split(strings.TrimSpace(writeResults(t, formatNDJSON, nil)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `{"domain":"x.com"}`, lines[1])
+}
+
+func TestNewResultWriter_Unknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := newResultWriter("xml", &strings.Builder{}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"golang.org/x/term"
+)
+
+const (
+	replPrompt = "> "
+	replHelp   = `commands:
+  out [host|n]             outgoing links of host or pivot n from the current list
+  in [host|n]              incoming links
+  sub [host|n]             subdomains
+  path <from> <to> [depth] shortest chain of outgoing links
+  common <a> <b>           neighbours shared by two hosts
+  back                     return to the previous list
+  ls                       print the current list
+  history                  print commands of the session
+  export <file>            save visited links as CSV or Graphviz .dot file
+  help                     print this help
+  exit                     leave the shell
+`
+)
+
+var errExit = errors.New("exit")
+
+// view is a numbered list of hosts printed by the last command.
+type view struct {
+	title string
+	// host the list is about, used when command has no argument
+	subject string
+	hosts   []string
+}
+
+// link is a visited edge of the graph.
+type link struct {
+	from string
+	to   string
+}
+
+// session keeps one Searcher and state of interactive exploration.
+type session struct {
+	searcher *search.Searcher
+	opts     search.SearchOptions
+	out      io.Writer
+	current  view
+	previous []view
+	history  []string
+	// visited subgraph
+	links map[link]struct{}
+	hosts map[string]struct{}
+}
+
+func newSession(searcher *search.Searcher, opts search.SearchOptions, out io.Writer) *session {
+	return &session{
+		searcher: searcher,
+		opts:     opts,
+		out:      out,
+		links:    map[link]struct{}{},
+		hosts:    map[string]struct{}{},
+	}
+}
+
+// lineReader reads commands one by one, io.EOF ends the session.
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+type scannerReader struct {
+	scanner *bufio.Scanner
+}
+
+func (s scannerReader) ReadLine() (string, error) {
+	if s.scanner.Scan() {
+		return s.scanner.Text(), nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+
+	return "", io.EOF
+}
+
+// runRepl starts interactive shell, terminal gets line editing, history and tab completion.
+// Commands are read line by line without prompt when stdin is not a terminal.
+func runRepl(ctx context.Context, args []string, stdin *os.File, stdout io.Writer) error {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+
+	var sf searchFlags
+
+	sf.register(flags)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	opts, err := sf.options()
+	if err != nil {
+		return err
+	}
+
+	searcher, err := sf.newSearcher(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	fd := int(stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return newSession(searcher, opts, stdout).run(ctx, scannerReader{scanner: bufio.NewScanner(stdin)})
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("error switching terminal to raw mode: %w", err)
+	}
+
+	defer func() {
+		if err := term.Restore(fd, state); err != nil {
+			log.Printf("error restoring terminal: %v", err)
+		}
+	}()
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{stdin, stdout}, replPrompt)
+
+	s := newSession(searcher, opts, terminal)
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		return s.complete(line, pos)
+	}
+
+	_, _ = fmt.Fprint(terminal, "type help for commands, tab completes hosts from the current list\n")
+
+	return s.run(ctx, terminal)
+}
+
+// run executes commands until exit or end of input, failed commands do not stop the session.
+func (s *session) run(ctx context.Context, reader lineReader) error {
+	for {
+		line, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = s.exec(ctx, line)
+		if errors.Is(err, errExit) {
+			return nil
+		}
+
+		if err != nil {
+			_, err = fmt.Fprintf(s.out, "error: %v\n", err)
+			if err != nil {
+				return fmt.Errorf("error writing output: %w", err)
+			}
+		}
+	}
+}
+
+// exec runs one command line.
+func (s *session) exec(ctx context.Context, line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	s.history = append(s.history, strings.Join(fields, " "))
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "out", "in":
+		return s.neighbours(ctx, command, args)
+	case "sub":
+		return s.subdomains(ctx, args)
+	case "path":
+		return s.path(ctx, args)
+	case "common":
+		return s.common(ctx, args)
+	case "back":
+		return s.back()
+	case "ls":
+		return s.print()
+	case "history":
+		return s.printHistory()
+	case "export":
+		if len(args) != 1 {
+			return errors.New("usage: export <file>")
+		}
+
+		return s.export(args[0])
+	case "help":
+		_, err := io.WriteString(s.out, replHelp)
+
+		return err
+	case "exit", "quit":
+		return errExit
+	default:
+		return fmt.Errorf("unknown command %q, type help for commands", command)
+	}
+}
+
+// pivot resolves argument into host, number picks host from the current list.
+// Subject of the current list is used when argument is missed.
+func (s *session) pivot(args []string, i int) (string, error) {
+	if i >= len(args) {
+		if s.current.subject == "" {
+			return "", errors.New("host is missed")
+		}
+
+		return s.current.subject, nil
+	}
+
+	n, err := strconv.Atoi(args[i])
+	if err != nil {
+		return strings.ToLower(args[i]), nil
+	}
+
+	if n < 1 || n > len(s.current.hosts) {
+		return "", fmt.Errorf("no host %d in the current list", n)
+	}
+
+	return s.current.hosts[n-1], nil
+}
+
+func (s *session) neighbours(ctx context.Context, direction string, args []string) error {
+	host, err := s.pivot(args, 0)
+	if err != nil {
+		return err
+	}
+
+	opts := s.opts
+	opts.Direction = search.Direction(direction)
+	opts.Mutual = false
+
+	result, err := s.searcher.GetTargetsWithOptions(ctx, host, opts)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return fmt.Errorf("host %q is not found", host)
+	}
+
+	hosts := result.Out
+	if direction == "in" {
+		hosts = result.In
+	}
+
+	for _, neighbour := range hosts {
+		if direction == "in" {
+			s.visit(neighbour, host)
+		} else {
+			s.visit(host, neighbour)
+		}
+	}
+
+	return s.show(view{title: fmt.Sprintf("%s %s: %d hosts", direction, host, len(hosts)), subject: host, hosts: hosts})
+}
+
+func (s *session) subdomains(ctx context.Context, args []string) error {
+	host, err := s.pivot(args, 0)
+	if err != nil {
+		return err
+	}
+
+	hosts, err := s.searcher.Subdomains(ctx, host, s.opts.Limit)
+	if err != nil {
+		return err
+	}
+
+	return s.show(view{title: fmt.Sprintf("sub %s: %d hosts", host, len(hosts)), subject: host, hosts: hosts})
+}
+
+func (s *session) path(ctx context.Context, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("usage: path <from> <to> [depth]")
+	}
+
+	from, err := s.pivot(args, 0)
+	if err != nil {
+		return err
+	}
+
+	to, err := s.pivot(args, 1)
+	if err != nil {
+		return err
+	}
+
+	depth := search.DefaultPathDepth
+	if len(args) == 3 {
+		depth, err = strconv.Atoi(args[2])
+		if err != nil || depth <= 0 {
+			return fmt.Errorf("invalid depth: %q", args[2])
+		}
+	}
+
+	hosts, err := s.searcher.Path(ctx, from, to, search.PathLimits{Depth: depth}, s.opts)
+	if err != nil {
+		return err
+	}
+
+	if hosts == nil {
+		return fmt.Errorf("no path from %q to %q within %d links", from, to, depth)
+	}
+
+	for i := 1; i < len(hosts); i++ {
+		s.visit(hosts[i-1], hosts[i])
+	}
+
+	return s.show(view{title: fmt.Sprintf("path %s -> %s: %d links", from, to, len(hosts)-1), subject: to, hosts: hosts})
+}
+
+// common lists hosts both hosts link to, followed by hosts linking to both of them.
+func (s *session) common(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: common <a> <b>")
+	}
+
+	a, err := s.pivot(args, 0)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.pivot(args, 1)
+	if err != nil {
+		return err
+	}
+
+	opts := s.opts
+	opts.Mutual = false
+
+	results, err := s.searcher.GetTargetsBatch(ctx, []string{a, b}, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+
+		if result.Result == nil {
+			return fmt.Errorf("host %q is not found", result.Domain)
+		}
+	}
+
+	out := shared(results[0].Result.Out, results[1].Result.Out)
+	in := shared(results[0].Result.In, results[1].Result.In)
+
+	for _, host := range out {
+		s.visit(a, host)
+		s.visit(b, host)
+	}
+
+	for _, host := range in {
+		s.visit(host, a)
+		s.visit(host, b)
+	}
+
+	title := fmt.Sprintf("common %s %s: %d out, %d in", a, b, len(out), len(in))
+
+	return s.show(view{title: title, subject: a, hosts: slices.Concat(out, in)})
+}
+
+// shared returns hosts present in both lists in order of the first list.
+func shared(a []string, b []string) []string {
+	results := []string{}
+
+	for _, host := range a {
+		if slices.Contains(b, host) {
+			results = append(results, host)
+		}
+	}
+
+	return results
+}
+
+func (s *session) back() error {
+	if len(s.previous) == 0 {
+		return errors.New("no previous list")
+	}
+
+	s.current = s.previous[len(s.previous)-1]
+	s.previous = s.previous[:len(s.previous)-1]
+
+	return s.print()
+}
+
+// show makes list current and prints it.
+func (s *session) show(v view) error {
+	if s.current.title != "" {
+		s.previous = append(s.previous, s.current)
+	}
+
+	s.current = v
+
+	return s.print()
+}
+
+func (s *session) print() error {
+	if s.current.title == "" {
+		return errors.New("no list yet")
+	}
+
+	_, err := fmt.Fprintln(s.out, s.current.title)
+	if err != nil {
+		return err
+	}
+
+	for i, host := range s.current.hosts {
+		_, err := fmt.Fprintf(s.out, "%4d  %s\n", i+1, host)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) printHistory() error {
+	for i, line := range s.history {
+		_, err := fmt.Fprintf(s.out, "%4d  %s\n", i+1, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) visit(from string, to string) {
+	s.links[link{from: from, to: to}] = struct{}{}
+	s.hosts[from] = struct{}{}
+	s.hosts[to] = struct{}{}
+}
+
+// export writes visited links sorted by hosts, .dot files are written in Graphviz format and others as CSV.
+func (s *session) export(fileName string) error {
+	links := slices.SortedFunc(maps.Keys(s.links), func(a, b link) int {
+		if c := strings.Compare(a.from, b.from); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.to, b.to)
+	})
+
+	f, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	if filepath.Ext(fileName) == ".dot" {
+		err = writeDot(f, links)
+	} else {
+		err = writeLinksCSV(f, links)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error writing file %q: %w", fileName, err)
+	}
+
+	_, err = fmt.Fprintf(s.out, "exported %d links between %d hosts to %s\n", len(links), len(s.hosts), fileName)
+
+	return err
+}
+
+func writeDot(w io.Writer, links []link) error {
+	_, err := io.WriteString(w, "digraph links {\n")
+	if err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		_, err := fmt.Fprintf(w, "  %q -> %q;\n", l.from, l.to)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, "}\n")
+
+	return err
+}
+
+func writeLinksCSV(w io.Writer, links []link) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"from", "to"})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		err := writer.Write([]string{l.from, l.to})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+//nolint:gochecknoglobals
+var replCommands = []string{"back", "common", "exit", "export", "help", "history", "in", "ls", "out", "path", "sub"}
+
+// complete completes the word before cursor, the first word is completed with commands
+// and others with hosts of the current list and visited hosts.
+// Ambiguous word is extended to the longest common prefix of candidates.
+func (s *session) complete(line string, pos int) (string, int, bool) {
+	start := strings.LastIndex(line[:pos], " ") + 1
+	word := line[start:pos]
+
+	candidates := replCommands
+	if strings.TrimSpace(line[:start]) != "" {
+		candidates = slices.Concat(s.current.hosts, slices.Sorted(maps.Keys(s.hosts)))
+	}
+
+	prefix := ""
+	found := false
+
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate, word) {
+			continue
+		}
+
+		if !found {
+			prefix, found = candidate, true
+
+			continue
+		}
+
+		prefix = commonPrefix(prefix, candidate)
+	}
+
+	if !found || len(prefix) == len(word) {
+		return "", 0, false
+	}
+
+	return line[:start] + prefix + line[pos:], start + len(prefix), true
+}
+
+func commonPrefix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return a[:n]
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFile = "part-00000.txt"
+
+// newTestSession writes graph a.com -> b.com, blog.a.com; b.com -> blog.a.com, c.org; c.org -> a.com.
+func newTestSession(t *testing.T) (*session, *strings.Builder) {
+	t.Helper()
+
+	root := t.TempDir()
+	domains := []string{"com.a", "com.a.blog", "com.b", "org.c"}
+
+	vLines := make([]string, 0, len(domains))
+	for id, domain := range domains {
+		vLines = append(vLines, fmt.Sprintf("%d\t%s", id, domain))
+	}
+
+	vSize := writeTestFile(t, filepath.Join(root, vertices.Folder), vLines)
+	vOffsets := vertices.Offsets{}
+	vOffsets.Append([]vertices.Offset{
+		vertices.NewOffset(0, domains[0], 0, testFile),
+		vertices.NewOffset(vSize, domains[3], 3, testFile),
+	})
+
+	out := newTestEdges(t, filepath.Join(root, edges.EdgesFolder), []string{"0\t1", "0\t2", "2\t1", "2\t3", "3\t0"})
+	in := newTestEdges(t, filepath.Join(root, edges.EdgesReversedFolder), []string{"0\t3", "1\t0", "1\t2", "2\t0", "3\t2"})
+	v := vertices.NewVertices(file.NewGetter(filepath.Join(root, vertices.Folder)), vOffsets)
+
+	output := &strings.Builder{}
+
+	return newSession(search.NewSearcher(v, out, in), search.SearchOptions{}, output), output
+}
+
+func newTestEdges(t *testing.T, folder string, lines []string) *edges.Edges {
+	t.Helper()
+
+	size := writeTestFile(t, folder, lines)
+	first, _, _ := strings.Cut(lines[0], "\t")
+	last, _, _ := strings.Cut(lines[len(lines)-1], "\t")
+
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{edges.NewOffset(0, first, testFile), edges.NewOffset(size, last, testFile)})
+
+	return edges.NewEdges(file.NewGetter(folder), offsets)
+}
+
+func writeTestFile(t *testing.T, folder string, lines []string) int {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+
+	content := strings.Join(lines, "\n") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(folder, testFile), []byte(content), 0o644))
+
+	return len(content)
+}
+
+func TestSession_Navigation(t *testing.T) {
+	t.Parallel()
+
+	s, output := newTestSession(t)
+	input := "out a.com\nout 2\nin\nback\nsub a.com\nhistory\nexit\nout a.com\n"
+
+	require.NoError(t, s.run(t.Context(), scannerReader{scanner: bufio.NewScanner(strings.NewReader(input))}))
+
+	expected := "out a.com: 2 hosts\n" +
+		"   1  b.com\n" +
+		"   2  blog.a.com\n" +
+		"out blog.a.com: 0 hosts\n" +
+		"in blog.a.com: 2 hosts\n" +
+		"   1  a.com\n" +
+		"   2  b.com\n" +
+		"out blog.a.com: 0 hosts\n" +
+		"sub a.com: 1 hosts\n" +
+		"   1  blog.a.com\n" +
+		"   1  out a.com\n" +
+		"   2  out 2\n" +
+		"   3  in\n" +
+		"   4  back\n" +
+		"   5  sub a.com\n" +
+		"   6  history\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func TestSession_Errors(t *testing.T) {
+	t.Parallel()
+
+	s, output := newTestSession(t)
+	input := "in\nout x.com\nout 1\nback\nfly\n"
+
+	require.NoError(t, s.run(t.Context(), scannerReader{scanner: bufio.NewScanner(strings.NewReader(input))}))
+
+	expected := "error: host is missed\n" +
+		"error: host \"x.com\" is not found\n" +
+		"error: no host 1 in the current list\n" +
+		"error: no previous list\n" +
+		"error: unknown command \"fly\", type help for commands\n"
+	assert.Equal(t, expected, output.String())
+}
+
+func TestSession_PathCommonExport(t *testing.T) {
+	t.Parallel()
+
+	s, output := newTestSession(t)
+
+	require.NoError(t, s.exec(t.Context(), "path c.org blog.a.com"))
+	require.NoError(t, s.exec(t.Context(), "common a.com b.com"))
+	require.Error(t, s.exec(t.Context(), "path blog.a.com c.org 2"))
+
+	expected := "path c.org -> blog.a.com: 2 links\n" +
+		"   1  c.org\n" +
+		"   2  a.com\n" +
+		"   3  blog.a.com\n" +
+		"common a.com b.com: 1 out, 0 in\n" +
+		"   1  blog.a.com\n"
+	assert.Equal(t, expected, output.String())
+
+	require.NoError(t, s.exec(t.Context(), "out a.com"))
+
+	folder := t.TempDir()
+
+	require.NoError(t, s.exec(t.Context(), "export "+filepath.Join(folder, "links.csv")))
+	data, err := os.ReadFile(filepath.Join(folder, "links.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "from,to\na.com,b.com\na.com,blog.a.com\nb.com,blog.a.com\nc.org,a.com\n", string(data))
+
+	require.NoError(t, s.exec(t.Context(), "export "+filepath.Join(folder, "links.dot")))
+	data, err = os.ReadFile(filepath.Join(folder, "links.dot"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "digraph links {\n  \"a.com\" -> \"b.com\";\n"), string(data))
+	assert.True(t, strings.HasSuffix(string(data), "  \"c.org\" -> \"a.com\";\n}\n"), string(data))
+}
+
+func TestSession_Complete(t *testing.T) {
+	t.Parallel()
+
+	s, _ := newTestSession(t)
+	require.NoError(t, s.exec(t.Context(), "out a.com"))
+
+	tests := []struct {
+		line     string
+		pos      int
+		expected string
+		cursor   int
+		ok       bool
+	}{
+		{line: "ou", pos: 2, expected: "out", cursor: 3, ok: true},
+		{line: "h", pos: 1},
+		{line: "in bl", pos: 5, expected: "in blog.a.com", cursor: 13, ok: true},
+		{line: "in b", pos: 4},
+		{line: "common a b.com", pos: 8, expected: "common a.com b.com", cursor: 12, ok: true},
+		{line: "out x", pos: 5},
+	}
+
+	for _, test := range tests {
+		line, pos, ok := s.complete(test.line, test.pos)
+		assert.Equal(t, test.ok, ok, test.line)
+
+		if ok {
+			assert.Equal(t, test.expected, line, test.line)
+			assert.Equal(t, test.cursor, pos, test.line)
+		}
+	}
+}
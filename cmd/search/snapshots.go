@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/dharnitski/cc-hosts/config"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+)
+
+// runDiff compares neighbours of every domain between two snapshots and writes one JSON result per line.
+func runDiff(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	snapshotsConfig := flags.String("snapshots", "", "snapshots config file, CC_HOSTS_SNAPSHOTS by default")
+	from := flags.String("from", "", "old snapshot name")
+	to := flags.String("to", "", "new snapshot name")
+	limit := flags.Int("limit", 0, "max number of neighbours compared in every direction")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		return errors.New("-from and -to are required")
+	}
+
+	registry, err := openRegistry(*snapshotsConfig)
+	if err != nil {
+		return err
+	}
+
+	fromSearcher, err := registry.Get(ctx, *from)
+	if err != nil {
+		return err
+	}
+
+	toSearcher, err := registry.Get(ctx, *to)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(stdout)
+
+	for _, domain := range flags.Args() {
+		result, err := search.Diff(ctx, fromSearcher, toSearcher, domain, search.SearchOptions{Limit: *limit})
+		if err != nil {
+			return err
+		}
+
+		if result == nil {
+			log.Printf("domain %s is not found in both snapshots", domain)
+
+			continue
+		}
+
+		result.From = *from
+		result.To = *to
+
+		err = encoder.Encode(result)
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runHistory writes presence of every host across snapshots.
+func runHistory(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	snapshotsConfig := flags.String("snapshots", "", "snapshots config file, CC_HOSTS_SNAPSHOTS by default")
+	names := flags.String("names", "", "comma separated snapshot names in time order, all snapshots by release date by default")
+	format := flags.String("format", "json", "output format: json or csv")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format: %q", *format)
+	}
+
+	registry, err := openRegistry(*snapshotsConfig)
+	if err != nil {
+		return err
+	}
+
+	snapshotNames := config.SplitList(*names)
+
+	encoder := json.NewEncoder(stdout)
+
+	if *format == "csv" {
+		err = snapshots.WriteCSVHeader(stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, host := range flags.Args() {
+		history, err := registry.History(ctx, host, snapshotNames)
+		if err != nil {
+			return err
+		}
+
+		if *format == "csv" {
+			err = history.WriteCSVRows(stdout)
+		} else {
+			err = encoder.Encode(history)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestSnapshot writes graph a.com -> b.com with offsets and returns snapshots config file.
+func writeTestSnapshot(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	offsetsFolder := filepath.Join(root, offsets.Folder)
+	require.NoError(t, os.MkdirAll(offsetsFolder, 0o755))
+
+	vSize := writeTestFile(t, filepath.Join(root, vertices.Folder), []string{"0\tcom.a", "1\tcom.b"})
+	vOffsets := vertices.Offsets{}
+	vOffsets.Append([]vertices.Offset{
+		vertices.NewOffset(0, "com.a", 0, testFile),
+		vertices.NewOffset(vSize, "com.b", 1, testFile),
+	})
+	require.NoError(t, vOffsets.Save(filepath.Join(offsetsFolder, offsets.VerticesOffsetsFile)))
+
+	for folder, offsetsFile := range map[string]string{
+		edges.EdgesFolder:         offsets.EdgesOffsetsFile,
+		edges.EdgesReversedFolder: offsets.EdgesReversedOffsetFile,
+	} {
+		from, to := "0", "1"
+		if folder == edges.EdgesReversedFolder {
+			from, to = to, from
+		}
+
+		size := writeTestFile(t, filepath.Join(root, folder), []string{from + "\t" + to})
+		eOffsets := edges.Offsets{}
+		eOffsets.Append([]edges.Offset{edges.NewOffset(0, from, testFile), edges.NewOffset(size, from, testFile)})
+		require.NoError(t, eOffsets.Save(filepath.Join(offsetsFolder, offsetsFile)))
+	}
+
+	config := filepath.Join(root, "snapshots.json")
+	content := `{"default": "2024", "snapshots": {"2024": {"location": "` + root + `", "offsets": "` + offsetsFolder + `"}}}`
+	require.NoError(t, os.WriteFile(config, []byte(content), 0o644))
+
+	return config
+}
+
+func TestRunHistory_CSV(t *testing.T) {
+	t.Parallel()
+
+	config := writeTestSnapshot(t)
+
+	output := &strings.Builder{}
+	err := runHistory(t.Context(), []string{"-snapshots", config, "-names", " 2024,", "-format", "csv", "a.com", "b.com"}, output)
+	require.NoError(t, err)
+	// header is written once for all hosts
+	assert.Equal(t, "host,snapshot,present,id,out_degree,in_degree\n"+
+		"a.com,2024,true,0,1,0\n"+
+		"b.com,2024,true,1,0,1\n", output.String())
+}
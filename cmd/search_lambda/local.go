@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/dharnitski/cc-hosts/api"
+)
+
+const (
+	// stage of emulated API Gateway, it is passed in request context
+	localStage = "local"
+	// max size of request body, API Gateway limit is 10 MB
+	maxBodySize = 10 << 20
+)
+
+// GatewayFunc handles API Gateway proxy request, HandleGateway in production.
+type GatewayFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// newLocalHandler emulates API Gateway REST API with Lambda proxy integration.
+// Every route is a resource, requests are converted into proxy events and responses are written back.
+func newLocalHandler(routes []api.Route, gateway GatewayFunc) http.Handler {
+	mux := http.NewServeMux()
+
+	for _, route := range routes {
+		mux.HandleFunc(route.Method+" "+route.Pattern, func(w http.ResponseWriter, r *http.Request) {
+			pathParameters := make(map[string]string)
+			for _, name := range route.Params() {
+				pathParameters[name] = r.PathValue(name)
+			}
+
+			request, err := toProxyRequest(r, route.Pattern, pathParameters)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.As(err, new(*http.MaxBytesError)) {
+					status = http.StatusRequestEntityTooLarge
+				}
+
+				writeGatewayError(w, status, err.Error())
+
+				return
+			}
+
+			response, err := gateway(r.Context(), request)
+			if err != nil {
+				// failed invocation, API Gateway hides the error from client
+				log.Printf("error invoking handler for %s %s: %v", r.Method, r.URL.Path, err)
+				writeGatewayError(w, http.StatusBadGateway, "Internal server error")
+
+				return
+			}
+
+			err = writeProxyResponse(w, response)
+			if err != nil {
+				log.Printf("error writing response: %v", err)
+			}
+		})
+	}
+
+	// API Gateway answers unknown resources and methods this way
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		writeGatewayError(w, http.StatusForbidden, "Missing Authentication Token")
+	})
+
+	return mux
+}
+
+// toProxyRequest converts HTTP request into proxy event of resource.
+// Text bodies are passed as is and others are base64 encoded as API Gateway does for binary media types.
+func toProxyRequest(r *http.Request, resource string, pathParameters map[string]string) (events.APIGatewayProxyRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, fmt.Errorf("error reading body: %w", err)
+	}
+
+	request := events.APIGatewayProxyRequest{
+		Resource:                        resource,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         make(map[string]string, len(r.Header)),
+		MultiValueHeaders:               make(map[string][]string, len(r.Header)),
+		QueryStringParameters:           make(map[string]string),
+		MultiValueQueryStringParameters: make(map[string][]string),
+		PathParameters:                  pathParameters,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage:            localStage,
+			RequestID:        strconv.FormatInt(time.Now().UnixNano(), 36),
+			ResourcePath:     resource,
+			Path:             "/" + localStage + r.URL.Path,
+			HTTPMethod:       r.Method,
+			RequestTimeEpoch: time.Now().UnixMilli(),
+			Identity:         events.APIGatewayRequestIdentity{SourceIP: sourceIP(r)},
+		},
+	}
+
+	for name, values := range r.Header {
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+
+	// last value wins in single value parameters
+	for name, values := range r.URL.Query() {
+		request.QueryStringParameters[name] = values[len(values)-1]
+		request.MultiValueQueryStringParameters[name] = values
+	}
+
+	if len(body) > 0 {
+		if isText(r.Header.Get("Content-Type")) {
+			request.Body = string(body)
+		} else {
+			request.Body = base64.StdEncoding.EncodeToString(body)
+			request.IsBase64Encoded = true
+		}
+	}
+
+	return request, nil
+}
+
+// isText reports whether content type is passed to Lambda without encoding.
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func writeProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) error {
+	// Lambda response without status code is invalid
+	if response.StatusCode == 0 {
+		writeGatewayError(w, http.StatusBadGateway, "Internal server error")
+
+		return errors.New("response has no status code")
+	}
+
+	body := []byte(response.Body)
+
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			writeGatewayError(w, http.StatusBadGateway, "Internal server error")
+
+			return fmt.Errorf("invalid base64 body: %w", err)
+		}
+
+		body = decoded
+	}
+
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+
+	w.WriteHeader(response.StatusCode)
+
+	_, err := w.Write(body)
+
+	return err
+}
+
+func writeGatewayError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	_, err := fmt.Fprintf(w, "{\"message\":%q}", message)
+	if err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+// serveLocal serves emulator on addr until ctx is canceled.
+func serveLocal(ctx context.Context, addr string, gateway GatewayFunc) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           newLocalHandler(handler.Routes(), gateway),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		log.Printf("emulating API Gateway on %s", addr)
+
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("error serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+
+	return nil
+}
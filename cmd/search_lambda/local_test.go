@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoutes(t *testing.T) []api.Route {
+	t.Helper()
+
+	registry, err := snapshots.NewRegistry(snapshots.DefaultConfig(), snapshots.NewGetter)
+	require.NoError(t, err)
+
+	return api.New(registry).Routes()
+}
+
+func serve(t *testing.T, handler http.Handler, request *http.Request) (*http.Response, string) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+
+	return response, string(body)
+}
+
+func TestLocalHandler_Request(t *testing.T) {
+	t.Parallel()
+
+	var received events.APIGatewayProxyRequest
+
+	gateway := func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		received = request
+
+		return events.APIGatewayProxyResponse{
+			StatusCode:        http.StatusOK,
+			Headers:           map[string]string{"Content-Type": "application/json"},
+			MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+			Body:              `{"target":"a.com"}`,
+		}, nil
+	}
+
+	handler := newLocalHandler(newTestRoutes(t), gateway)
+
+	request := httptest.NewRequest(http.MethodGet, "/domain/a.com?snapshot=old&snapshot=new", nil)
+	request.Header.Set("Accept", "application/json")
+
+	response, body := serve(t, handler, request)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `{"target":"a.com"}`, body)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"a=1", "b=2"}, response.Header.Values("Set-Cookie"))
+
+	assert.Equal(t, "/domain/{domain}", received.Resource)
+	assert.Equal(t, "/domain/a.com", received.Path)
+	assert.Equal(t, http.MethodGet, received.HTTPMethod)
+	assert.Equal(t, map[string]string{"domain": "a.com"}, received.PathParameters)
+	assert.Equal(t, "new", received.QueryStringParameters["snapshot"])
+	assert.Equal(t, []string{"old", "new"}, received.MultiValueQueryStringParameters["snapshot"])
+	assert.Equal(t, "application/json", received.Headers["Accept"])
+	assert.Equal(t, localStage, received.RequestContext.Stage)
+	assert.Equal(t, "/domain/{domain}", received.RequestContext.ResourcePath)
+	assert.Empty(t, received.Body)
+}
+
+func TestLocalHandler_Body(t *testing.T) {
+	t.Parallel()
+
+	var received events.APIGatewayProxyRequest
+
+	gateway := func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		received = request
+
+		return events.APIGatewayProxyResponse{
+			StatusCode:      http.StatusOK,
+			Body:            base64.StdEncoding.EncodeToString([]byte("binary")),
+			IsBase64Encoded: true,
+		}, nil
+	}
+
+	handler := newLocalHandler(newTestRoutes(t), gateway)
+
+	request := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(`{"domains":["a.com"]}`))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	response, body := serve(t, handler, request)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "binary", body)
+	assert.Equal(t, `{"domains":["a.com"]}`, received.Body)
+	assert.False(t, received.IsBase64Encoded)
+
+	request = httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader("gzip"))
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	_, _ = serve(t, handler, request)
+	assert.True(t, received.IsBase64Encoded)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("gzip")), received.Body)
+}
+
+func TestLocalHandler_BodyErrors(t *testing.T) {
+	t.Parallel()
+
+	gateway := func(_ context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	handler := newLocalHandler(newTestRoutes(t), gateway)
+
+	request := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(strings.Repeat("a", maxBodySize+1)))
+	response, _ := serve(t, handler, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+
+	// body which can not be read is not too large
+	request = httptest.NewRequest(http.MethodPost, "/domains", iotest.ErrReader(errors.New("connection reset")))
+	response, _ = serve(t, handler, request)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestLocalHandler_GatewayErrors(t *testing.T) {
+	t.Parallel()
+
+	gateway := func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if request.PathParameters["domain"] == "empty.com" {
+			return events.APIGatewayProxyResponse{}, nil
+		}
+
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, errors.New("failed")
+	}
+
+	handler := newLocalHandler(newTestRoutes(t), gateway)
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		body   string
+	}{
+		{method: http.MethodGet, target: "/domain/a.com", status: http.StatusBadGateway, body: `{"message":"Internal server error"}`},
+		{method: http.MethodGet, target: "/domain/empty.com", status: http.StatusBadGateway, body: `{"message":"Internal server error"}`},
+		{method: http.MethodGet, target: "/unknown", status: http.StatusForbidden, body: `{"message":"Missing Authentication Token"}`},
+		{method: http.MethodDelete, target: "/domain/a.com", status: http.StatusForbidden, body: `{"message":"Missing Authentication Token"}`},
+	}
+
+	for _, test := range tests {
+		response, body := serve(t, handler, httptest.NewRequest(test.method, test.target, nil))
+		assert.Equal(t, test.status, response.StatusCode, test.target)
+		assert.Equal(t, test.body, body, test.target)
+	}
+}
+
+//nolint:paralleltest // replaces global handler
+func TestLocalHandler_Gateway(t *testing.T) {
+	cfg := snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": {Location: t.TempDir()}},
+	}
+
+	registry, err := snapshots.NewRegistry(cfg, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	handler = api.New(registry)
+
+	local := newLocalHandler(handler.Routes(), HandleGateway)
+
+	response, body := serve(t, local, httptest.NewRequest(http.MethodGet, "/domain/a.com?snapshot=missed", nil))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Contains(t, body, "unknown snapshot")
+
+	response, _ = serve(t, local, httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
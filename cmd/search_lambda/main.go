@@ -2,107 +2,163 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/dharnitski/cc-hosts/access/aws"
-	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/config"
 	"github.com/dharnitski/cc-hosts/search"
-	"github.com/dharnitski/cc-hosts/vertices"
 )
 
-var searcher *search.Searcher //nolint:gochecknoglobals
+var handler *api.API //nolint:gochecknoglobals
 
 type Request struct {
 	Domain string `json:"domain"`
+	// Snapshot is snapshot name, default snapshot when empty
+	Snapshot string `json:"snapshot,omitempty"`
 }
 
+// BatchRequest is the body of POST /domains request.
+type BatchRequest = api.BatchRequest
+
+// HandleRequest serves direct invocation of single domain, result is null when domain is not in the graph.
 func HandleRequest(ctx context.Context, event *Request) (*search.Result, error) {
 	if event == nil {
 		return &search.Result{}, nil
 	}
 
-	return searcher.GetTargets(ctx, event.Domain)
+	domain, err := api.Host(event.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	searcher, err := handler.Registry().Get(ctx, event.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.GetTargets(ctx, domain)
 }
 
+// HandleGateway routes API Gateway request by resource to API handler.
+// GET requests with unknown resource and domain path parameter are served as GET /domain/{domain}.
+// Known resource with other method is answered with 405, other unknown requests with 404.
+// Server errors are logged and answered with JSON error, they are not returned to Lambda runtime.
 func HandleGateway(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	domain, ok := request.PathParameters["domain"]
+	route, ok := handler.Find(request.HTTPMethod, request.Resource)
 	if !ok {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       "Missing domain parameter in path",
-		}, nil
+		response, found := fallbackRoute(request)
+		if !found {
+			return toGatewayResponse(response), nil
+		}
+
+		route, _ = handler.Find(http.MethodGet, "/domain/{domain}")
 	}
 
-	response, err := searcher.GetTargets(ctx, domain)
-	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       err.Error(),
-		}, err
+	response := serveRoute(ctx, route, request.PathParameters, request.QueryStringParameters, request.Headers,
+		request.Body, request.IsBase64Encoded)
+
+	return toGatewayResponse(response), nil
+}
+
+// fallbackRoute checks request without route, it reports whether request is served as GET /domain/{domain}.
+// Error response is returned otherwise.
+func fallbackRoute(request events.APIGatewayProxyRequest) (api.Response, bool) {
+	for _, route := range handler.Routes() {
+		if route.Pattern == request.Resource {
+			message := fmt.Sprintf("method %s is not allowed for %s", request.HTTPMethod, request.Resource)
+
+			return api.ErrorResponse(http.StatusMethodNotAllowed, api.CodeMethodNotAllowed, message), false
+		}
 	}
 
-	jsonResponse, err := json.Marshal(response)
-	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       "Error marshalling response",
-		}, err
+	if request.HTTPMethod != http.MethodGet {
+		message := fmt.Sprintf("%s %s is not found", request.HTTPMethod, request.Resource)
+
+		return api.ErrorResponse(http.StatusNotFound, api.CodeNotFound, message), false
+	}
+
+	if _, found := request.PathParameters["domain"]; !found {
+		return api.ErrorResponse(http.StatusBadRequest, api.CodeBadRequest, "missing domain parameter in path"), false
 	}
 
+	return api.Response{}, true
+}
+
+func toGatewayResponse(response api.Response) events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Headers:    map[string]string{"Content-Type": "application/json"},
-		Body:       string(jsonResponse),
-	}, nil
+		StatusCode: response.StatusCode,
+		Headers:    responseHeaders(response),
+		Body:       string(response.Body),
+	}
 }
 
-func createSearcher(ctx context.Context) (*search.Searcher, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+func parseBatchRequest(request events.APIGatewayProxyRequest) (*BatchRequest, error) {
+	body, err := decodeBody(request.Body, request.IsBase64Encoded)
 	if err != nil {
 		return nil, err
 	}
 
-	eOffsets, err := edges.NewOffsets()
+	return api.ParseBatchRequest(body, api.MaxBatchSize)
+}
+
+// createAPI loads config from environment, -data flag wins over snapshots registry of environment.
+func createAPI(data string, offsetsFolder string) (*api.API, error) {
+	cfg, err := config.FromEnv()
 	if err != nil {
 		return nil, err
 	}
 
-	edgesGetter := aws.New(cfg, aws.Bucket, edges.EdgesFolder)
-	out := edges.NewEdges(edgesGetter, *eOffsets)
+	if data != "" {
+		cfg.Snapshots = ""
+		cfg.Data = data
+		cfg.Offsets = offsetsFolder
+	}
 
-	reversedOffsets, err := edges.NewOffsetsReversed()
+	err = cfg.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	revEdgesGetter := aws.New(cfg, aws.Bucket, edges.EdgesReversedFolder)
-	in := edges.NewEdges(revEdgesGetter, *reversedOffsets)
-
-	vOffsets, err := vertices.NewOffsets()
+	registry, err := cfg.Registry()
 	if err != nil {
 		return nil, err
 	}
 
-	verticesGetter := aws.New(cfg, aws.Bucket, vertices.Folder)
-	v := vertices.NewVertices(verticesGetter, *vOffsets)
-
-	searcher := search.NewSearcher(v, out, in)
-
-	return searcher, nil
+	return api.New(registry, api.WithLimits(cfg.APILimits())), nil
 }
 
 func main() {
-	var err error
+	local := flag.String("local", "", "emulate API Gateway on address instead of running in Lambda, sample: :3000")
+	data := flag.String("data", "", "local folder served as the only snapshot, sample: data")
+	offsetsFolder := flag.String("offsets", "", "offsets folder for -data, embedded offsets by default")
+	flag.Parse()
 
-	ctx := context.Background()
+	var err error
 
-	searcher, err = createSearcher(ctx)
+	handler, err = createAPI(*data, *offsetsFolder)
 	if err != nil {
 		panic(err)
 	}
 
-	lambda.Start(HandleGateway)
+	if *local == "" {
+		lambda.Start(Handle)
+
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = serveLocal(ctx, *local, HandleGateway)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchRequest(t *testing.T) {
+	t.Parallel()
+
+	batch, err := parseBatchRequest(events.APIGatewayProxyRequest{Body: `{"domains":["a.com","b.com"],"mutual":true}`})
+	require.NoError(t, err)
+	assert.Equal(t, &BatchRequest{Domains: []string{"a.com", "b.com"}, Mutual: true}, batch)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"domains":["a.com"]}`))
+	batch, err = parseBatchRequest(events.APIGatewayProxyRequest{Body: encoded, IsBase64Encoded: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.com"}, batch.Domains)
+}
+
+func TestParseBatchRequest_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		``,
+		`{"domains":[]}`,
+		`{"domains":"a.com"}`,
+	}
+
+	for _, body := range tests {
+		_, err := parseBatchRequest(events.APIGatewayProxyRequest{Body: body})
+		require.Error(t, err, body)
+	}
+}
+
+func TestHandleBatch_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	response, err := HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Resource:   "/domains",
+		Body:       "{",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleGateway_MissingDomain(t *testing.T) {
+	t.Parallel()
+
+	response, err := HandleGateway(t.Context(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.JSONEq(t, `{"status":400,"code":"bad_request","message":"missing domain parameter in path"}`, response.Body)
+}
+
+func TestHandleGateway_UnknownRoute(t *testing.T) {
+	t.Parallel()
+
+	// domain path parameter does not make other methods search
+	response, err := HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "DELETE",
+		Resource:       "/anything/{domain}",
+		PathParameters: map[string]string{"domain": "a.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.Contains(t, response.Body, `"code":"not_found"`)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "DELETE",
+		Resource:       "/domain/{domain}",
+		PathParameters: map[string]string{"domain": "a.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 405, response.StatusCode)
+	assert.Contains(t, response.Body, `"code":"method_not_allowed"`)
+}
+
+//nolint:paralleltest // replaces global registry
+func TestHandleGateway_UnknownSnapshot(t *testing.T) {
+	cfg := snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": {Location: t.TempDir()}},
+	}
+
+	registry, err := snapshots.NewRegistry(cfg, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	handler = api.New(registry)
+
+	response, err := HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		PathParameters:        map[string]string{"domain": "a.com"},
+		QueryStringParameters: map[string]string{"snapshot": "missed"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, `"code":"unknown_snapshot"`)
+	assert.Equal(t, "no-store", response.Headers["Cache-Control"])
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"domain": "a b.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, `"code":"invalid_host"`)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Resource:   "/domains",
+		Body:       `{"domains":["a.com"],"snapshot":"missed"}`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		Resource:              "/diff/{domain}",
+		PathParameters:        map[string]string{"domain": "a.com"},
+		QueryStringParameters: map[string]string{"from": "missed", "to": "a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		Resource:       "/diff/{domain}",
+		PathParameters: map[string]string{"domain": "a.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		Resource:              "/history/{domain}",
+		PathParameters:        map[string]string{"domain": "a.com"},
+		QueryStringParameters: map[string]string{"snapshots": "missed"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, err = HandleGateway(t.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		Resource:              "/history/{domain}",
+		PathParameters:        map[string]string{"domain": "a.com"},
+		QueryStringParameters: map[string]string{"format": "xml"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/dharnitski/cc-hosts/api"
+)
+
+const (
+	// version of API Gateway HTTP API and Function URL payloads
+	payloadVersion2 = "2.0"
+	// route key of HTTP API catch-all route and Function URL
+	defaultRouteKey = "$default"
+	// Function URL host is <url-id>.lambda-url.<region>.on.aws
+	functionURLDomain = ".lambda-url."
+)
+
+// EventKind is the type of Lambda event detected by Handle.
+type EventKind string
+
+const (
+	// EventGatewayV1 is API Gateway REST API proxy event
+	EventGatewayV1 EventKind = "gateway-v1"
+	// EventGatewayV2 is API Gateway HTTP API event with payload version 2.0
+	EventGatewayV2 EventKind = "gateway-v2"
+	// EventFunctionURL is Lambda Function URL event
+	EventFunctionURL EventKind = "function-url"
+	// EventDirect is JSON invocation of single domain
+	EventDirect EventKind = "direct"
+	// EventDirectBatch is JSON invocation of many domains, payload is BatchRequest
+	EventDirectBatch EventKind = "direct-batch"
+)
+
+// ErrUnknownEvent is returned for payloads Handle does not recognize.
+var ErrUnknownEvent = errors.New("unknown event")
+
+// probe has fields telling event kinds apart.
+type probe struct {
+	Version        string          `json:"version"`
+	HTTPMethod     string          `json:"httpMethod"`
+	Domain         string          `json:"domain"`
+	Domains        json.RawMessage `json:"domains"`
+	RequestContext struct {
+		DomainName string `json:"domainName"`
+		HTTP       struct {
+			Method string `json:"method"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// detect returns kind of event payload.
+func detect(payload []byte) (EventKind, error) {
+	var p probe
+
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnknownEvent, err)
+	}
+
+	switch {
+	case p.HTTPMethod != "":
+		return EventGatewayV1, nil
+	case p.Version == payloadVersion2 && p.RequestContext.HTTP.Method != "":
+		if strings.Contains(p.RequestContext.DomainName, functionURLDomain) {
+			return EventFunctionURL, nil
+		}
+
+		return EventGatewayV2, nil
+	case len(p.Domains) > 0:
+		return EventDirectBatch, nil
+	case p.Domain != "":
+		return EventDirect, nil
+	default:
+		return "", ErrUnknownEvent
+	}
+}
+
+// Handle is Lambda entry point, it detects event kind and serves it with matching handler.
+// One function can be behind REST API, HTTP API and Function URL or be invoked directly.
+func Handle(ctx context.Context, payload json.RawMessage) (any, error) {
+	kind, err := detect(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case EventGatewayV1:
+		var request events.APIGatewayProxyRequest
+
+		err = json.Unmarshal(payload, &request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event: %w", kind, err)
+		}
+
+		return HandleGateway(ctx, request)
+	case EventGatewayV2:
+		var request events.APIGatewayV2HTTPRequest
+
+		err = json.Unmarshal(payload, &request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event: %w", kind, err)
+		}
+
+		return HandleHTTPAPI(ctx, request)
+	case EventFunctionURL:
+		var request events.LambdaFunctionURLRequest
+
+		err = json.Unmarshal(payload, &request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event: %w", kind, err)
+		}
+
+		return HandleFunctionURL(ctx, request)
+	case EventDirectBatch:
+		return HandleBatch(ctx, payload)
+	default:
+		var request Request
+
+		err = json.Unmarshal(payload, &request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event: %w", kind, err)
+		}
+
+		return HandleRequest(ctx, &request)
+	}
+}
+
+// HandleHTTPAPI serves API Gateway HTTP API event.
+// Route key names the route, requests of $default route are matched by path.
+func HandleHTTPAPI(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	method := request.RequestContext.HTTP.Method
+	path := request.RawPath
+
+	// raw path of named stage starts with stage name
+	if stage := request.RequestContext.Stage; stage != "" && stage != defaultRouteKey {
+		path = strings.TrimPrefix(path, "/"+stage)
+	}
+
+	var response api.Response
+
+	if request.RouteKey == defaultRouteKey {
+		response = serveV2(ctx, method, path, request.QueryStringParameters, request.Headers,
+			request.Body, request.IsBase64Encoded)
+	} else {
+		_, resource, _ := strings.Cut(request.RouteKey, " ")
+
+		route, ok := handler.Find(method, resource)
+		if !ok {
+			response = api.ErrorResponse(http.StatusNotFound, api.CodeNotFound, fmt.Sprintf("no route for %s", request.RouteKey))
+		} else {
+			response = serveRoute(ctx, route, request.PathParameters, request.QueryStringParameters, request.Headers,
+				request.Body, request.IsBase64Encoded)
+		}
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: response.StatusCode,
+		Headers:    responseHeaders(response),
+		Body:       string(response.Body),
+	}, nil
+}
+
+// HandleFunctionURL serves Lambda Function URL event, routes are matched by path.
+func HandleFunctionURL(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	response := serveV2(ctx, request.RequestContext.HTTP.Method, request.RawPath, request.QueryStringParameters,
+		request.Headers, request.Body, request.IsBase64Encoded)
+
+	return events.LambdaFunctionURLResponse{
+		StatusCode: response.StatusCode,
+		Headers:    responseHeaders(response),
+		Body:       string(response.Body),
+	}, nil
+}
+
+// HandleBatch serves direct invocation with BatchRequest payload, invalid requests fail invocation.
+func HandleBatch(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+	route, _ := handler.Find(http.MethodPost, "/domains")
+
+	response, err := route.Handler(ctx, api.Request{Body: payload})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var apiErr api.Error
+
+		err = json.Unmarshal(response.Body, &apiErr)
+		if err != nil {
+			return nil, fmt.Errorf("batch failed with status %d", response.StatusCode)
+		}
+
+		return nil, fmt.Errorf("%s: %s", apiErr.Code, apiErr.Message)
+	}
+
+	return response.Body, nil
+}
+
+// serveV2 matches route by method and path of payload version 2.0 event.
+func serveV2(ctx context.Context, method string, path string, query map[string]string, headers map[string]string,
+	body string, base64Encoded bool,
+) api.Response {
+	route, pathParameters, ok := match(handler.Routes(), method, path)
+	if !ok {
+		return api.ErrorResponse(http.StatusNotFound, api.CodeNotFound, fmt.Sprintf("no route for %s %s", method, path))
+	}
+
+	return serveRoute(ctx, route, pathParameters, query, headers, body, base64Encoded)
+}
+
+// match finds route of method and path and returns its path parameters.
+func match(routes []api.Route, method string, path string) (api.Route, map[string]string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, route := range routes {
+		if route.Method != method {
+			continue
+		}
+
+		patternParts := strings.Split(strings.Trim(route.Pattern, "/"), "/")
+		if len(patternParts) != len(parts) {
+			continue
+		}
+
+		params := make(map[string]string)
+		matched := true
+
+		for i, part := range patternParts {
+			if name, ok := strings.CutPrefix(part, "{"); ok {
+				value, err := url.PathUnescape(parts[i])
+				if err != nil || value == "" {
+					matched = false
+
+					break
+				}
+
+				params[strings.TrimSuffix(name, "}")] = value
+			} else if part != parts[i] {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return route, params, true
+		}
+	}
+
+	return api.Route{}, nil, false
+}
+
+// serveRoute calls route handler with event parameters, server errors are logged.
+// Path parameters win over query parameters with the same name.
+func serveRoute(ctx context.Context, route api.Route, pathParameters map[string]string, query map[string]string,
+	headers map[string]string, body string, base64Encoded bool,
+) api.Response {
+	decoded, err := decodeBody(body, base64Encoded)
+	if err != nil {
+		return api.ErrorResponse(http.StatusBadRequest, api.CodeBadRequest, err.Error())
+	}
+
+	params := make(map[string]string, len(query)+len(pathParameters))
+	maps.Copy(params, query)
+	maps.Copy(params, pathParameters)
+
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		canonical[http.CanonicalHeaderKey(name)] = value
+	}
+
+	response, err := route.Handler(ctx, api.Request{Params: params, Headers: canonical, Body: decoded})
+	if err != nil {
+		log.Printf("error serving %s %s: %v", route.Method, route.Pattern, err)
+	}
+
+	return response
+}
+
+// decodeBody returns request body, API Gateway and Function URL encode binary bodies with base64.
+func decodeBody(body string, base64Encoded bool) ([]byte, error) {
+	if !base64Encoded {
+		return []byte(body), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 body: %w", err)
+	}
+
+	return decoded, nil
+}
+
+func responseHeaders(response api.Response) map[string]string {
+	headers := make(map[string]string, len(response.Headers)+1)
+	maps.Copy(headers, response.Headers)
+
+	if response.ContentType != "" {
+		headers["Content-Type"] = response.ContentType
+	}
+
+	return headers
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useFixtureGraph serves fixtures/graph: a.com links blog.a.com and b.com, b.com links blog.a.com.
+func useFixtureGraph(t *testing.T) {
+	t.Helper()
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default: "fixture",
+		Snapshots: map[string]snapshots.Snapshot{
+			"fixture": {Location: filepath.Join("fixtures", "graph"), Offsets: filepath.Join("fixtures", "graph", "offsets")},
+		},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	handler = api.New(registry)
+}
+
+// invoke passes recorded event from fixtures/events to Handle.
+func invoke(t *testing.T, name string) (any, error) {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("fixtures", "events", name))
+	require.NoError(t, err)
+
+	return Handle(t.Context(), payload)
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]EventKind{
+		"gateway-v1-domain.json":        EventGatewayV1,
+		"gateway-v1-batch.json":         EventGatewayV1,
+		"gateway-v2-domain.json":        EventGatewayV2,
+		"gateway-v2-default-route.json": EventGatewayV2,
+		"function-url-domain.json":      EventFunctionURL,
+		"function-url-batch.json":       EventFunctionURL,
+		"direct-domain.json":            EventDirect,
+		"direct-batch.json":             EventDirectBatch,
+	}
+
+	for name, expected := range tests {
+		payload, err := os.ReadFile(filepath.Join("fixtures", "events", name))
+		require.NoError(t, err)
+
+		kind, err := detect(payload)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, kind, name)
+	}
+
+	for _, payload := range []string{`{}`, `{"domain":""}`, `[]`, `"a.com"`} {
+		_, err := detect([]byte(payload))
+		require.ErrorIs(t, err, ErrUnknownEvent, payload)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	routes := api.New(nil).Routes()
+
+	route, params, ok := match(routes, "GET", "/path/a.com/b%2Ecom")
+	require.True(t, ok)
+	assert.Equal(t, "/path/{from}/{to}", route.Pattern)
+	assert.Equal(t, map[string]string{"from": "a.com", "to": "b.com"}, params)
+
+	route, params, ok = match(routes, "POST", "/domains/")
+	require.True(t, ok)
+	assert.Equal(t, "/domains", route.Pattern)
+	assert.Empty(t, params)
+
+	for _, path := range []string{"/", "/domain", "/domain/", "/domain/a.com/b.com", "/unknown/a.com"} {
+		_, _, ok = match(routes, "GET", path)
+		assert.False(t, ok, path)
+	}
+
+	_, _, ok = match(routes, "DELETE", "/domain/a.com")
+	assert.False(t, ok)
+}
+
+//nolint:paralleltest // replaces global registry
+func TestHandle_GatewayV1(t *testing.T) {
+	useFixtureGraph(t)
+
+	response, err := invoke(t, "gateway-v1-domain.json")
+	require.NoError(t, err)
+	require.IsType(t, events.APIGatewayProxyResponse{}, response)
+
+	proxy := response.(events.APIGatewayProxyResponse) //nolint:forcetypeassert // checked above
+	assert.Equal(t, 200, proxy.StatusCode)
+	assert.Equal(t, "application/json", proxy.Headers["Content-Type"])
+	assert.NotEmpty(t, proxy.Headers["ETag"])
+
+	var result api.DomainResult
+	require.NoError(t, json.Unmarshal([]byte(proxy.Body), &result))
+	assert.Equal(t, []string{"b.com", "blog.a.com"}, result.Out)
+	assert.Empty(t, result.In)
+
+	response, err = invoke(t, "gateway-v1-batch.json")
+	require.NoError(t, err)
+
+	proxy = response.(events.APIGatewayProxyResponse) //nolint:forcetypeassert // Handle returns response of event kind
+	assert.Equal(t, 200, proxy.StatusCode)
+
+	var results []search.BatchResult
+	require.NoError(t, json.Unmarshal([]byte(proxy.Body), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"blog.a.com"}, results[1].Result.Out)
+}
+
+//nolint:paralleltest // replaces global registry
+func TestHandle_GatewayV2(t *testing.T) {
+	useFixtureGraph(t)
+
+	response, err := invoke(t, "gateway-v2-domain.json")
+	require.NoError(t, err)
+	require.IsType(t, events.APIGatewayV2HTTPResponse{}, response)
+
+	httpResponse := response.(events.APIGatewayV2HTTPResponse) //nolint:forcetypeassert // checked above
+	assert.Equal(t, 200, httpResponse.StatusCode)
+
+	var result api.DomainResult
+	require.NoError(t, json.Unmarshal([]byte(httpResponse.Body), &result))
+	assert.Equal(t, []string{"b.com", "blog.a.com"}, result.Out)
+
+	// $default route of prod stage is matched by path without stage
+	response, err = invoke(t, "gateway-v2-default-route.json")
+	require.NoError(t, err)
+
+	httpResponse = response.(events.APIGatewayV2HTTPResponse) //nolint:forcetypeassert // Handle returns response of event kind
+	assert.Equal(t, 200, httpResponse.StatusCode)
+	assert.JSONEq(t, `{"target":"a.com","subdomains":["blog.a.com"]}`, httpResponse.Body)
+	assert.NotEqual(t, `W/"0000"`, httpResponse.Headers["ETag"])
+
+	response, err = HandleHTTPAPI(t.Context(), events.APIGatewayV2HTTPRequest{
+		RouteKey:       "GET /unknown",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 404, response.(events.APIGatewayV2HTTPResponse).StatusCode) //nolint:forcetypeassert // typed handler
+}
+
+//nolint:paralleltest // replaces global registry
+func TestHandle_FunctionURL(t *testing.T) {
+	useFixtureGraph(t)
+
+	response, err := invoke(t, "function-url-domain.json")
+	require.NoError(t, err)
+	require.IsType(t, events.LambdaFunctionURLResponse{}, response)
+
+	urlResponse := response.(events.LambdaFunctionURLResponse) //nolint:forcetypeassert // checked above
+	assert.Equal(t, 200, urlResponse.StatusCode)
+
+	var result api.DomainResult
+	require.NoError(t, json.Unmarshal([]byte(urlResponse.Body), &result))
+	assert.Equal(t, "b.com", result.Target)
+	assert.Equal(t, []string{"a.com"}, result.In)
+	assert.Empty(t, result.Out)
+
+	// base64 body of binary content type
+	response, err = invoke(t, "function-url-batch.json")
+	require.NoError(t, err)
+
+	urlResponse = response.(events.LambdaFunctionURLResponse) //nolint:forcetypeassert // Handle returns response of event kind
+	assert.Equal(t, 200, urlResponse.StatusCode)
+
+	var results []search.BatchResult
+	require.NoError(t, json.Unmarshal([]byte(urlResponse.Body), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, "missed.com", results[1].Domain)
+	assert.Nil(t, results[1].Result)
+
+	response, err = invoke(t, "function-url-unknown.json")
+	require.NoError(t, err)
+
+	urlResponse = response.(events.LambdaFunctionURLResponse) //nolint:forcetypeassert // Handle returns response of event kind
+	assert.Equal(t, 404, urlResponse.StatusCode)
+	assert.Contains(t, urlResponse.Body, `"code":"not_found"`)
+}
+
+//nolint:paralleltest // replaces global registry
+func TestHandle_Direct(t *testing.T) {
+	useFixtureGraph(t)
+
+	response, err := invoke(t, "direct-domain.json")
+	require.NoError(t, err)
+	require.IsType(t, &search.Result{}, response)
+	assert.Equal(t, []string{"b.com", "blog.a.com"}, response.(*search.Result).Out) //nolint:forcetypeassert // checked above
+
+	response, err = invoke(t, "direct-batch.json")
+	require.NoError(t, err)
+
+	var results []search.BatchResult
+	require.NoError(t, json.Unmarshal(response.(json.RawMessage), &results)) //nolint:forcetypeassert // batch is JSON
+	require.Len(t, results, 2)
+	assert.Equal(t, "b.com", results[1].Domain)
+	assert.Empty(t, results[1].Result.Mutual)
+
+	_, err = Handle(t.Context(), []byte(`{"domains":["a.com"],"snapshot":"missed"}`))
+	require.ErrorContains(t, err, "unknown_snapshot")
+
+	_, err = Handle(t.Context(), []byte(`{"domain":"a b.com"}`))
+	require.Error(t, err)
+
+	_, err = Handle(t.Context(), []byte(`{"path":"/domain/a.com"}`))
+	require.ErrorIs(t, err, ErrUnknownEvent)
+}
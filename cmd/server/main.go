@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/config"
+)
+
+type options struct {
+	addr            string
+	origins         string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	requestTimeout  time.Duration
+	shutdownTimeout time.Duration
+	// drainDelay keeps serving with failing readiness, so load balancer stops routing before listener is closed
+	drainDelay time.Duration
+	// config is loaded from environment, data location flags override it
+	config *config.Config
+}
+
+func main() {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := options{config: cfg}
+
+	var ranks string
+
+	flag.StringVar(&opts.addr, "addr", ":8080", "listen address")
+	flag.StringVar(&cfg.Snapshots, "snapshots", cfg.Snapshots, "snapshots config file")
+	flag.StringVar(&cfg.Data, "data", cfg.Data, "local folder or s3://bucket/prefix served as the only snapshot when -snapshots is not set")
+	flag.StringVar(&cfg.Offsets, "offsets", cfg.Offsets, "offsets folder for -data location, embedded offsets by default")
+	flag.StringVar(&ranks, "ranks", strings.Join(cfg.Ranks, ","), "comma separated rank tables of -data location")
+	flag.StringVar(&opts.origins, "cors", "", "comma separated allowed CORS origins, * allows any origin")
+	flag.DurationVar(&opts.readTimeout, "read-timeout", 10*time.Second, "max duration of reading request")
+	flag.DurationVar(&opts.writeTimeout, "write-timeout", time.Minute, "max duration of writing response")
+	flag.DurationVar(&opts.requestTimeout, "request-timeout", 30*time.Second, "max duration of search, 0 disables timeout")
+	flag.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 30*time.Second, "max duration of graceful shutdown")
+	flag.DurationVar(&opts.drainDelay, "drain-delay", 5*time.Second, "duration of failing readiness before listener is closed on shutdown")
+	flag.Parse()
+
+	cfg.Ranks = config.SplitList(ranks)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = run(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves requests until ctx is canceled and waits for active requests on shutdown.
+func run(ctx context.Context, opts options) error {
+	err := opts.config.Validate()
+	if err != nil {
+		return err
+	}
+
+	registry, err := opts.config.Registry()
+	if err != nil {
+		return err
+	}
+
+	s := newServer(api.New(registry, api.WithLimits(opts.config.APILimits())), config.SplitList(opts.origins), opts.requestTimeout)
+
+	srv := &http.Server{
+		Addr:              opts.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: opts.readTimeout,
+		ReadTimeout:       opts.readTimeout,
+		WriteTimeout:      opts.writeTimeout,
+	}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		log.Printf("serving %s on %s", strings.Join(s.routes(), ", "), opts.addr)
+
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("error serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down")
+	s.ready.Store(false)
+
+	if opts.drainDelay > 0 {
+		log.Printf("draining for %s", opts.drainDelay)
+		time.Sleep(opts.drainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dharnitski/cc-hosts/api"
+)
+
+const (
+	// max size of request body, batch of 1000 domains fits into it
+	maxBodySize = 1 << 20
+	corsMethods = "GET, POST, OPTIONS"
+	corsHeaders = "Content-Type"
+)
+
+// server serves API routes with health, readiness and OpenAPI endpoints.
+type server struct {
+	api *api.API
+	// allowed CORS origins, * allows any origin
+	origins []string
+	// request timeout, 0 disables timeout
+	timeout time.Duration
+	// ready is cleared on shutdown, so load balancer stops sending requests
+	ready atomic.Bool
+}
+
+func newServer(a *api.API, origins []string, timeout time.Duration) *server {
+	s := &server{api: a, origins: origins, timeout: timeout}
+	s.ready.Store(true)
+
+	return s
+}
+
+// Handler returns handler with all routes.
+func (s *server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	for _, route := range s.api.Routes() {
+		mux.Handle(route.Method+" "+route.Pattern, s.route(route))
+	}
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, "text/plain", []byte("ok"))
+	})
+	mux.HandleFunc("GET /readyz", s.readiness)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, "application/json", api.OpenAPI)
+	})
+
+	return s.cors(mux)
+}
+
+// route converts HTTP request into API request, path parameters win over query parameters with the same name.
+func (s *server) route(route api.Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if s.timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+		}
+
+		params := make(map[string]string)
+		for name, values := range r.URL.Query() {
+			params[name] = values[0]
+		}
+
+		for _, name := range route.Params() {
+			params[name] = r.PathValue(name)
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			response := api.ErrorResponse(http.StatusRequestEntityTooLarge, api.CodeBadRequest, err.Error())
+			write(w, response.StatusCode, response.ContentType, response.Body)
+
+			return
+		}
+
+		headers := make(map[string]string, len(r.Header))
+		for name, values := range r.Header {
+			headers[name] = strings.Join(values, ", ")
+		}
+
+		response, err := route.Handler(ctx, api.Request{Params: params, Headers: headers, Body: body})
+		if err != nil {
+			log.Printf("error serving %s %s: %v", r.Method, r.URL.Path, err)
+		}
+
+		for name, value := range response.Headers {
+			w.Header().Set(name, value)
+		}
+
+		write(w, response.StatusCode, response.ContentType, response.Body)
+	})
+}
+
+// readiness reports whether default snapshot is loaded, the first call loads it.
+func (s *server) readiness(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		write(w, http.StatusServiceUnavailable, "text/plain", []byte("shutting down"))
+
+		return
+	}
+
+	_, err := s.api.Registry().Get(r.Context(), "")
+	if err != nil {
+		write(w, http.StatusServiceUnavailable, "text/plain", []byte(err.Error()))
+
+		return
+	}
+
+	write(w, http.StatusOK, "text/plain", []byte("ok"))
+}
+
+// cors adds CORS headers for allowed origins and answers preflight requests.
+func (s *server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !s.allowed(origin) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Methods", corsMethods)
+		header.Set("Access-Control-Allow-Headers", corsHeaders)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) allowed(origin string) bool {
+	return slices.ContainsFunc(s.origins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, origin)
+	})
+}
+
+func write(w http.ResponseWriter, statusCode int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(statusCode)
+
+	_, err := w.Write(body)
+	if err != nil {
+		log.Printf("error writing response: %v", err)
+	}
+}
+
+// routes returns sorted patterns of all routes, used in startup log.
+func (s *server) routes() []string {
+	patterns := make([]string, 0, len(s.api.Routes()))
+	for _, route := range s.api.Routes() {
+		patterns = append(patterns, route.Method+" "+route.Pattern)
+	}
+
+	slices.Sort(patterns)
+
+	return patterns
+}
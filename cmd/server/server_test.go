@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/config"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves default snapshot from empty folder, so snapshot can't be loaded.
+func newTestServer(t *testing.T, origins []string) *server {
+	t.Helper()
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": {Location: t.TempDir(), Offsets: t.TempDir()}},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	return newServer(api.New(registry), origins, time.Second)
+}
+
+func get(t *testing.T, handler http.Handler, request *http.Request) (*http.Response, string) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+
+	return response, string(body)
+}
+
+func TestServer_Routes(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestServer(t, nil).Handler()
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{method: http.MethodGet, target: "/healthz", status: http.StatusOK},
+		{method: http.MethodGet, target: "/openapi.json", status: http.StatusOK},
+		{method: http.MethodGet, target: "/domain/a.com?snapshot=missed", status: http.StatusBadRequest},
+		{method: http.MethodGet, target: "/subdomains/a.com?snapshot=missed", status: http.StatusBadRequest},
+		{method: http.MethodGet, target: "/path/a.com/b.com?depth=10", status: http.StatusBadRequest},
+		{method: http.MethodGet, target: "/history/a.com?format=xml", status: http.StatusBadRequest},
+		{method: http.MethodPost, target: "/domains", status: http.StatusBadRequest},
+		{method: http.MethodGet, target: "/unknown", status: http.StatusNotFound},
+		{method: http.MethodDelete, target: "/domain/a.com", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		response, body := get(t, handler, httptest.NewRequest(test.method, test.target, nil))
+		assert.Equal(t, test.status, response.StatusCode, "%s %s: %s", test.method, test.target, body)
+	}
+}
+
+func TestServer_Readiness(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t, nil)
+
+	response, _ := get(t, s.Handler(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	s.ready.Store(false)
+
+	response, body := get(t, s.Handler(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "shutting down", body)
+}
+
+func TestServer_CORS(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestServer(t, []string{"https://example.com"}).Handler()
+
+	request := httptest.NewRequest(http.MethodOptions, "/domain/a.com", nil)
+	request.Header.Set("Origin", "https://example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	response, _ := get(t, handler, request)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, "https://example.com", response.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, corsMethods, response.Header.Get("Access-Control-Allow-Methods"))
+
+	request = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	request.Header.Set("Origin", "https://other.com")
+
+	response, _ = get(t, handler, request)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestRun_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	errs := make(chan error, 1)
+
+	go func() {
+		cfg := config.Default()
+		cfg.Data = t.TempDir()
+
+		errs <- run(ctx, options{addr: "127.0.0.1:0", config: &cfg, shutdownTimeout: time.Second})
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is not stopped")
+	}
+}
+
+func TestRun_DrainDelay(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	cfg := config.Default()
+	cfg.Data = t.TempDir()
+
+	start := time.Now()
+	err := run(ctx, options{addr: "127.0.0.1:0", config: &cfg, shutdownTimeout: time.Second, drainDelay: 100 * time.Millisecond})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
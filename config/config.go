@@ -0,0 +1,377 @@
+// Package config is the runtime configuration shared by Lambda, server and command line search.
+// Values come from defaults, optional JSON file named by CC_HOSTS_CONFIG and CC_HOSTS_* environment variables,
+// later sources override earlier ones.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+)
+
+const (
+	// FileEnv names JSON config file
+	FileEnv = "CC_HOSTS_CONFIG"
+	// LegacySnapshotsEnv is snapshots registry file variable used by Lambda before config was added
+	LegacySnapshotsEnv = "SNAPSHOTS_CONFIG"
+	// LocalSnapshot is the name of snapshot created for Data location
+	LocalSnapshot = "local"
+
+	s3Scheme = "s3://"
+)
+
+// Config is the runtime configuration, sample file:
+//
+//	{
+//	  "data": "s3://common-crawl-hosts",
+//	  "s3": {"endpoint": "http://localhost:9000", "path_style": true},
+//	  "concurrency": 50,
+//	  "max_results": 1000
+//	}
+type Config struct {
+	// Snapshots is snapshots registry file, it wins over Data
+	Snapshots string `json:"snapshots,omitempty"`
+	// Data is local folder or s3://bucket/prefix served as the only snapshot
+	Data string `json:"data,omitempty"`
+	// Offsets is offsets folder of Data, embedded offsets are used when empty
+	Offsets string `json:"offsets,omitempty"`
+	// Ranks are rank tables of Data
+	Ranks []string `json:"ranks,omitempty"`
+	// Folders are names of data folders in locations
+	Folders snapshots.Folders `json:"folders"`
+	// S3 configures client of s3:// locations
+	S3 snapshots.S3Options `json:"s3"`
+	// Concurrency is max number of parallel reads of vertices, edges and ranks
+	Concurrency int `json:"concurrency"`
+	// BatchConcurrency is max number of domains searched in parallel by batch search
+	BatchConcurrency int `json:"batch_concurrency"`
+	// MaxResults is max number of neighbours in one direction
+	MaxResults int `json:"max_results"`
+	// MaxBatchSize is max number of domains in one batch request
+	MaxBatchSize int `json:"max_batch_size"`
+	// MaxLoadedSnapshots is max number of snapshots kept in memory, 0 keeps all
+	MaxLoadedSnapshots int `json:"max_loaded_snapshots"`
+	// CacheMaxAge is HTTP cache age in seconds of responses for default snapshot
+	CacheMaxAge int `json:"cache_max_age"`
+	// PinnedCacheMaxAge is HTTP cache age in seconds of responses for named snapshots
+	PinnedCacheMaxAge int `json:"pinned_cache_max_age"`
+}
+
+// Default returns config with values compiled into packages, default snapshot is served.
+func Default() Config {
+	searchLimits := search.DefaultLimits()
+	apiLimits := api.DefaultLimits()
+
+	return Config{
+		Folders:           snapshots.DefaultFolders(),
+		Concurrency:       searchLimits.Concurrency,
+		BatchConcurrency:  searchLimits.BatchConcurrency,
+		MaxResults:        searchLimits.MaxResults,
+		MaxBatchSize:      apiLimits.MaxBatchSize,
+		CacheMaxAge:       apiLimits.CacheMaxAge,
+		PinnedCacheMaxAge: apiLimits.PinnedCacheMaxAge,
+	}
+}
+
+// FromEnv loads config from process environment.
+func FromEnv() (*Config, error) {
+	return Load(os.Getenv)
+}
+
+// Load reads config file named by CC_HOSTS_CONFIG over defaults and applies environment variables.
+// Config is not validated, flags can still change it.
+func Load(getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	if fileName := getenv(FileEnv); fileName != "" {
+		err := cfg.loadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := cfg.applyEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(fileName string) error {
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(c)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %q: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// variable binds environment variable to config field.
+type variable struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+func stringVar(name string, field func(c *Config) *string) variable {
+	return variable{name: name, set: func(c *Config, value string) error {
+		*field(c) = value
+
+		return nil
+	}}
+}
+
+func intVar(name string, field func(c *Config) *int) variable {
+	return variable{name: name, set: func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer: %q", value)
+		}
+
+		*field(c) = n
+
+		return nil
+	}}
+}
+
+func boolVar(name string, field func(c *Config) *bool) variable {
+	return variable{name: name, set: func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean: %q", value)
+		}
+
+		*field(c) = b
+
+		return nil
+	}}
+}
+
+// variables are all supported environment variables.
+func variables() []variable {
+	return []variable{
+		stringVar(LegacySnapshotsEnv, func(c *Config) *string { return &c.Snapshots }),
+		stringVar("CC_HOSTS_SNAPSHOTS", func(c *Config) *string { return &c.Snapshots }),
+		stringVar("CC_HOSTS_DATA", func(c *Config) *string { return &c.Data }),
+		stringVar("CC_HOSTS_OFFSETS", func(c *Config) *string { return &c.Offsets }),
+		{name: "CC_HOSTS_RANKS", set: func(c *Config, value string) error {
+			c.Ranks = SplitList(value)
+
+			return nil
+		}},
+		stringVar("CC_HOSTS_VERTICES_FOLDER", func(c *Config) *string { return &c.Folders.Vertices }),
+		stringVar("CC_HOSTS_EDGES_FOLDER", func(c *Config) *string { return &c.Folders.Edges }),
+		stringVar("CC_HOSTS_EDGES_REVERSED_FOLDER", func(c *Config) *string { return &c.Folders.EdgesReversed }),
+		stringVar("CC_HOSTS_RANKS_FOLDER", func(c *Config) *string { return &c.Folders.Ranks }),
+		stringVar("CC_HOSTS_S3_ENDPOINT", func(c *Config) *string { return &c.S3.Endpoint }),
+		stringVar("CC_HOSTS_S3_REGION", func(c *Config) *string { return &c.S3.Region }),
+		boolVar("CC_HOSTS_S3_PATH_STYLE", func(c *Config) *bool { return &c.S3.PathStyle }),
+		stringVar("CC_HOSTS_S3_ACCESS_KEY_ID", func(c *Config) *string { return &c.S3.AccessKeyID }),
+		stringVar("CC_HOSTS_S3_SECRET_ACCESS_KEY", func(c *Config) *string { return &c.S3.SecretAccessKey }),
+		stringVar("CC_HOSTS_S3_SESSION_TOKEN", func(c *Config) *string { return &c.S3.SessionToken }),
+		boolVar("CC_HOSTS_S3_ANONYMOUS", func(c *Config) *bool { return &c.S3.Anonymous }),
+		intVar("CC_HOSTS_CONCURRENCY", func(c *Config) *int { return &c.Concurrency }),
+		intVar("CC_HOSTS_BATCH_CONCURRENCY", func(c *Config) *int { return &c.BatchConcurrency }),
+		intVar("CC_HOSTS_MAX_RESULTS", func(c *Config) *int { return &c.MaxResults }),
+		intVar("CC_HOSTS_MAX_BATCH_SIZE", func(c *Config) *int { return &c.MaxBatchSize }),
+		intVar("CC_HOSTS_MAX_LOADED_SNAPSHOTS", func(c *Config) *int { return &c.MaxLoadedSnapshots }),
+		intVar("CC_HOSTS_CACHE_MAX_AGE", func(c *Config) *int { return &c.CacheMaxAge }),
+		intVar("CC_HOSTS_PINNED_CACHE_MAX_AGE", func(c *Config) *int { return &c.PinnedCacheMaxAge }),
+	}
+}
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var errs []error
+
+	for _, v := range variables() {
+		value := strings.TrimSpace(getenv(v.name))
+		if value == "" {
+			continue
+		}
+
+		err := v.set(c, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors: %v", errs)
+	}
+
+	return nil
+}
+
+// Validate checks all values, it is called at startup so misconfiguration fails fast.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validateLocation(c.Data); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateEndpoint(c.S3.Endpoint); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateCredentials(c.S3); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, folder := range []string{c.Folders.Vertices, c.Folders.Edges, c.Folders.EdgesReversed, c.Folders.Ranks} {
+		if folder == "" || strings.Contains(folder, "..") {
+			errs = append(errs, fmt.Errorf("invalid folder: %q", folder))
+		}
+	}
+
+	limits := []struct {
+		name  string
+		value int
+		min   int
+	}{
+		{"concurrency", c.Concurrency, 1},
+		{"batch_concurrency", c.BatchConcurrency, 1},
+		{"max_results", c.MaxResults, 1},
+		{"max_batch_size", c.MaxBatchSize, 1},
+		{"max_loaded_snapshots", c.MaxLoadedSnapshots, 0},
+		{"cache_max_age", c.CacheMaxAge, 0},
+		{"pinned_cache_max_age", c.PinnedCacheMaxAge, 0},
+	}
+
+	for _, limit := range limits {
+		if limit.value < limit.min {
+			errs = append(errs, fmt.Errorf("%s is %d, min %d", limit.name, limit.value, limit.min))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %v", errs)
+	}
+
+	return nil
+}
+
+// validateLocation checks that s3:// location has bucket, local folders are checked on the first search.
+func validateLocation(location string) error {
+	bucketPath, ok := strings.CutPrefix(location, s3Scheme)
+	if !ok {
+		return nil
+	}
+
+	bucket, _, _ := strings.Cut(bucketPath, "/")
+	if bucket == "" {
+		return fmt.Errorf("no bucket in data location %q", location)
+	}
+
+	return nil
+}
+
+// validateCredentials checks that static credentials are complete and are not mixed with anonymous access.
+func validateCredentials(opts snapshots.S3Options) error {
+	static := opts.AccessKeyID != "" || opts.SecretAccessKey != "" || opts.SessionToken != ""
+
+	switch {
+	case opts.Anonymous && static:
+		return errors.New("S3 anonymous access and access key are exclusive")
+	case static && (opts.AccessKeyID == "" || opts.SecretAccessKey == ""):
+		return errors.New("S3 access key needs both access_key_id and secret_access_key")
+	default:
+		return nil
+	}
+}
+
+func validateEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid S3 endpoint %q, expected http(s)://host[:port]", endpoint)
+	}
+
+	return nil
+}
+
+// SearchLimits returns limits of snapshots loaded by Registry.
+func (c *Config) SearchLimits() search.Limits {
+	return search.Limits{
+		MaxResults:       c.MaxResults,
+		Concurrency:      c.Concurrency,
+		BatchConcurrency: c.BatchConcurrency,
+	}
+}
+
+// APILimits returns limits of API requests.
+func (c *Config) APILimits() api.Limits {
+	return api.Limits{
+		MaxResults:        c.MaxResults,
+		MaxBatchSize:      c.MaxBatchSize,
+		CacheMaxAge:       c.CacheMaxAge,
+		PinnedCacheMaxAge: c.PinnedCacheMaxAge,
+	}
+}
+
+// Registry creates snapshots registry from Snapshots file, Data location or default snapshot, in this order.
+// Folders and MaxLoadedSnapshots apply when registry file does not set them.
+func (c *Config) Registry() (*snapshots.Registry, error) {
+	var cfg snapshots.Config
+
+	switch {
+	case c.Snapshots != "":
+		loaded, err := snapshots.LoadConfigFile(c.Snapshots)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg = *loaded
+	case c.Data != "":
+		cfg = snapshots.Config{
+			Default: LocalSnapshot,
+			Snapshots: map[string]snapshots.Snapshot{
+				LocalSnapshot: {Location: c.Data, Offsets: c.Offsets, Ranks: c.Ranks},
+			},
+		}
+	default:
+		cfg = snapshots.DefaultConfig()
+	}
+
+	if cfg.Folders == (snapshots.Folders{}) {
+		cfg.Folders = c.Folders
+	}
+
+	if cfg.MaxLoaded == 0 {
+		cfg.MaxLoaded = c.MaxLoadedSnapshots
+	}
+
+	return snapshots.NewRegistry(cfg, snapshots.NewGetterFunc(c.S3), snapshots.WithLimits(c.SearchLimits()))
+}
+
+// SplitList splits comma separated list, empty items are skipped.
+// Implementation is in api, api can not import config.
+func SplitList(value string) []string {
+	return api.SplitList(value)
+}
@@ -0,0 +1,187 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/api"
+	"github.com/dharnitski/cc-hosts/config"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(name string) string {
+		return values[name]
+	}
+}
+
+func TestLoad_Default(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), *cfg)
+	assert.Equal(t, snapshots.DefaultFolders(), cfg.Folders)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(fileName, []byte(`{
+		"data": "s3://bucket/2024",
+		"folders": {"vertices": "v"},
+		"s3": {"endpoint": "http://localhost:9000", "path_style": true},
+		"concurrency": 10,
+		"max_results": 100
+	}`), 0o644))
+
+	cfg, err := config.Load(env(map[string]string{
+		config.FileEnv:          fileName,
+		"CC_HOSTS_CONCURRENCY":  "20",
+		"CC_HOSTS_RANKS":        "indegree, pagerank",
+		"CC_HOSTS_S3_REGION":    "us-west-2",
+		"CC_HOSTS_EDGES_FOLDER": "e",
+		"CC_HOSTS_S3_ANONYMOUS": "true",
+	}))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, "s3://bucket/2024", cfg.Data)
+	// environment wins over file
+	assert.Equal(t, 20, cfg.Concurrency)
+	assert.Equal(t, 100, cfg.MaxResults)
+	assert.Equal(t, []string{"indegree", "pagerank"}, cfg.Ranks)
+	assert.Equal(t, snapshots.S3Options{Endpoint: "http://localhost:9000", Region: "us-west-2", PathStyle: true, Anonymous: true},
+		cfg.S3)
+	// folders missed in file keep defaults
+	assert.Equal(t, snapshots.Folders{Vertices: "v", Edges: "e", EdgesReversed: edges.EdgesReversedFolder, Ranks: ranks.Folder},
+		cfg.Folders)
+	assert.Equal(t, search.BatchConcurrency, cfg.BatchConcurrency)
+}
+
+func TestLoad_LegacySnapshotsEnv(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(env(map[string]string{config.LegacySnapshotsEnv: "old.json"}))
+	require.NoError(t, err)
+	assert.Equal(t, "old.json", cfg.Snapshots)
+
+	cfg, err = config.Load(env(map[string]string{config.LegacySnapshotsEnv: "old.json", "CC_HOSTS_SNAPSHOTS": "new.json"}))
+	require.NoError(t, err)
+	assert.Equal(t, "new.json", cfg.Snapshots)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []map[string]string{
+		{"CC_HOSTS_CONCURRENCY": "many"},
+		{"CC_HOSTS_S3_PATH_STYLE": "maybe"},
+		{config.FileEnv: filepath.Join(t.TempDir(), "missed.json")},
+	}
+
+	for _, values := range tests {
+		_, err := config.Load(env(values))
+		require.Error(t, err, values)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"concurency": 10}`), 0o644))
+
+	_, err := config.Load(env(map[string]string{config.FileEnv: fileName}))
+	require.ErrorContains(t, err, "unknown field")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []func(c *config.Config){
+		func(c *config.Config) { c.Data = "s3://" },
+		func(c *config.Config) { c.S3.Endpoint = "localhost:9000" },
+		func(c *config.Config) { c.S3.Endpoint = "ftp://localhost" },
+		func(c *config.Config) { c.S3.AccessKeyID = "key" },
+		func(c *config.Config) { c.S3.SecretAccessKey = "secret" },
+		func(c *config.Config) { c.S3.Anonymous, c.S3.AccessKeyID, c.S3.SecretAccessKey = true, "key", "secret" },
+		func(c *config.Config) { c.Folders.Edges = "" },
+		func(c *config.Config) { c.Folders.Ranks = "../ranks" },
+		func(c *config.Config) { c.Concurrency = 0 },
+		func(c *config.Config) { c.BatchConcurrency = -1 },
+		func(c *config.Config) { c.MaxResults = 0 },
+		func(c *config.Config) { c.MaxBatchSize = 0 },
+		func(c *config.Config) { c.MaxLoadedSnapshots = -1 },
+		func(c *config.Config) { c.CacheMaxAge = -1 },
+	}
+
+	for i, change := range tests {
+		cfg := config.Default()
+		change(&cfg)
+		require.Error(t, cfg.Validate(), i)
+	}
+
+	cfg := config.Default()
+	cfg.Data = "s3://bucket"
+	cfg.S3.Endpoint = "https://storage.example.com:9000"
+	require.NoError(t, cfg.Validate())
+
+	cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey = "key", "secret"
+	require.NoError(t, cfg.Validate())
+}
+
+func TestConfig_Registry(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+
+	registry, err := cfg.Registry()
+	require.NoError(t, err)
+	assert.Equal(t, []string{snapshots.DefaultName}, registry.Names())
+
+	cfg.Data = t.TempDir()
+
+	registry, err = cfg.Registry()
+	require.NoError(t, err)
+	assert.Equal(t, []string{config.LocalSnapshot}, registry.Names())
+
+	fileName := filepath.Join(t.TempDir(), "snapshots.json")
+	require.NoError(t, os.WriteFile(fileName,
+		[]byte(`{"default": "a", "snapshots": {"a": {"location": "data"}, "b": {"location": "data"}}}`), 0o644))
+
+	// snapshots file wins over data
+	cfg.Snapshots = fileName
+
+	registry, err = cfg.Registry()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, registry.Names())
+
+	cfg.Snapshots = filepath.Join(t.TempDir(), "missed.json")
+	_, err = cfg.Registry()
+	require.Error(t, err)
+}
+
+func TestConfig_Limits(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.Concurrency = 7
+	cfg.BatchConcurrency = 3
+	cfg.MaxResults = 50
+	cfg.MaxBatchSize = 10
+	cfg.CacheMaxAge = 1
+	cfg.PinnedCacheMaxAge = 2
+
+	assert.Equal(t, search.Limits{MaxResults: 50, Concurrency: 7, BatchConcurrency: 3}, cfg.SearchLimits())
+	assert.Equal(t, api.Limits{MaxResults: 50, MaxBatchSize: 10, CacheMaxAge: 1, PinnedCacheMaxAge: 2}, cfg.APILimits())
+
+	// defaults are compiled into packages and never change
+	defaults := config.Default()
+	assert.Equal(t, search.DefaultLimits(), defaults.SearchLimits())
+	assert.Equal(t, api.DefaultLimits(), defaults.APILimits())
+}
@@ -0,0 +1,273 @@
+package edges
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is layout of edges files.
+type Format string
+
+const (
+	// FormatTSV has "from \t to" line for every edge.
+	FormatTSV Format = "tsv"
+	// FormatAdjacency has binary record for every source vertice:
+	// uvarint source id, uvarint number of targets, uvarint size of targets in bytes
+	// and sorted targets, the first target is uvarint id and the next are uvarint deltas to the previous one.
+	FormatAdjacency Format = "adjacency"
+
+	// AdjacencyExt is extension of edges files in adjacency format.
+	AdjacencyExt = ".adj"
+	// AdjacencyChunkSize is distance between offsets in adjacency files.
+	// Records are about 4 times smaller than TSV lines, so chunk keeps the same number of edges as TSV chunk.
+	AdjacencyChunkSize = FileChunkSize / 4
+)
+
+// Validate checks that format is known, empty format is TSV.
+func (f Format) Validate() error {
+	switch f {
+	case "", FormatTSV, FormatAdjacency:
+		return nil
+	}
+
+	return fmt.Errorf("unknown edges format: %q", f)
+}
+
+// AdjacencyName returns name of adjacency file converted from TSV file.
+func AdjacencyName(fileName string) string {
+	return strings.TrimSuffix(fileName, ".txt") + AdjacencyExt
+}
+
+// AdjacencyWriter writes adjacency records and offsets of chunks to find them.
+// Records have to be written in order of source id.
+type AdjacencyWriter struct {
+	writer *bufio.Writer
+	file   string
+	// bytes offset in file
+	offset          int
+	lastSavedOffset int
+	lastID          int
+	offsets         []Offset
+	record          []byte
+	targets         []byte
+}
+
+func NewAdjacencyWriter(writer io.Writer, file string) *AdjacencyWriter {
+	return &AdjacencyWriter{writer: bufio.NewWriter(writer), file: file, lastID: -1}
+}
+
+// Write saves targets of source vertice, targets have to be sorted.
+func (a *AdjacencyWriter) Write(from int, targets []int) error {
+	if from <= a.lastID {
+		return fmt.Errorf("source ID goes down: %d, previous %d", from, a.lastID)
+	}
+
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets for source ID %d", from)
+	}
+
+	if targets[0] < 0 || !slices.IsSorted(targets) {
+		return fmt.Errorf("targets of source ID %d are not sorted", from)
+	}
+
+	a.targets = a.targets[:0]
+	previous := 0
+
+	for _, target := range targets {
+		a.targets = binary.AppendUvarint(a.targets, uint64(target-previous)) //nolint:gosec
+		previous = target
+	}
+
+	a.record = binary.AppendUvarint(a.record[:0], uint64(from)) //nolint:gosec
+	a.record = binary.AppendUvarint(a.record, uint64(len(targets)))
+	a.record = binary.AppendUvarint(a.record, uint64(len(a.targets)))
+	a.record = append(a.record, a.targets...)
+
+	id := strconv.Itoa(from)
+
+	if len(a.offsets) == 0 || a.offset-a.lastSavedOffset >= AdjacencyChunkSize {
+		a.offsets = append(a.offsets, NewOffset(a.offset, id, a.file))
+		a.lastSavedOffset = a.offset
+	}
+
+	_, err := a.writer.Write(a.record)
+	if err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	a.offset += len(a.record)
+	a.lastID = from
+
+	return nil
+}
+
+// Close flushes records and saves the last offset, it does not close underlying writer.
+func (a *AdjacencyWriter) Close() error {
+	err := a.writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	if a.lastID >= 0 {
+		a.offsets = append(a.offsets, NewOffset(a.offset, strconv.Itoa(a.lastID), a.file))
+	}
+
+	return nil
+}
+
+// Offsets returns offsets of written records, the last offset is the end of file.
+func (a *AdjacencyWriter) Offsets() []Offset {
+	return a.offsets
+}
+
+// adjacencyRecord is one decoded record, targets are decoded on demand.
+type adjacencyRecord struct {
+	from    int
+	count   int
+	targets []byte
+}
+
+var errTruncatedRecord = errors.New("truncated adjacency record")
+
+// readRecord decodes record at the start of buffer and returns the rest of buffer.
+func readRecord(buffer []byte) (adjacencyRecord, []byte, error) {
+	values := [3]uint64{}
+
+	for i := range values {
+		value, n := binary.Uvarint(buffer)
+		if n <= 0 {
+			return adjacencyRecord{}, nil, errTruncatedRecord
+		}
+
+		values[i] = value
+		buffer = buffer[n:]
+	}
+
+	size := values[2]
+	if size > uint64(len(buffer)) {
+		return adjacencyRecord{}, nil, errTruncatedRecord
+	}
+
+	record := adjacencyRecord{from: int(values[0]), count: int(values[1]), targets: buffer[:size]} //nolint:gosec
+
+	return record, buffer[size:], nil
+}
+
+// each calls fn for targets in increasing order until fn returns false.
+func (r adjacencyRecord) each(fn func(id int) bool) error {
+	buffer := r.targets
+	id := 0
+
+	for range r.count {
+		delta, n := binary.Uvarint(buffer)
+		if n <= 0 {
+			return errTruncatedRecord
+		}
+
+		buffer = buffer[n:]
+		id += int(delta) //nolint:gosec
+
+		if !fn(id) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// eachRecord calls fn for records of fromID vertice in buffer.
+func eachRecord(buffer []byte, fromID string, fn func(record adjacencyRecord) (bool, error)) error {
+	id, err := strconv.Atoi(fromID)
+	if err != nil {
+		return fmt.Errorf("invalid ID: %s", fromID)
+	}
+
+	for len(buffer) > 0 {
+		record, rest, err := readRecord(buffer)
+		if err != nil {
+			return err
+		}
+
+		buffer = rest
+
+		// records are sorted and we can break after we pass fromID
+		if record.from > id {
+			break
+		}
+
+		if record.from < id {
+			continue
+		}
+
+		next, err := fn(record)
+		if err != nil || !next {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findAdjacencyEdges(buffer []byte, fromID string, filter Filter, limit int) ([]string, error) {
+	results := make([]string, 0)
+
+	err := eachRecord(buffer, fromID, func(record adjacencyRecord) (bool, error) {
+		err := record.each(func(id int) bool {
+			if filter != nil && !filter(id) {
+				return true
+			}
+
+			results = append(results, strconv.Itoa(id))
+
+			return len(results) < limit
+		})
+
+		return len(results) < limit, err
+	})
+
+	return results, err
+}
+
+func countAdjacencyEdges(buffer []byte, fromID string) (int, error) {
+	count := 0
+
+	err := eachRecord(buffer, fromID, func(record adjacencyRecord) (bool, error) {
+		count += record.count
+
+		return true, nil
+	})
+
+	return count, err
+}
+
+// findAdjacencyTargets marks sorted targets present in fromID record.
+func findAdjacencyTargets(buffer []byte, fromID string, targets []int) ([]bool, error) {
+	found := make([]bool, len(targets))
+
+	err := eachRecord(buffer, fromID, func(record adjacencyRecord) (bool, error) {
+		next := 0
+
+		err := record.each(func(id int) bool {
+			for next < len(targets) && targets[next] < id {
+				next++
+			}
+
+			if next < len(targets) && targets[next] == id {
+				found[next] = true
+				next++
+			}
+
+			return next < len(targets)
+		})
+
+		return true, err
+	})
+
+	return found, err
+}
@@ -0,0 +1,298 @@
+package edges_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access"
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run is source vertice with sorted targets.
+type run struct {
+	from    int
+	targets []int
+}
+
+// parseRuns groups sorted TSV edges by source vertice.
+func parseRuns(t testing.TB, content string) []run {
+	t.Helper()
+
+	runs := []run{}
+
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		fromID, toID, _ := strings.Cut(line, "\t")
+		from, err := strconv.Atoi(fromID)
+		require.NoError(t, err)
+		to, err := strconv.Atoi(toID)
+		require.NoError(t, err)
+
+		if len(runs) == 0 || runs[len(runs)-1].from != from {
+			runs = append(runs, run{from: from})
+		}
+
+		runs[len(runs)-1].targets = append(runs[len(runs)-1].targets, to)
+	}
+
+	return runs
+}
+
+// writeAdjacency saves runs in adjacency format and returns offsets of the file.
+func writeAdjacency(t testing.TB, folder string, runs []run) edges.Offsets {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	writer := edges.NewAdjacencyWriter(&buffer, "edges"+edges.AdjacencyExt)
+	for _, r := range runs {
+		require.NoError(t, writer.Write(r.from, r.targets))
+	}
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "edges"+edges.AdjacencyExt), buffer.Bytes(), 0o644))
+
+	offsets := edges.Offsets{}
+	offsets.Append(writer.Offsets())
+	require.NoError(t, offsets.Validate())
+
+	return offsets
+}
+
+func newTestAdjacencyEdges(t *testing.T, content string) *edges.Edges {
+	t.Helper()
+
+	folder := t.TempDir()
+	offsets := writeAdjacency(t, folder, parseRuns(t, content))
+
+	e, err := edges.NewFormatEdges(file.NewGetter(folder), offsets, edges.FormatAdjacency)
+	require.NoError(t, err)
+
+	return e
+}
+
+func TestAdjacency_SameAsTSV(t *testing.T) {
+	t.Parallel()
+
+	content := "1\t5\n2\t3\n2\t9\n2\t12\n2\t300\n2\t100000\n3\t2\n12\t1\n"
+	tsv := newTestEdges(t, content)
+	adjacency := newTestAdjacencyEdges(t, content)
+
+	even := func(id int) bool { return id%2 == 0 }
+
+	for _, from := range []string{"0", "1", "2", "3", "4", "12", "13"} {
+		expected, err := tsv.Get(t.Context(), from)
+		require.NoError(t, err)
+		result, err := adjacency.Get(t.Context(), from)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result, from)
+
+		expected, err = tsv.GetFiltered(t.Context(), from, even)
+		require.NoError(t, err)
+		result, err = adjacency.GetFiltered(t.Context(), from, even)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result, from)
+
+		expectedCount, err := tsv.Count(t.Context(), from)
+		require.NoError(t, err)
+		count, err := adjacency.Count(t.Context(), from)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCount, count, from)
+	}
+
+	limited, err := adjacency.GetLimited(t.Context(), "2", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "9"}, limited)
+
+	found, err := adjacency.HasBatch(t.Context(), []edges.Edge{
+		edges.NewEdge("2", "12"),
+		edges.NewEdge("2", "100000"),
+		edges.NewEdge("2", "13"),
+		edges.NewEdge("1", "3"),
+		edges.NewEdge("3", "2"),
+		edges.NewEdge("4", "2"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false, false, true, false}, found)
+}
+
+func TestEdgesVisit(t *testing.T) {
+	t.Parallel()
+
+	content := "1\t5\n2\t3\n2\t9\n2\t12\n3\t2\n"
+
+	for _, e := range []*edges.Edges{newTestEdges(t, content), newTestAdjacencyEdges(t, content)} {
+		var (
+			mu      sync.Mutex
+			visited []int
+		)
+
+		require.NoError(t, e.Visit(t.Context(), "2", func(id int) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			visited = append(visited, id)
+		}))
+
+		slices.Sort(visited)
+		assert.Equal(t, []int{3, 9, 12}, visited)
+	}
+}
+
+func TestAdjacency_Truncated(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	offsets := writeAdjacency(t, folder, []run{{from: 1, targets: []int{5, 1000}}})
+
+	fileName := filepath.Join(folder, "edges"+edges.AdjacencyExt)
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	// the last target is cut but offsets still cover whole record
+	require.NoError(t, os.WriteFile(fileName, append(data[:len(data)-1], 0xff), 0o644))
+
+	e, err := edges.NewFormatEdges(file.NewGetter(folder), offsets, edges.FormatAdjacency)
+	require.NoError(t, err)
+
+	_, err = e.Get(t.Context(), "1")
+	require.Error(t, err)
+}
+
+func TestAdjacencyWriter_Errors(t *testing.T) {
+	t.Parallel()
+
+	writer := edges.NewAdjacencyWriter(&bytes.Buffer{}, "edges.adj")
+	require.Error(t, writer.Write(1, nil))
+	require.Error(t, writer.Write(1, []int{5, 3}))
+	require.Error(t, writer.Write(1, []int{-1}))
+	require.NoError(t, writer.Write(1, []int{3, 5}))
+	require.Error(t, writer.Write(1, []int{7}))
+	require.NoError(t, writer.Close())
+	assert.Len(t, writer.Offsets(), 2)
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, edges.Format("").Validate())
+	require.NoError(t, edges.FormatTSV.Validate())
+	require.NoError(t, edges.FormatAdjacency.Validate())
+
+	_, err := edges.NewFormatEdges(nil, edges.Offsets{}, "csv")
+	require.Error(t, err)
+
+	assert.Equal(t, "part-00000.adj", edges.AdjacencyName("part-00000.txt"))
+}
+
+// countingGetter counts bytes fetched from underlying Getter.
+type countingGetter struct {
+	getter access.Getter
+	bytes  atomic.Int64
+}
+
+func (c *countingGetter) Get(ctx context.Context, fileName string, offset int, length int) ([]byte, error) {
+	c.bytes.Add(int64(length))
+
+	return c.getter.Get(ctx, fileName, offset, length)
+}
+
+// benchmarkGraph is random graph with power law out-degrees and Common Crawl sized ids.
+func benchmarkGraph() []run {
+	random := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+	runs := make([]run, 0, 20000)
+
+	for from := range 20000 {
+		targets := make([]int, 1+int(10/(random.Float64()+0.01)))
+		for i := range targets {
+			targets[i] = random.IntN(300_000_000)
+		}
+
+		slices.Sort(targets)
+		runs = append(runs, run{from: from * 7, targets: slices.Compact(targets)})
+	}
+
+	return runs
+}
+
+// writeTSV saves runs as TSV and returns offsets built the same way as indexer does.
+func writeTSV(b *testing.B, folder string, runs []run) edges.Offsets {
+	b.Helper()
+
+	var buffer bytes.Buffer
+
+	items := []edges.Offset{}
+	lastSavedOffset := 0
+	last := ""
+
+	for _, r := range runs {
+		for _, target := range r.targets {
+			last = strconv.Itoa(r.from)
+			if len(items) == 0 || buffer.Len()-lastSavedOffset >= edges.FileChunkSize {
+				items = append(items, edges.NewOffset(buffer.Len(), last, "edges.txt"))
+				lastSavedOffset = buffer.Len()
+			}
+
+			fmt.Fprintf(&buffer, "%d\t%d\n", r.from, target)
+		}
+	}
+
+	items = append(items, edges.NewOffset(buffer.Len(), last, "edges.txt"))
+	require.NoError(b, os.WriteFile(filepath.Join(folder, "edges.txt"), buffer.Bytes(), 0o644))
+
+	offsets := edges.Offsets{}
+	offsets.Append(items)
+
+	return offsets
+}
+
+func benchmarkEdges(b *testing.B, format edges.Format) {
+	b.Helper()
+
+	folder := b.TempDir()
+	runs := benchmarkGraph()
+
+	var offsets edges.Offsets
+	if format == edges.FormatAdjacency {
+		offsets = writeAdjacency(b, folder, runs)
+	} else {
+		offsets = writeTSV(b, folder, runs)
+	}
+
+	getter := &countingGetter{getter: file.NewGetter(folder)}
+
+	e, err := edges.NewFormatEdges(getter, offsets, format)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	for i := range b.N {
+		from := strconv.Itoa(runs[(i*7919)%len(runs)].from)
+
+		_, err := e.Get(b.Context(), from)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(getter.bytes.Load())/float64(b.N), "fetched-B/op")
+}
+
+func BenchmarkEdgesGet_TSV(b *testing.B) {
+	benchmarkEdges(b, edges.FormatTSV)
+}
+
+func BenchmarkEdgesGet_Adjacency(b *testing.B) {
+	benchmarkEdges(b, edges.FormatAdjacency)
+}
@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -15,7 +18,14 @@ import (
 const (
 	EdgesFolder         = "edges"
 	EdgesReversedFolder = "edges_reversed"
-	DefaultMaxSize      = 5_000
+)
+
+// Default limits, they are changed per Edges with options.
+const (
+	// DefaultMaxSize is max number of neighbours in one direction
+	DefaultMaxSize = 5_000
+	// Concurrency is max number of source vertices processed in parallel by batch operations
+	Concurrency = 100
 )
 
 type Edge struct {
@@ -33,6 +43,10 @@ func (v *Edge) ToID() string {
 	return v.toID
 }
 
+func NewEdge(fromID string, toID string) Edge {
+	return Edge{fromID: fromID, toID: toID}
+}
+
 func LoadEdge(line string) (*Edge, error) {
 	parts := strings.Split(line, "\t")
 	if len(parts) != 2 {
@@ -46,21 +60,165 @@ type Edges struct {
 	// offsets to find edges in edges files
 	offsets Offsets
 	getter  access.Getter
+	format  Format
+	// max number of neighbours returned by Get and GetFiltered
+	maxSize     int
+	concurrency int
+}
+
+// Option configures Edges.
+type Option func(e *Edges)
+
+// WithMaxSize sets max number of neighbours returned by Get and GetFiltered, DefaultMaxSize by default.
+func WithMaxSize(size int) Option {
+	return func(e *Edges) {
+		e.maxSize = size
+	}
+}
+
+// WithConcurrency sets max number of source vertices processed in parallel, Concurrency by default.
+func WithConcurrency(concurrency int) Option {
+	return func(e *Edges) {
+		e.concurrency = concurrency
+	}
+}
+
+func NewEdges(getter access.Getter, offsets Offsets, opts ...Option) *Edges {
+	e := &Edges{
+		offsets:     offsets,
+		getter:      getter,
+		format:      FormatTSV,
+		maxSize:     DefaultMaxSize,
+		concurrency: Concurrency,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// NewFormatEdges creates Edges reading files in format, empty format is TSV.
+func NewFormatEdges(getter access.Getter, offsets Offsets, format Format, opts ...Option) (*Edges, error) {
+	err := format.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	edges := NewEdges(getter, offsets, opts...)
+	if format != "" {
+		edges.format = format
+	}
+
+	return edges, nil
+}
+
+func (v *Edges) decodeEdges(buffer []byte, fromID string, filter Filter, limit int) ([]string, error) {
+	if v.format == FormatAdjacency {
+		return findAdjacencyEdges(buffer, fromID, filter, limit)
+	}
+
+	return findEdges(buffer, fromID, filter, limit)
 }
 
-func NewEdges(getter access.Getter, offsets Offsets) *Edges {
-	return &Edges{
-		offsets: offsets,
-		getter:  getter,
+func (v *Edges) decodeCount(buffer []byte, fromID string) (int, error) {
+	if v.format == FormatAdjacency {
+		return countAdjacencyEdges(buffer, fromID)
 	}
+
+	return countEdges(buffer, fromID)
+}
+
+func (v *Edges) decodeTargets(buffer []byte, fromID string, targets []int) ([]bool, error) {
+	if v.format == FormatAdjacency {
+		return findAdjacencyTargets(buffer, fromID, targets)
+	}
+
+	return findTargets(buffer, fromID, targets)
 }
 
+// Filter reports whether target vertice id should be kept in results.
+type Filter func(id int) bool
+
 // for source vertice id return list of target vertice ids.
 func (v *Edges) Get(ctx context.Context, fromID string) ([]string, error) {
+	return v.GetFiltered(ctx, fromID, nil)
+}
+
+// GetFiltered returns target vertice ids accepted by filter, nil filter accepts all.
+// Filter is applied before results are truncated to max size, DefaultMaxSize unless WithMaxSize is set.
+func (v *Edges) GetFiltered(ctx context.Context, fromID string, filter Filter) ([]string, error) {
+	return v.GetLimited(ctx, fromID, filter, v.maxSize)
+}
+
+// GetLimited is GetFiltered with custom max number of results.
+// Run can span several files, results are merged in file name order before they are truncated,
+// so the same neighbours are returned on every call.
+func (v *Edges) GetLimited(ctx context.Context, fromID string, filter Filter, limit int) ([]string, error) {
+	offsets := v.offsets.FindForFromID(fromID)
+	files := slices.Sorted(maps.Keys(offsets))
+
+	results := make([][]string, len(files))
+	errs := make([]error, len(files))
+
+	var wg sync.WaitGroup
+
+	for i, file := range files {
+		wg.Add(1)
+
+		go func(i int, file string, offset TwoOffsets) {
+			defer wg.Done()
+
+			buffer, err := v.getter.Get(ctx, file, offset.From.offset, offset.To.offset-offset.From.offset)
+			if err != nil {
+				errs[i] = err
+
+				return
+			}
+
+			results[i], errs[i] = v.decodeEdges(buffer, fromID, filter, limit)
+		}(i, file, offsets[file])
+	}
+
+	wg.Wait()
+
+	allEdges := make([]string, 0)
+
+	for i := range files {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		allEdges = append(allEdges, results[i]...)
+	}
+
+	allEdges = allEdges[:min(len(allEdges), limit)]
+
+	sort.Strings(allEdges)
+
+	return allEdges, nil
+}
+
+// Visit calls fn for every target of fromID vertice, the whole run is read.
+// Files are scanned in parallel, so fn has to be safe for concurrent calls.
+func (v *Edges) Visit(ctx context.Context, fromID string, fn func(id int)) error {
+	// nothing passes the filter, so limit is never reached
+	_, err := v.GetLimited(ctx, fromID, func(id int) bool {
+		fn(id)
+
+		return false
+	}, 1)
+
+	return err
+}
+
+// Count returns number of target vertices for source vertice id without loading them.
+func (v *Edges) Count(ctx context.Context, fromID string) (int, error) {
 	offsets := v.offsets.FindForFromID(fromID)
 
 	type result struct {
-		edges []string
+		count int
 		err   error
 	}
 
@@ -76,13 +234,13 @@ func (v *Edges) Get(ctx context.Context, fromID string) ([]string, error) {
 
 			buffer, err := v.getter.Get(ctx, file, offset.From.offset, offset.To.offset-offset.From.offset)
 			if err != nil {
-				results <- result{nil, err}
+				results <- result{0, err}
 
 				return
 			}
 
-			edges, err := findEdges(buffer, fromID)
-			results <- result{edges, err}
+			count, err := v.decodeCount(buffer, fromID)
+			results <- result{count, err}
 		}(file, offset)
 	}
 
@@ -91,28 +249,54 @@ func (v *Edges) Get(ctx context.Context, fromID string) ([]string, error) {
 		close(results)
 	}()
 
-	allEdges := make([]string, 0)
+	total := 0
+	errs := []error{}
 
 	for res := range results {
 		if res.err != nil {
-			return nil, res.err
+			errs = append(errs, res.err)
+
+			continue
 		}
 
-		allEdges = append(allEdges, res.edges...)
-		if len(allEdges) >= DefaultMaxSize {
-			allEdges = allEdges[:DefaultMaxSize]
+		total += res.count
+	}
+
+	if len(errs) > 0 {
+		return 0, fmt.Errorf("errors: %v", errs)
+	}
+
+	return total, nil
+}
+
+func countEdges(buffer []byte, fromID string) (int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(buffer))
+	prefix := []byte(fromID + "\t")
+	count := 0
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		if bytes.HasPrefix(line, prefix) {
+			count++
+		} else if count > 0 {
+			// items sorted and we can break after we reach items with different fromID
+			break
 		}
 	}
 
-	sort.Strings(allEdges)
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading file: %w", err)
+	}
 
-	return allEdges, nil
+	return count, nil
 }
 
-func findEdges(buffer []byte, fromID string) ([]string, error) {
+func findEdges(buffer []byte, fromID string, filter Filter, limit int) ([]string, error) {
 	reader := bytes.NewReader(buffer)
 	scanner := bufio.NewScanner(reader)
 	results := make([]string, 0)
+	inRun := false
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -123,11 +307,24 @@ func findEdges(buffer []byte, fromID string) ([]string, error) {
 		}
 
 		if vertice.fromID == fromID {
+			inRun = true
+
+			if filter != nil {
+				id, err := strconv.Atoi(vertice.toID)
+				if err != nil {
+					return nil, fmt.Errorf("invalid ID: %s", vertice.toID)
+				}
+
+				if !filter(id) {
+					continue
+				}
+			}
+
 			results = append(results, vertice.toID)
-			if len(results) >= DefaultMaxSize {
+			if len(results) >= limit {
 				break
 			}
-		} else if len(results) > 0 {
+		} else if inRun {
 			// items sorted and we can break after we reach items with different fromID
 			break
 		}
@@ -139,3 +336,195 @@ func findEdges(buffer []byte, fromID string) ([]string, error) {
 
 	return results, nil
 }
+
+// Has reports whether fromID vertice links to toID vertice.
+// Only chunks for fromID are read, targets within the run can be in any order.
+func (v *Edges) Has(ctx context.Context, fromID string, toID string) (bool, error) {
+	found, err := v.hasTargets(ctx, fromID, []string{toID})
+	if err != nil {
+		return false, err
+	}
+
+	return found[0], nil
+}
+
+// HasBatch checks many edges at once, result is aligned with items.
+// Edges with the same source vertice share chunk reads.
+func (v *Edges) HasBatch(ctx context.Context, items []Edge) ([]bool, error) {
+	// group edges by source vertice to read every run only once
+	groups := make(map[string][]int)
+	for i, item := range items {
+		groups[item.fromID] = append(groups[item.fromID], i)
+	}
+
+	results := make([]bool, len(items))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	semaphore := make(chan struct{}, v.concurrency)
+
+	for fromID, indexes := range groups {
+		wg.Add(1)
+
+		semaphore <- struct{}{}
+
+		go func(fromID string, indexes []int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			toIDs := make([]string, 0, len(indexes))
+			for _, i := range indexes {
+				toIDs = append(toIDs, items[i].toID)
+			}
+
+			found, err := v.hasTargets(ctx, fromID, toIDs)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+
+				return
+			}
+
+			for j, i := range indexes {
+				results[i] = found[j]
+			}
+		}(fromID, indexes)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("errors: %v", errs)
+	}
+
+	return results, nil
+}
+
+// hasTargets reports for every toID whether fromID links to it.
+func (v *Edges) hasTargets(ctx context.Context, fromID string, toIDs []string) ([]bool, error) {
+	targets := make([]int, 0, len(toIDs))
+
+	for _, toID := range toIDs {
+		id, err := strconv.Atoi(toID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID: %s", toID)
+		}
+
+		targets = append(targets, id)
+	}
+
+	// scan needs sorted unique targets
+	sorted := append([]int{}, targets...)
+	sort.Ints(sorted)
+	sorted = slices.Compact(sorted)
+
+	offsets := v.offsets.FindForFromID(fromID)
+
+	type result struct {
+		found []bool
+		err   error
+	}
+
+	results := make(chan result, len(offsets))
+
+	var wg sync.WaitGroup
+
+	for file, offset := range offsets {
+		wg.Add(1)
+
+		go func(file string, offset TwoOffsets) {
+			defer wg.Done()
+
+			buffer, err := v.getter.Get(ctx, file, offset.From.offset, offset.To.offset-offset.From.offset)
+			if err != nil {
+				results <- result{nil, err}
+
+				return
+			}
+
+			found, err := v.decodeTargets(buffer, fromID, sorted)
+			results <- result{found, err}
+		}(file, offset)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// every file has own part of the run
+	found := make([]bool, len(sorted))
+
+	for res := range results {
+		if res.err != nil {
+			return nil, res.err
+		}
+
+		for i, ok := range res.found {
+			found[i] = found[i] || ok
+		}
+	}
+
+	answer := make([]bool, len(targets))
+	for i, id := range targets {
+		j, _ := slices.BinarySearch(sorted, id)
+		answer[i] = found[j]
+	}
+
+	return answer, nil
+}
+
+// findTargets scans fromID run in the buffer and marks targets present in the run.
+// Targets must be sorted, run is looked up in them because TSV runs sorted by `sort -n`
+// have ties of the first column in byte order.
+func findTargets(buffer []byte, fromID string, targets []int) ([]bool, error) {
+	found := make([]bool, len(targets))
+	reader := bytes.NewReader(buffer)
+	scanner := bufio.NewScanner(reader)
+	inRun := false
+	left := len(targets)
+
+	for scanner.Scan() && left > 0 {
+		line := scanner.Text()
+
+		edge, err := LoadEdge(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.fromID != fromID {
+			if inRun {
+				// items sorted and we can break after we reach items with different fromID
+				break
+			}
+
+			continue
+		}
+
+		inRun = true
+
+		toID, err := strconv.Atoi(edge.toID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID: %s", edge.toID)
+		}
+
+		i, ok := slices.BinarySearch(targets, toID)
+		if ok && !found[i] {
+			found[i] = true
+			left--
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return found, nil
+}
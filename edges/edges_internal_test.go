@@ -0,0 +1,40 @@
+package edges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTargets(t *testing.T) {
+	t.Parallel()
+
+	buffer := []byte("1\t5\n2\t3\n2\t9\n2\t12\n2\t100\n3\t4\n")
+
+	found, err := findTargets(buffer, "2", []int{1, 9, 12, 50, 100, 200})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, true, false, true, false}, found)
+
+	found, err = findTargets(buffer, "4", []int{4})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, found)
+}
+
+func TestFindTargets_ByteOrder(t *testing.T) {
+	t.Parallel()
+
+	// `sort -n` breaks ties of the first column in byte order
+	buffer := []byte("69\t161511398\n69\t243778869\n69\t40219320\n69\t92659650\n71\t133263766\n")
+
+	found, err := findTargets(buffer, "69", []int{40219320, 92659650, 161511398, 200000000})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, true, false}, found)
+}
+
+func TestFindTargets_InvalidLine(t *testing.T) {
+	t.Parallel()
+
+	_, err := findTargets([]byte("2\t3\n2\n"), "2", []int{4})
+	require.Error(t, err)
+}
@@ -1,8 +1,14 @@
 package edges_test
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dharnitski/cc-hosts/access"
 	"github.com/dharnitski/cc-hosts/access/file"
 	"github.com/dharnitski/cc-hosts/edges"
 	"github.com/stretchr/testify/assert"
@@ -62,3 +68,128 @@ func TestEdgesGet(t *testing.T) {
 		})
 	}
 }
+
+func newTestEdges(t *testing.T, content string) *edges.Edges {
+	t.Helper()
+
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "edges.txt"), []byte(content), 0o644))
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	first, _, _ := strings.Cut(lines[0], "\t")
+	last, _, _ := strings.Cut(lines[len(lines)-1], "\t")
+
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{
+		edges.NewOffset(0, first, "edges.txt"),
+		edges.NewOffset(len(content), last, "edges.txt"),
+	})
+
+	return edges.NewEdges(file.NewGetter(folder), offsets)
+}
+
+func TestEdgesHas(t *testing.T) {
+	t.Parallel()
+
+	e := newTestEdges(t, "1\t5\n2\t3\n2\t9\n2\t12\n3\t2\n")
+
+	tests := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{from: "1", to: "5", expected: true},
+		{from: "2", to: "12", expected: true},
+		{from: "2", to: "5", expected: false},
+		{from: "3", to: "2", expected: true},
+		{from: "3", to: "9", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"-"+tt.to, func(t *testing.T) {
+			t.Parallel()
+
+			found, err := e.Has(t.Context(), tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, found)
+		})
+	}
+}
+
+func TestEdgesHasBatch(t *testing.T) {
+	t.Parallel()
+
+	e := newTestEdges(t, "1\t5\n2\t3\n2\t9\n2\t12\n3\t2\n")
+
+	found, err := e.HasBatch(t.Context(), []edges.Edge{
+		edges.NewEdge("2", "12"),
+		edges.NewEdge("1", "3"),
+		edges.NewEdge("2", "3"),
+		edges.NewEdge("2", "3"),
+		edges.NewEdge("3", "2"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true, true, true}, found)
+
+	_, err = e.HasBatch(t.Context(), []edges.Edge{edges.NewEdge("2", "x")})
+	require.Error(t, err)
+}
+
+func TestEdgesCount(t *testing.T) {
+	t.Parallel()
+
+	e := newTestEdges(t, "1\t5\n2\t3\n2\t9\n2\t12\n3\t2\n12\t1\n")
+
+	tests := map[string]int{"1": 1, "2": 3, "3": 1, "12": 1, "4": 0}
+	for from, expected := range tests {
+		count, err := e.Count(t.Context(), from)
+		require.NoError(t, err)
+		assert.Equal(t, expected, count, from)
+	}
+}
+
+// slowGetter delays reads of one file, so it is read after other files.
+type slowGetter struct {
+	getter access.Getter
+	slow   string
+}
+
+func (g *slowGetter) Get(ctx context.Context, fileName string, offset int, length int) ([]byte, error) {
+	if fileName == g.slow {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return g.getter.Get(ctx, fileName, offset, length)
+}
+
+func TestEdgesGetLimited_SeveralFiles(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	// run of source 2 continues in the next file
+	first := "1\t5\n2\t1\n2\t2\n"
+	second := "2\t3\n2\t4\n3\t1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00000.txt"), []byte(first), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-00001.txt"), []byte(second), 0o644))
+
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{
+		edges.NewOffset(0, "1", "part-00000.txt"),
+		edges.NewOffset(len(first), "2", "part-00000.txt"),
+		edges.NewOffset(0, "2", "part-00001.txt"),
+		edges.NewOffset(len(second), "3", "part-00001.txt"),
+	})
+
+	// the first file is read last, its neighbours are still kept
+	getter := &slowGetter{getter: file.NewGetter(folder), slow: "part-00000.txt"}
+	e := edges.NewEdges(getter, offsets)
+
+	for range 3 {
+		ids, err := e.GetLimited(t.Context(), "2", nil, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, ids)
+	}
+
+	ids, err := e.Get(t.Context(), "2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+}
@@ -17,6 +17,9 @@ import (
 const (
 	// fileChunkSize is the size of the chunk of the file to be read in bytes.
 	FileChunkSize = 1024 * 128 // 128 KB
+
+	// exactMark is the fourth column of offset that is the exact start of its source run.
+	exactMark = "exact"
 )
 
 type Offset struct {
@@ -27,14 +30,26 @@ type Offset struct {
 	id string
 	// vertices file name without path
 	file string
+	// offset is the first line of id run, run ends at the next offset with greater id
+	exact bool
 }
 
 func NewOffset(offset int, id, file string) Offset {
 	return Offset{offset: offset, id: id, file: file}
 }
 
-// save in format "domain \t offset \t file".
+// NewExactOffset returns offset of the first line of id run.
+// Lookup of id reads from it instead of the previous chunk.
+func NewExactOffset(offset int, id, file string) Offset {
+	return Offset{offset: offset, id: id, file: file, exact: true}
+}
+
+// save in format "domain \t offset \t file", exact offset has "exact" column.
 func (v Offset) String() string {
+	if v.exact {
+		return fmt.Sprintf("%s\t%d\t%s\t%s", v.id, v.offset, v.file, exactMark)
+	}
+
 	return fmt.Sprintf("%s\t%d\t%s", v.id, v.offset, v.file)
 }
 
@@ -44,7 +59,7 @@ func (v Offset) Offset() int {
 
 func loadOffset(line string) (Offset, error) {
 	parts := strings.Split(line, "\t")
-	if len(parts) != 3 {
+	if len(parts) != 3 && (len(parts) != 4 || parts[3] != exactMark) {
 		return Offset{}, fmt.Errorf("invalid line: %s, %d parts", line, len(parts))
 	}
 
@@ -53,7 +68,7 @@ func loadOffset(line string) (Offset, error) {
 		return Offset{}, fmt.Errorf("invalid offset: %s", parts[1])
 	}
 
-	return Offset{offset: offset, id: parts[0], file: parts[2]}, nil
+	return Offset{offset: offset, id: parts[0], file: parts[2], exact: len(parts) == 4}, nil
 }
 
 type Offsets struct {
@@ -188,6 +203,11 @@ func (v *Offsets) Validate() error { //nolint:cyclop
 			return fmt.Errorf("ID goes down: %d, previous %d", id, previousID)
 		}
 
+		// there is nothing of id before its exact offset
+		if offset.exact && previousFile == offset.file && id == previousID {
+			return fmt.Errorf("exact offset is not the first offset of ID %d", id)
+		}
+
 		previousID = id
 
 		// file
@@ -254,7 +274,8 @@ func findFromFomIDInFile(fromID string, offsets []Offset) (Offset, Offset) {
 			return Offset{}, Offset{}
 		}
 
-		if id < inID {
+		// exact offset skips the end of previous chunk
+		if id < inID || (id == inID && offset.exact) {
 			left = offset
 
 			continue
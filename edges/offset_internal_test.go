@@ -18,6 +18,18 @@ func TestLoadOffset(t *testing.T) {
 	assert.Equal(t, "edges.txt", offset.file)
 }
 
+func TestLoadOffset_Exact(t *testing.T) {
+	t.Parallel()
+
+	offset, err := loadOffset("123\t456\tedges.txt\texact")
+	require.NoError(t, err)
+	assert.True(t, offset.exact)
+	assert.Equal(t, 456, offset.offset)
+
+	_, err = loadOffset("123\t456\tedges.txt\tunknown")
+	require.Error(t, err)
+}
+
 func TestLoadOffset_InvalidLine(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@ package edges_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/dharnitski/cc-hosts/edges"
@@ -15,6 +16,9 @@ func TestOffset(t *testing.T) {
 	offset := edges.NewOffset(123, "id123", "edges.txt")
 	expected := "id123\t123\tedges.txt"
 	assert.Equal(t, expected, offset.String())
+
+	offset = edges.NewExactOffset(123, "id123", "edges.txt")
+	assert.Equal(t, "id123\t123\tedges.txt\texact", offset.String())
 }
 
 func TestOffsets_SaveLoad(t *testing.T) {
@@ -23,21 +27,20 @@ func TestOffsets_SaveLoad(t *testing.T) {
 	items := []edges.Offset{
 		edges.NewOffset(123, "42", "vertices.txt"),
 		edges.NewOffset(456, "84", "vertices.txt"),
+		edges.NewExactOffset(789, "85", "vertices.txt"),
 	}
 	offsets := edges.Offsets{}
 	offsets.Append(items)
 
-	fileName := "test_offsets.txt"
+	fileName := filepath.Join(t.TempDir(), "test_offsets.txt")
 	err := offsets.Save(fileName)
 	require.NoError(t, err)
 
-	defer os.Remove(fileName)
-
 	// Verify the file content
 	content, err := os.ReadFile(fileName)
 	require.NoError(t, err)
 
-	expected := "42\t123\tvertices.txt\n84\t456\tvertices.txt\n"
+	expected := "42\t123\tvertices.txt\n84\t456\tvertices.txt\n85\t789\tvertices.txt\texact\n"
 	assert.Equal(t, expected, string(content))
 
 	actual := edges.Offsets{}
@@ -141,6 +144,14 @@ func TestOffsets_Validate(t *testing.T) {
 			},
 			expected: "ID goes down: 42, previous 84",
 		},
+		{
+			name: "Exact offset in the middle of run",
+			offsets: []edges.Offset{
+				edges.NewOffset(123, "42", "vertices.txt"),
+				edges.NewExactOffset(456, "42", "vertices.txt"),
+			},
+			expected: "exact offset is not the first offset of ID 42",
+		},
 		{
 			name: "Empty file",
 			offsets: []edges.Offset{
@@ -200,4 +211,72 @@ func TestOffsetsFindForFromID(t *testing.T) {
 			assert.Equal(t, tt.to, offset.To.Offset())
 		})
 	}
+
+	// offsets saved before run boundaries were exact read the end of previous chunk
+	legacy := edges.Offsets{}
+	legacy.Append([]edges.Offset{
+		edges.NewExactOffset(150, "30", "edges.txt"),
+		edges.NewOffset(250, "30", "edges.txt"),
+		edges.NewOffset(400, "31", "edges.txt"),
+		edges.NewOffset(500, "40", "edges.txt"),
+	})
+	require.NoError(t, legacy.Validate())
+
+	offset := legacy.FindForFromID("31")["edges.txt"]
+	assert.Equal(t, 250, offset.From.Offset())
+	assert.Equal(t, 500, offset.To.Offset())
+}
+
+func TestOffsetsFindForFromID_Exact(t *testing.T) {
+	t.Parallel()
+
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{
+		// every run boundary is exact start of the run
+		edges.NewExactOffset(0, "10", "edges.txt"),
+		edges.NewExactOffset(100, "20", "edges.txt"),
+		// 30 is hub, it has exact start, chunks inside and the next run offset
+		edges.NewExactOffset(150, "30", "edges.txt"),
+		edges.NewOffset(250, "30", "edges.txt"),
+		edges.NewExactOffset(400, "31", "edges.txt"),
+		edges.NewExactOffset(500, "40", "edges.txt"),
+		edges.NewOffset(600, "40", "edges.txt"),
+	})
+	require.NoError(t, offsets.Validate())
+
+	tests := []struct {
+		id   string
+		from int
+		to   int
+	}{
+		{id: "20", from: 100, to: 150},
+		{id: "25", from: 100, to: 150},
+		{id: "30", from: 150, to: 400},
+		// lookup after hub does not read the end of hub
+		{id: "31", from: 400, to: 500},
+		{id: "40", from: 500, to: 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			offset := offsets.FindForFromID(tt.id)["edges.txt"]
+			assert.Equal(t, tt.from, offset.From.Offset())
+			assert.Equal(t, tt.to, offset.To.Offset())
+		})
+	}
+
+	// offsets saved before run boundaries were exact read the end of previous chunk
+	legacy := edges.Offsets{}
+	legacy.Append([]edges.Offset{
+		edges.NewExactOffset(150, "30", "edges.txt"),
+		edges.NewOffset(250, "30", "edges.txt"),
+		edges.NewOffset(400, "31", "edges.txt"),
+		edges.NewOffset(500, "40", "edges.txt"),
+	})
+	require.NoError(t, legacy.Validate())
+
+	offset := legacy.FindForFromID("31")["edges.txt"]
+	assert.Equal(t, 250, offset.From.Offset())
+	assert.Equal(t, 500, offset.To.Offset())
 }
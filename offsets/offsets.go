@@ -7,6 +7,12 @@ const (
 	VerticesOffsetsFile     = "vertices.offsets.txt"
 	EdgesOffsetsFile        = "edges.offsets.txt"
 	EdgesReversedOffsetFile = "edges-reversed.offsets.txt"
+	ManifestFile            = "manifest.json"
+
+	// block indexes of compressed data
+	VerticesBlocksFile      = "vertices.blocks.txt"
+	EdgesBlocksFile         = "edges.blocks.txt"
+	EdgesReversedBlocksFile = "edges-reversed.blocks.txt"
 )
 
 //go:embed vertices.offsets.txt
@@ -17,3 +23,6 @@ var Edges []byte
 
 //go:embed edges-reversed.offsets.txt
 var EdgesReversed []byte
+
+//go:embed manifest.json
+var Manifest []byte
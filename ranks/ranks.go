@@ -0,0 +1,233 @@
+package ranks
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/access"
+)
+
+const (
+	Folder = "ranks"
+	// ValueSize is the size of one value in bytes, table stores float32 values.
+	ValueSize = 4
+	// BlockSize is max size of one read in bytes, close ids are fetched with one read.
+	BlockSize = 1024 * 32 // 32 KB
+	// ext is table file extension.
+	ext = ".bin"
+	// Concurrency is default max number of parallel reads.
+	Concurrency = 100
+)
+
+// Names of rank tables built by this repo.
+const (
+	// InDegree is number of incoming links.
+	InDegree = "indegree"
+	// PageRank is PageRank score of the host.
+	PageRank = "pagerank"
+	// Harmonic is harmonic centrality of the host.
+	Harmonic = "harmonic"
+	// CCHarmonic is harmonic centrality published by Common Crawl in host-ranks.txt.
+	CCHarmonic = "cc_harmonic"
+	// CCPageRank is PageRank published by Common Crawl in host-ranks.txt.
+	CCPageRank = "cc_pagerank"
+)
+
+// FileName returns table file name for rank name.
+// sample: indegree -> indegree.bin.
+func FileName(name string) string {
+	return name + ext
+}
+
+// Table is ID addressable list of ranks.
+// Value for vertice id is stored as little endian float32 at id * ValueSize offset.
+type Table struct {
+	getter access.Getter
+	file   string
+	// max number of parallel reads
+	concurrency int
+}
+
+// Option configures Table.
+type Option func(t *Table)
+
+// WithConcurrency sets max number of parallel reads, Concurrency by default.
+func WithConcurrency(concurrency int) Option {
+	return func(t *Table) {
+		t.concurrency = concurrency
+	}
+}
+
+func NewTable(getter access.Getter, name string, opts ...Option) *Table {
+	t := &Table{getter: getter, file: FileName(name), concurrency: Concurrency}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// block is range of ids fetched with one read.
+type block struct {
+	from int
+	to   int
+}
+
+// Get returns ranks for ids.
+// Ids close to each other are fetched with one read.
+func (t *Table) Get(ctx context.Context, ids []int) (map[int]float32, error) {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	blocks := make([]block, 0)
+
+	for _, id := range sorted {
+		if id < 0 {
+			return nil, fmt.Errorf("invalid ID: %d", id)
+		}
+
+		last := len(blocks) - 1
+		if last >= 0 && (id-blocks[last].from+1)*ValueSize <= BlockSize {
+			blocks[last].to = id
+
+			continue
+		}
+
+		blocks = append(blocks, block{from: id, to: id})
+	}
+
+	results := make(map[int]float32, len(sorted))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	semaphore := make(chan struct{}, t.concurrency)
+
+	for _, b := range blocks {
+		wg.Add(1)
+
+		semaphore <- struct{}{}
+
+		go func(b block) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			buffer, err := t.getter.Get(ctx, t.file, b.from*ValueSize, (b.to-b.from+1)*ValueSize)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+
+				return
+			}
+
+			// only requested ids from the block
+			start, _ := slices.BinarySearch(sorted, b.from)
+			for _, id := range sorted[start:] {
+				if id > b.to {
+					break
+				}
+
+				pos := (id - b.from) * ValueSize
+				results[id] = math.Float32frombits(binary.LittleEndian.Uint32(buffer[pos : pos+ValueSize]))
+			}
+		}(b)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("errors: %v", errs)
+	}
+
+	return results, nil
+}
+
+// Writer writes ranks in ids order.
+type Writer struct {
+	w *bufio.Writer
+	// next id to write
+	next int
+	buf  [ValueSize]byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write saves rank for id, ids must grow. Skipped ids get zero rank.
+func (w *Writer) Write(id int, value float32) error {
+	if id < w.next {
+		return fmt.Errorf("ID goes down: %d, previous %d", id, w.next-1)
+	}
+
+	buf := w.buf[:]
+	clear(buf)
+
+	for ; w.next < id; w.next++ {
+		_, err := w.w.Write(buf)
+		if err != nil {
+			return fmt.Errorf("error writing rank: %w", err)
+		}
+	}
+
+	binary.LittleEndian.PutUint32(buf, math.Float32bits(value))
+
+	_, err := w.w.Write(buf)
+	if err != nil {
+		return fmt.Errorf("error writing rank: %w", err)
+	}
+
+	w.next++
+
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Save writes ranks into file, rank for id is values[id].
+func Save(fileName string, values []float32) error {
+	file, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	writer := NewWriter(file)
+
+	for id, value := range values {
+		err := writer.Write(id, value)
+		if err != nil {
+			return fmt.Errorf("error writing to file %q: %w", fileName, err)
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing to file %q: %w", fileName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package ranks_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_Get(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	// ids spread over several blocks
+	values := make([]float32, 20_000)
+	for i := range values {
+		values[i] = float32(i) / 2
+	}
+
+	err := ranks.Save(filepath.Join(folder, ranks.FileName("indegree")), values)
+	require.NoError(t, err)
+
+	table := ranks.NewTable(file.NewGetter(folder), "indegree")
+
+	found, err := table.Get(t.Context(), []int{19_999, 0, 7, 8192, 7})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]float32{0: 0, 7: 3.5, 8192: 4096, 19_999: 9999.5}, found)
+
+	_, err = table.Get(t.Context(), []int{-1})
+	require.Error(t, err)
+}
+
+func TestWriter_Gaps(t *testing.T) {
+	t.Parallel()
+
+	buffer := bytes.Buffer{}
+	writer := ranks.NewWriter(&buffer)
+
+	require.NoError(t, writer.Write(1, 1))
+	require.NoError(t, writer.Write(3, 2))
+	require.Error(t, writer.Write(2, 2))
+	require.NoError(t, writer.Flush())
+
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0x80, 0x3f, 0, 0, 0, 0, 0, 0, 0, 0x40}, buffer.Bytes())
+}
+
+func TestSave_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	err := ranks.Save(filepath.Join(t.TempDir(), "missing", "indegree.bin"), []float32{1})
+	require.Error(t, err)
+}
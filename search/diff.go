@@ -0,0 +1,240 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+// Changes are neighbours of the target compared between two snapshots.
+type Changes struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// ErrTooManyNeighbours is returned by Diff when neighbours of the target do not fit the limit.
+var ErrTooManyNeighbours = errors.New("too many neighbours to compare")
+
+// DiffResult is the link profile change of the target between two snapshots.
+type DiffResult struct {
+	Target string `json:"target"`
+	// snapshot names, filled by caller
+	From    string         `json:"from,omitempty"`
+	To      string         `json:"to,omitempty"`
+	Out     Changes        `json:"out"`
+	In      Changes        `json:"in"`
+	Timings map[string]int `json:"timing"`
+}
+
+// neighbours are all filtered neighbours of the target in one snapshot.
+type neighbours struct {
+	out     []string
+	in      []string
+	timings map[string]int
+}
+
+// Diff searches domain in both snapshots and compares neighbours.
+// Vertice ids differ between snapshots, so lists cut by the limit keep different neighbours.
+// Whole runs are compared by domain names instead, ErrTooManyNeighbours is returned
+// when the target has more than opts.Limit neighbours in one direction of either snapshot.
+// Include, Exclude, ExcludeInternal, Deny and Direction filter neighbours, other options are ignored.
+// It returns nil when domain is missed in both snapshots.
+func Diff(ctx context.Context, from *Searcher, to *Searcher, domain string, opts SearchOptions) (*DiffResult, error) {
+	if domain == "" {
+		return nil, errors.New("domain is empty")
+	}
+
+	var (
+		wg                   sync.WaitGroup
+		fromResult, toResult *neighbours
+		fromErr, toErr       error
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		fromResult, fromErr = from.neighbours(ctx, domain, opts)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		toResult, toErr = to.neighbours(ctx, domain, opts)
+	}()
+
+	wg.Wait()
+
+	if fromErr != nil {
+		return nil, fmt.Errorf("error searching from snapshot: %w", fromErr)
+	}
+
+	if toErr != nil {
+		return nil, fmt.Errorf("error searching to snapshot: %w", toErr)
+	}
+
+	if fromResult == nil && toResult == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	// domain missed in one snapshot has no neighbours there
+	if fromResult == nil {
+		fromResult = &neighbours{}
+	}
+
+	if toResult == nil {
+		toResult = &neighbours{}
+	}
+
+	timings := make(map[string]int, len(fromResult.timings)+len(toResult.timings))
+	for key, value := range fromResult.timings {
+		timings["from_"+key] = value
+	}
+
+	for key, value := range toResult.timings {
+		timings["to_"+key] = value
+	}
+
+	return &DiffResult{
+		Target:  domain,
+		Out:     compare(fromResult.out, toResult.out),
+		In:      compare(fromResult.in, toResult.in),
+		Timings: timings,
+	}, nil
+}
+
+// neighbours loads whole runs of the domain and resolves them to domains.
+// It returns nil when domain is not in the graph.
+func (s *Searcher) neighbours(ctx context.Context, domain string, opts SearchOptions) (*neighbours, error) {
+	err := s.validateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	timings := make(map[string]int)
+	start := time.Now()
+
+	vertice, err := s.v.GetByDomain(ctx, vertices.ReverseDomain(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	timings["get_by_domain"] = int(time.Since(start).Milliseconds())
+
+	if vertice == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	shared, err := s.newIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.targetFilter(ctx, shared, domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &neighbours{timings: timings}
+
+	result.out, err = s.runDomains(ctx, vertice.ID(), out, filter, opts, timings)
+	if err != nil {
+		return nil, err
+	}
+
+	result.in, err = s.runDomains(ctx, vertice.ID(), in, filter, opts, timings)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// runDomains reads the whole run in one direction and resolves it to domains in browser format.
+// Run is checked against the limit before it is resolved.
+func (s *Searcher) runDomains(
+	ctx context.Context, verticeID string, pref direction, filter edges.Filter, opts SearchOptions, timings map[string]int,
+) ([]string, error) {
+	if !opts.Direction.includes(pref) {
+		return []string{}, nil
+	}
+
+	run := s.out
+	if pref == in {
+		run = s.in
+	}
+
+	start := time.Now()
+
+	numbers, err := scanIDs(ctx, run, verticeID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	timings[fmt.Sprintf("edges_get_%s", pref)] = int(time.Since(start).Milliseconds())
+
+	size := s.limit(opts)
+	if len(numbers) > size {
+		return nil, invalidOptions(fmt.Errorf("%w: %d %s neighbours, limit is %d", ErrTooManyNeighbours, len(numbers), pref, size))
+	}
+
+	ids := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		ids = append(ids, strconv.Itoa(number))
+	}
+
+	start = time.Now()
+
+	found, err := s.v.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	timings[fmt.Sprintf("v_get_by_ids_%s", pref)] = int(time.Since(start).Milliseconds())
+
+	domains := make([]string, 0, len(found))
+	for _, v := range found {
+		domains = append(domains, vertices.ReverseDomain(v.Domain()))
+	}
+
+	return domains, nil
+}
+
+// compare splits domains into added to, removed from and present in both lists, results are sorted.
+func compare(from []string, to []string) Changes {
+	fromSet := make(map[string]struct{}, len(from))
+	for _, domain := range from {
+		fromSet[domain] = struct{}{}
+	}
+
+	changes := Changes{Added: []string{}, Removed: []string{}, Unchanged: []string{}}
+
+	for _, domain := range to {
+		if _, ok := fromSet[domain]; ok {
+			changes.Unchanged = append(changes.Unchanged, domain)
+			delete(fromSet, domain)
+
+			continue
+		}
+
+		changes.Added = append(changes.Added, domain)
+	}
+
+	changes.Removed = slices.AppendSeq(changes.Removed, maps.Keys(fromSet))
+
+	slices.Sort(changes.Added)
+	slices.Sort(changes.Removed)
+	slices.Sort(changes.Unchanged)
+
+	return changes
+}
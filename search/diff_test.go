@@ -0,0 +1,79 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	from := newTestSearcher(t, testDomains, testLinks)
+	// vertice ids of the same domains differ from the first snapshot
+	to := newTestSearcher(t, []string{"com.a", "com.aa", "com.b", "com.c", "org.f"}, [][2]int{
+		{0, 2}, {0, 4},
+		{1, 0},
+		{3, 0},
+	})
+
+	result, err := search.Diff(t.Context(), from, to, "a.com", search.SearchOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "a.com", result.Target)
+	assert.Equal(t, search.Changes{
+		Added:     []string{"f.org"},
+		Removed:   []string{"c.com", "d.com"},
+		Unchanged: []string{"b.com"},
+	}, result.Out)
+	assert.Equal(t, search.Changes{
+		Added:     []string{"aa.com"},
+		Removed:   []string{"b.com", "e.org"},
+		Unchanged: []string{"c.com"},
+	}, result.In)
+	assert.Contains(t, result.Timings, "from_get_by_domain")
+	assert.Contains(t, result.Timings, "to_get_by_domain")
+}
+
+func TestDiff_Missed(t *testing.T) {
+	t.Parallel()
+
+	from := newTestSearcher(t, testDomains, testLinks)
+	to := newTestSearcher(t, []string{"com.b", "com.c"}, [][2]int{{0, 1}})
+
+	// domain is gone in the second snapshot
+	result, err := search.Diff(t.Context(), from, to, "a.com", search.SearchOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"b.com", "c.com", "d.com"}, result.Out.Removed)
+	assert.Empty(t, result.Out.Added)
+
+	result, err = search.Diff(t.Context(), from, to, "x.com", search.SearchOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	_, err = search.Diff(t.Context(), from, to, "", search.SearchOptions{})
+	require.Error(t, err)
+}
+
+func TestDiff_TooManyNeighbours(t *testing.T) {
+	t.Parallel()
+
+	from := newTestSearcher(t, testDomains, testLinks)
+	to := newTestSearcher(t, testDomains, testLinks)
+
+	// a.com has 3 outgoing neighbours in both snapshots
+	_, err := search.Diff(t.Context(), from, to, "a.com", search.SearchOptions{Limit: 2})
+	require.ErrorIs(t, err, search.ErrTooManyNeighbours)
+	require.ErrorIs(t, err, search.ErrInvalidOptions)
+
+	// run of exactly limit size is compared
+	result, err := search.Diff(t.Context(), from, to, "a.com", search.SearchOptions{Limit: 3})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"b.com", "c.com", "d.com"}, result.Out.Unchanged)
+	assert.Empty(t, result.Out.Added)
+	assert.Empty(t, result.Out.Removed)
+}
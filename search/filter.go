@@ -0,0 +1,156 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"golang.org/x/net/publicsuffix"
+)
+
+// idFilter keeps vertice ids by ranges, vertices are sorted by reversed domain
+// so any reversed domain prefix is a contiguous range of ids.
+type idFilter struct {
+	// nil when all ids are included
+	include []vertices.IDRange
+	exclude []vertices.IDRange
+}
+
+func (f *idFilter) keep(id int) bool {
+	for _, r := range f.exclude {
+		if r.Contains(id) {
+			return false
+		}
+	}
+
+	if f.include == nil {
+		return true
+	}
+
+	for _, r := range f.include {
+		if r.Contains(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newIDFilter converts options into ranges shared by all targets, nil when nothing to filter.
+func (s *Searcher) newIDFilter(ctx context.Context, opts SearchOptions) (*idFilter, error) {
+	excludes := make([]string, 0, len(opts.Exclude)+len(opts.Deny))
+	excludes = append(excludes, opts.Exclude...)
+
+	for _, host := range opts.Deny {
+		excludes = append(excludes, vertices.ReverseDomain(host))
+	}
+
+	if len(opts.Include) == 0 && len(excludes) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	filter := &idFilter{}
+
+	if len(opts.Include) > 0 {
+		include, err := s.prefixRanges(ctx, opts.Include)
+		if err != nil {
+			return nil, err
+		}
+		// not nil even when nothing matches prefixes
+		filter.include = append(make([]vertices.IDRange, 0, len(include)), include...)
+	}
+
+	exclude, err := s.prefixRanges(ctx, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.exclude = exclude
+
+	return filter, nil
+}
+
+// targetFilter adds target specific exclusions to shared filter.
+// It returns nil filter when there is nothing to filter.
+func (s *Searcher) targetFilter(ctx context.Context, shared *idFilter, domain string, opts SearchOptions) (edges.Filter, error) {
+	filter := shared
+
+	if opts.ExcludeInternal {
+		internal, err := s.prefixRanges(ctx, []string{vertices.ReverseDomain(RegisteredDomain(domain))})
+		if err != nil {
+			return nil, err
+		}
+
+		filter = &idFilter{}
+		if shared != nil {
+			filter.include = shared.include
+			filter.exclude = append(filter.exclude, shared.exclude...)
+		}
+
+		filter.exclude = append(filter.exclude, internal...)
+	}
+
+	if filter == nil {
+		return nil, nil
+	}
+
+	return filter.keep, nil
+}
+
+func (s *Searcher) prefixRanges(ctx context.Context, prefixes []string) ([]vertices.IDRange, error) {
+	results := make([]vertices.IDRange, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		prefix = strings.Trim(strings.ToLower(prefix), ".")
+		if prefix == "" {
+			continue
+		}
+
+		ranges, err := s.v.PrefixRanges(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving prefix %q: %w", prefix, err)
+		}
+
+		results = append(results, ranges...)
+	}
+
+	return results, nil
+}
+
+// RegisteredDomain returns registered domain (eTLD+1) for host in browser format,
+// sample: blog.example.co.uk -> example.co.uk.
+// Host is returned as is when it has no registered domain, for example IP address or public suffix.
+func RegisteredDomain(host string) string {
+	registered, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+
+	return registered
+}
+
+// LoadDenyList reads hosts in browser format one per line.
+// Empty lines and lines started with # are ignored.
+func LoadDenyList(reader io.Reader) ([]string, error) {
+	results := make([]string, 0)
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		results = append(results, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading deny list: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,118 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals
+var filterDomains = []string{
+	"com.a",
+	"com.a.blog",
+	"com.b",
+	"com.googleapis.fonts",
+	"gov.nasa",
+	"gov.nasa.www",
+	"org.e",
+}
+
+// a links everybody, blog.a.com and e.org link a.
+//
+//nolint:gochecknoglobals
+var filterLinks = [][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}, {0, 6},
+	{1, 0},
+	{6, 0},
+}
+
+func TestSearcher_Filters(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, filterDomains, filterLinks)
+
+	tests := []struct {
+		name string
+		opts search.SearchOptions
+		out  []string
+		in   []string
+	}{
+		{
+			name: "no filters",
+			out:  []string{"b.com", "blog.a.com", "e.org", "fonts.googleapis.com", "nasa.gov", "www.nasa.gov"},
+			in:   []string{"blog.a.com", "e.org"},
+		},
+		{
+			name: "include tld",
+			opts: search.SearchOptions{Include: []string{"gov"}},
+			out:  []string{"nasa.gov", "www.nasa.gov"},
+			in:   []string{},
+		},
+		{
+			name: "include and exclude",
+			opts: search.SearchOptions{Include: []string{"gov", "com"}, Exclude: []string{"gov.nasa.www", "com.b"}},
+			out:  []string{"blog.a.com", "fonts.googleapis.com", "nasa.gov"},
+			in:   []string{"blog.a.com"},
+		},
+		{
+			name: "include nothing",
+			opts: search.SearchOptions{Include: []string{"net"}},
+			out:  []string{},
+			in:   []string{},
+		},
+		{
+			name: "exclude internal",
+			opts: search.SearchOptions{ExcludeInternal: true},
+			out:  []string{"b.com", "e.org", "fonts.googleapis.com", "nasa.gov", "www.nasa.gov"},
+			in:   []string{"e.org"},
+		},
+		{
+			name: "deny",
+			opts: search.SearchOptions{Deny: []string{"fonts.googleapis.com", "nasa.gov"}},
+			out:  []string{"b.com", "blog.a.com", "e.org"},
+			in:   []string{"blog.a.com", "e.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, results.Out)
+			assert.Equal(t, tt.in, results.In)
+		})
+	}
+}
+
+func TestSearcher_FiltersBatch(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, filterDomains, filterLinks)
+
+	results, err := searcher.GetTargetsBatch(t.Context(), []string{"a.com", "blog.a.com"}, search.SearchOptions{ExcludeInternal: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"e.org"}, results[0].Result.In)
+	assert.Equal(t, []string{}, results[1].Result.Out)
+}
+
+func TestRegisteredDomain(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "example.co.uk", search.RegisteredDomain("blog.example.co.uk"))
+	assert.Equal(t, "example.com", search.RegisteredDomain("example.com"))
+	assert.Equal(t, "co.uk", search.RegisteredDomain("co.uk"))
+}
+
+func TestLoadDenyList(t *testing.T) {
+	t.Parallel()
+
+	hosts, err := search.LoadDenyList(strings.NewReader("# CDNs\nfonts.googleapis.com\n\n  cdn.example.com \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fonts.googleapis.com", "cdn.example.com"}, hosts)
+}
@@ -0,0 +1,114 @@
+package search_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/require"
+)
+
+const testFile = "part-00000.txt"
+
+// small graph used by unit tests, vertice ID is the index in the list.
+//
+//nolint:gochecknoglobals
+var testDomains = []string{
+	"com.a",
+	"com.b",
+	"com.c",
+	"com.d",
+	"org.e",
+}
+
+// a links b, c, d; b links a; c links a, b; e links a.
+//
+//nolint:gochecknoglobals
+var testLinks = [][2]int{
+	{0, 1}, {0, 2}, {0, 3},
+	{1, 0},
+	{2, 0}, {2, 1},
+	{4, 0},
+}
+
+// newTestSearcher writes the graph into temporary folders and returns Searcher on top of it.
+func newTestSearcher(t *testing.T, domains []string, links [][2]int, opts ...search.Option) *search.Searcher {
+	t.Helper()
+
+	root := t.TempDir()
+
+	reversed := make([][2]int, 0, len(links))
+	for _, link := range links {
+		reversed = append(reversed, [2]int{link[1], link[0]})
+	}
+
+	out := newTestEdges(t, filepath.Join(root, edges.EdgesFolder), links)
+	in := newTestEdges(t, filepath.Join(root, edges.EdgesReversedFolder), reversed)
+
+	return search.NewSearcher(newTestVertices(t, root, domains), out, in, opts...)
+}
+
+// newTestVertices writes vertices into vertices folder of root, vertice ID is the index in the list.
+func newTestVertices(t *testing.T, root string, domains []string) *vertices.Vertices {
+	t.Helper()
+
+	vLines := make([]string, 0, len(domains))
+	for id, domain := range domains {
+		vLines = append(vLines, fmt.Sprintf("%d\t%s", id, domain))
+	}
+
+	vSize := writeLines(t, filepath.Join(root, vertices.Folder), vLines)
+	vOffsets := vertices.Offsets{}
+	vOffsets.Append([]vertices.Offset{
+		vertices.NewOffset(0, domains[0], 0, testFile),
+		vertices.NewOffset(vSize, domains[len(domains)-1], len(domains)-1, testFile),
+	})
+
+	return vertices.NewVertices(file.NewGetter(filepath.Join(root, vertices.Folder)), vOffsets)
+}
+
+func newTestEdges(t *testing.T, folder string, links [][2]int) *edges.Edges {
+	t.Helper()
+
+	sorted := append([][2]int{}, links...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+
+		return sorted[i][1] < sorted[j][1]
+	})
+
+	lines := make([]string, 0, len(sorted))
+	for _, link := range sorted {
+		lines = append(lines, fmt.Sprintf("%d\t%d", link[0], link[1]))
+	}
+
+	size := writeLines(t, folder, lines)
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{
+		edges.NewOffset(0, strconv.Itoa(sorted[0][0]), testFile),
+		edges.NewOffset(size, strconv.Itoa(sorted[len(sorted)-1][0]), testFile),
+	})
+
+	return edges.NewEdges(file.NewGetter(folder), offsets)
+}
+
+func writeLines(t *testing.T, folder string, lines []string) int {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+
+	content := strings.Join(lines, "\n") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(folder, testFile), []byte(content), 0o644))
+
+	return len(content)
+}
@@ -0,0 +1,259 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+// Order defines how neighbours are sorted before they are truncated to the limit.
+// Any value except alphabetical and reversed is a name of rank table attached with AddRanks.
+type Order string
+
+const (
+	// OrderAlphabetical sorts neighbours by domain in browser format.
+	OrderAlphabetical Order = ""
+	// OrderReversed groups neighbours by reversed domain, subdomains stay next to each other.
+	OrderReversed Order = "reversed"
+	// OrderInDegree puts neighbours with more incoming links first.
+	OrderInDegree Order = ranks.InDegree
+	// OrderPageRank puts neighbours with higher PageRank first.
+	OrderPageRank Order = ranks.PageRank
+	// OrderHarmonic puts neighbours with higher harmonic centrality first.
+	OrderHarmonic Order = ranks.Harmonic
+)
+
+// ranked reports whether order needs rank table.
+func (o Order) ranked() bool {
+	return o != OrderAlphabetical && o != OrderReversed
+}
+
+// AddRanks attaches rank table, neighbours can be sorted by the table with Order(name).
+func (s *Searcher) AddRanks(name string, table *ranks.Table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tables == nil {
+		s.tables = make(map[string]*ranks.Table)
+	}
+
+	s.tables[name] = table
+}
+
+func (s *Searcher) rankTable(name string) (*ranks.Table, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[name]
+	if !ok {
+		return nil, invalidOptions(fmt.Errorf("unknown order: %q", name))
+	}
+
+	return table, nil
+}
+
+// limit returns max number of neighbours in the result.
+func (s *Searcher) limit(opts SearchOptions) int {
+	if opts.Limit <= 0 || opts.Limit > s.limits.MaxResults {
+		return s.limits.MaxResults
+	}
+
+	return opts.Limit
+}
+
+// scanIDs loads ids of every neighbour, ranked order and rank filters need the whole run
+// to return the same top neighbours on every call.
+func scanIDs(ctx context.Context, e *edges.Edges, verticeID string, filter edges.Filter) ([]int, error) {
+	var (
+		mu  sync.Mutex
+		ids []int
+	)
+
+	err := e.Visit(ctx, verticeID, func(id int) {
+		if filter != nil && !filter(id) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		ids = append(ids, id)
+	})
+
+	return ids, err
+}
+
+// orderIDs filters ids by ranks, sorts them before domains are resolved and truncates them to the limit.
+// Alphabetical order needs domains, ids are only truncated to Limits.MaxResults for it.
+func (s *Searcher) orderIDs(ctx context.Context, numbers []int, opts SearchOptions) ([]string, error) {
+	// vertice ids grow with reversed domain
+	slices.Sort(numbers)
+
+	numbers, err := s.filterByRanks(ctx, numbers, opts.MinRanks)
+	if err != nil {
+		return nil, err
+	}
+
+	size := s.limit(opts)
+
+	if opts.Order == OrderAlphabetical {
+		size = s.limits.MaxResults
+	}
+
+	if opts.Order.ranked() {
+		table, err := s.rankTable(string(opts.Order))
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := table.Get(ctx, numbers)
+		if err != nil {
+			return nil, err
+		}
+
+		// stable sort keeps reversed domain order for the same rank
+		sort.SliceStable(numbers, func(i, j int) bool {
+			return values[numbers[i]] > values[numbers[j]]
+		})
+	}
+
+	numbers = numbers[:min(len(numbers), size)]
+
+	results := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		results = append(results, strconv.Itoa(number))
+	}
+
+	return results, nil
+}
+
+// filterByRanks keeps ids with ranks greater or equal to min value of every table.
+func (s *Searcher) filterByRanks(ctx context.Context, ids []int, minRanks map[string]float32) ([]int, error) {
+	for name, minValue := range minRanks {
+		table, err := s.rankTable(name)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := table.Get(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = slices.DeleteFunc(ids, func(id int) bool {
+			return values[id] < minValue
+		})
+	}
+
+	return ids, nil
+}
+
+// toDomains converts vertices to list of domains in browser format.
+// Vertices are expected in the requested order unless it is alphabetical.
+func toDomains(items []vertices.Vertice, opts SearchOptions, size int) []string {
+	results := make([]string, 0, len(items))
+	for _, d := range items {
+		results = append(results, vertices.ReverseDomain(d.Domain()))
+	}
+
+	if opts.Order == OrderAlphabetical {
+		sort.Strings(results)
+	}
+
+	return results[:min(len(results), size)]
+}
+
+// host is a domain in browser format with its vertice id.
+type host struct {
+	domain string
+	id     string
+}
+
+// rankTarget is a result with all hosts that can be in it.
+type rankTarget struct {
+	result *Result
+	hosts  []host
+}
+
+func newRankTarget(result *Result, target host, groups ...[]vertices.Vertice) rankTarget {
+	hosts := []host{target}
+
+	for _, group := range groups {
+		for _, v := range group {
+			hosts = append(hosts, host{domain: vertices.ReverseDomain(v.Domain()), id: v.ID()})
+		}
+	}
+
+	return rankTarget{result: result, hosts: hosts}
+}
+
+// attachRanks fills Result.Ranks for the target and neighbours present in the result.
+// Every table is read once for all results.
+func (s *Searcher) attachRanks(ctx context.Context, names []string, targets []rankTarget) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0)
+
+	for _, target := range targets {
+		for _, h := range target.hosts {
+			id, err := strconv.Atoi(h.id)
+			if err != nil {
+				return fmt.Errorf("invalid ID: %s", h.id)
+			}
+
+			ids = append(ids, id)
+		}
+	}
+
+	values := make(map[string]map[int]float32, len(names))
+
+	for _, name := range names {
+		table, err := s.rankTable(name)
+		if err != nil {
+			return err
+		}
+
+		values[name], err = table.Get(ctx, ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, target := range targets {
+		present := make(map[string]struct{})
+
+		for _, domains := range [][]string{{target.result.Target}, target.result.Out, target.result.In} {
+			for _, domain := range domains {
+				present[domain] = struct{}{}
+			}
+		}
+
+		target.result.Ranks = make(map[string]map[string]float32, len(present))
+
+		for _, h := range target.hosts {
+			if _, ok := present[h.domain]; !ok {
+				continue
+			}
+
+			id, _ := strconv.Atoi(h.id)
+			hostRanks := make(map[string]float32, len(names))
+
+			for _, name := range names {
+				hostRanks[name] = values[name][id]
+			}
+
+			target.result.Ranks[h.domain] = hostRanks
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package search_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRankedSearcher(t *testing.T) *search.Searcher {
+	t.Helper()
+
+	searcher := newTestSearcher(t, filterDomains, filterLinks)
+
+	folder := t.TempDir()
+	err := ranks.Save(filepath.Join(folder, ranks.FileName("indegree")), []float32{2, 1, 5, 9, 2, 0, 5})
+	require.NoError(t, err)
+
+	searcher.AddRanks("indegree", ranks.NewTable(file.NewGetter(folder), "indegree"))
+
+	return searcher
+}
+
+func TestSearcher_Order(t *testing.T) {
+	t.Parallel()
+
+	searcher := newRankedSearcher(t)
+
+	tests := []struct {
+		name string
+		opts search.SearchOptions
+		out  []string
+	}{
+		{
+			name: "alphabetical",
+			opts: search.SearchOptions{Limit: 2},
+			out:  []string{"b.com", "blog.a.com"},
+		},
+		{
+			name: "reversed",
+			opts: search.SearchOptions{Order: search.OrderReversed},
+			out:  []string{"blog.a.com", "b.com", "fonts.googleapis.com", "nasa.gov", "www.nasa.gov", "e.org"},
+		},
+		{
+			name: "in degree",
+			opts: search.SearchOptions{Order: search.OrderInDegree},
+			out:  []string{"fonts.googleapis.com", "b.com", "e.org", "nasa.gov", "blog.a.com", "www.nasa.gov"},
+		},
+		{
+			name: "in degree limited",
+			opts: search.SearchOptions{Order: search.OrderInDegree, Limit: 3, Mutual: true},
+			out:  []string{"fonts.googleapis.com", "b.com", "e.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, results.Out)
+		})
+	}
+}
+
+func TestSearcher_OrderBatch(t *testing.T) {
+	t.Parallel()
+
+	searcher := newRankedSearcher(t)
+
+	results, err := searcher.GetTargetsBatch(t.Context(), []string{"a.com"}, search.SearchOptions{Order: search.OrderInDegree, Mutual: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e.org", "blog.a.com"}, results[0].Result.In)
+	assert.Equal(t, []string{"e.org", "blog.a.com"}, results[0].Result.Mutual)
+}
+
+func TestSearcher_UnknownOrder(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, filterDomains, filterLinks)
+
+	_, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Order: "centrality"})
+	require.EqualError(t, err, `unknown order: "centrality"`)
+	require.ErrorIs(t, err, search.ErrInvalidOptions)
+
+	_, err = searcher.GetTargetsBatch(t.Context(), []string{"a.com"}, search.SearchOptions{Order: "pagerank"})
+	require.Error(t, err)
+}
+
+func TestSearcher_Ranks(t *testing.T) {
+	t.Parallel()
+
+	searcher := newRankedSearcher(t)
+
+	results, err := searcher.GetTargetsWithOptions(t.Context(), "e.org", search.SearchOptions{Ranks: []string{"indegree"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]float32{
+		"e.org": {"indegree": 5},
+		"a.com": {"indegree": 2},
+	}, results.Ranks)
+
+	batch, err := searcher.GetTargetsBatch(t.Context(), []string{"a.com", "e.org"}, search.SearchOptions{Ranks: []string{"indegree"}, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]float32{
+		"a.com":      {"indegree": 2},
+		"b.com":      {"indegree": 5},
+		"blog.a.com": {"indegree": 1},
+	}, batch[0].Result.Ranks)
+	assert.Equal(t, results.Ranks, batch[1].Result.Ranks)
+
+	_, err = searcher.GetTargetsWithOptions(t.Context(), "e.org", search.SearchOptions{Ranks: []string{"pagerank"}})
+	require.Error(t, err)
+}
+
+func TestSearcher_MinRanks(t *testing.T) {
+	t.Parallel()
+
+	searcher := newRankedSearcher(t)
+
+	results, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{MinRanks: map[string]float32{"indegree": 5}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com", "e.org", "fonts.googleapis.com"}, results.Out)
+	assert.Equal(t, []string{"e.org"}, results.In)
+
+	results, err = searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{
+		MinRanks: map[string]float32{"indegree": 2},
+		Order:    search.OrderInDegree,
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fonts.googleapis.com", "b.com"}, results.Out)
+
+	_, err = searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{MinRanks: map[string]float32{"cc_harmonic": 1}})
+	require.Error(t, err)
+}
+
+func TestSearcher_OrderWholeRun(t *testing.T) {
+	t.Parallel()
+
+	// hub links more neighbours than any scan limit, the best ranked one is the last in file
+	const size = 25_000
+
+	domains := make([]string, 0, size+1)
+	links := make([][2]int, 0, size)
+	values := make([]float32, size+1)
+
+	for i := range size + 1 {
+		domains = append(domains, fmt.Sprintf("com.h%06d", i))
+		values[i] = float32(i % 100)
+
+		if i > 0 {
+			links = append(links, [2]int{0, i})
+		}
+	}
+
+	values[size] = 1000
+
+	searcher := newTestSearcher(t, domains, links)
+
+	folder := t.TempDir()
+	require.NoError(t, ranks.Save(filepath.Join(folder, ranks.FileName("indegree")), values))
+	searcher.AddRanks("indegree", ranks.NewTable(file.NewGetter(folder), "indegree"))
+
+	opts := search.SearchOptions{Order: search.OrderInDegree, Limit: 2, Direction: search.DirectionOut}
+
+	results, err := searcher.GetTargetsWithOptions(t.Context(), "h000000.com", opts)
+	require.NoError(t, err)
+	// ties are ordered by reversed domain
+	assert.Equal(t, []string{fmt.Sprintf("h%06d.com", size), "h000099.com"}, results.Out)
+
+	opts = search.SearchOptions{MinRanks: map[string]float32{"indegree": 500}, Direction: search.DirectionOut}
+
+	results, err = searcher.GetTargetsWithOptions(t.Context(), "h000000.com", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{fmt.Sprintf("h%06d.com", size)}, results.Out)
+}
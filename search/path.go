@@ -0,0 +1,184 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+const (
+	// DefaultPathDepth is max number of links in path when depth is not set
+	DefaultPathDepth = 3
+	// PathMaxFrontier is max number of hosts expanded on one level of path search when frontier is not set
+	PathMaxFrontier = 1000
+)
+
+// PathLimits bound work of one path search.
+type PathLimits struct {
+	// Depth is max number of links in path, DefaultPathDepth when not set
+	Depth int
+	// Frontier is max number of hosts expanded on one level, PathMaxFrontier when not set
+	Frontier int
+}
+
+// Path searches the shortest chain of outgoing links from one host to another, both are in browser format.
+// Search is breadth first on vertice ids and follows at most opts.Limit neighbours of every host,
+// levels wider than limits.Frontier are cut, so path can be missed in dense parts of the graph.
+// Only hosts of the found path are resolved to domains. Include, Exclude and Deny filter hosts of the path,
+// other options are ignored.
+// It returns hosts from the first to the last one or nil when no path is found within limits.Depth links.
+func (s *Searcher) Path(ctx context.Context, from string, to string, limits PathLimits, opts SearchOptions) ([]string, error) {
+	if from == "" || to == "" {
+		return nil, errors.New("domain is empty")
+	}
+
+	depth := limits.Depth
+	if depth <= 0 {
+		depth = DefaultPathDepth
+	}
+
+	frontierSize := limits.Frontier
+	if frontierSize <= 0 {
+		frontierSize = PathMaxFrontier
+	}
+
+	if from == to {
+		return []string{from}, nil
+	}
+
+	ids, err := s.resolveDomains(ctx, []string{from, to})
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, toID := ids[from], ids[to]
+	if fromID == "" || toID == "" {
+		return nil, nil
+	}
+
+	shared, err := s.newIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter edges.Filter
+	if shared != nil {
+		filter = shared.keep
+	}
+
+	// previous vertice in the path for every visited vertice
+	parents := map[string]string{fromID: ""}
+	frontier := []string{fromID}
+
+	for range depth {
+		neighbours, err := s.expand(ctx, frontier, filter, s.limit(opts))
+		if err != nil {
+			return nil, fmt.Errorf("error searching path from %q to %q: %w", from, to, err)
+		}
+
+		next := make([]string, 0, len(frontier))
+
+		for i, parent := range frontier {
+			for _, neighbour := range neighbours[i] {
+				if _, ok := parents[neighbour]; ok {
+					continue
+				}
+
+				parents[neighbour] = parent
+
+				if neighbour == toID {
+					return s.resolvePath(ctx, backtrack(parents, toID))
+				}
+
+				if len(next) < frontierSize {
+					next = append(next, neighbour)
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		frontier = next
+	}
+
+	return nil, nil
+}
+
+// expand loads outgoing neighbour ids of every vertice, result is aligned with ids.
+func (s *Searcher) expand(ctx context.Context, ids []string, filter edges.Filter, limit int) ([][]string, error) {
+	results := make([][]string, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, s.limits.BatchConcurrency)
+
+	for i, id := range ids {
+		wg.Add(1)
+
+		semaphore <- struct{}{}
+
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			results[i], errs[i] = s.out.GetLimited(ctx, id, filter, limit)
+		}(i, id)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// resolvePath converts vertice ids of the path into hosts in browser format.
+func (s *Searcher) resolvePath(ctx context.Context, ids []string) ([]string, error) {
+	found, err := s.v.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]vertices.Vertice, len(found))
+	for _, v := range found {
+		byID[v.ID()] = v
+	}
+
+	hosts := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		v, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("vertice %s of the path is not found", id)
+		}
+
+		hosts = append(hosts, v.ReversedDomain())
+	}
+
+	return hosts, nil
+}
+
+// backtrack restores path to vertice from parents.
+func backtrack(parents map[string]string, id string) []string {
+	path := []string{}
+
+	for ; id != ""; id = parents[id] {
+		path = append(path, id)
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
@@ -0,0 +1,57 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearcher_Path(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	tests := []struct {
+		from     string
+		to       string
+		depth    int
+		expected []string
+	}{
+		{from: "e.org", to: "d.com", expected: []string{"e.org", "a.com", "d.com"}},
+		{from: "c.com", to: "b.com", expected: []string{"c.com", "b.com"}},
+		{from: "a.com", to: "a.com", expected: []string{"a.com"}},
+		{from: "e.org", to: "d.com", depth: 1},
+		{from: "d.com", to: "a.com"},
+		{from: "x.com", to: "a.com"},
+	}
+
+	for _, test := range tests {
+		path, err := searcher.Path(t.Context(), test.from, test.to, search.PathLimits{Depth: test.depth}, search.SearchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, path, "%s -> %s", test.from, test.to)
+	}
+
+	_, err := searcher.Path(t.Context(), "", "a.com", search.PathLimits{}, search.SearchOptions{})
+	require.Error(t, err)
+}
+
+func TestSearcher_PathLimits(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	// e.org links to a.com only, a.com links to b.com, c.com and d.com
+	path, err := searcher.Path(t.Context(), "e.org", "d.com", search.PathLimits{}, search.SearchOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Nil(t, path, "d.com is over the limit of a.com neighbours")
+
+	path, err = searcher.Path(t.Context(), "e.org", "d.com", search.PathLimits{Frontier: 1}, search.SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e.org", "a.com", "d.com"}, path)
+
+	path, err = searcher.Path(t.Context(), "e.org", "d.com", search.PathLimits{}, search.SearchOptions{Exclude: []string{"com.a"}})
+	require.NoError(t, err)
+	assert.Nil(t, path, "a.com is excluded")
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+// Presence reports whether host is in the graph and its full number of links.
+type Presence struct {
+	Present   bool   `json:"present"`
+	ID        string `json:"id,omitempty"`
+	OutDegree int    `json:"out_degree"`
+	InDegree  int    `json:"in_degree"`
+}
+
+// Presence looks up host in browser format and counts its links in both directions.
+// Unlike GetTargets degrees are not limited by Limits.MaxResults.
+func (s *Searcher) Presence(ctx context.Context, domain string) (*Presence, error) {
+	if domain == "" {
+		return nil, errors.New("domain is empty")
+	}
+
+	vertice, err := s.v.GetByDomain(ctx, vertices.ReverseDomain(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	if vertice == nil {
+		return &Presence{}, nil
+	}
+
+	result := &Presence{Present: true, ID: vertice.ID()}
+
+	var (
+		wg            sync.WaitGroup
+		outErr, inErr error
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		result.OutDegree, outErr = s.out.Count(ctx, vertice.ID())
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		result.InDegree, inErr = s.in.Count(ctx, vertice.ID())
+	}()
+
+	wg.Wait()
+
+	if outErr != nil {
+		return nil, outErr
+	}
+
+	if inErr != nil {
+		return nil, inErr
+	}
+
+	return result, nil
+}
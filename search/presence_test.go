@@ -0,0 +1,27 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearcher_Presence(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	presence, err := searcher.Presence(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Equal(t, &search.Presence{Present: true, ID: "0", OutDegree: 3, InDegree: 3}, presence)
+
+	presence, err = searcher.Presence(t.Context(), "d.com")
+	require.NoError(t, err)
+	assert.Equal(t, &search.Presence{Present: true, ID: "3", OutDegree: 0, InDegree: 1}, presence)
+
+	presence, err = searcher.Presence(t.Context(), "x.com")
+	require.NoError(t, err)
+	assert.Equal(t, &search.Presence{}, presence)
+}
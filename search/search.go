@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/ranks"
 	"github.com/dharnitski/cc-hosts/vertices"
 )
 
@@ -19,31 +22,176 @@ const (
 	out direction = "out"
 )
 
+// Direction limits search to outgoing or incoming links.
+type Direction string
+
+const (
+	// DirectionBoth searches outgoing and incoming links.
+	DirectionBoth Direction = ""
+	// DirectionOut searches only links from the target, Result.In is empty.
+	DirectionOut Direction = "out"
+	// DirectionIn searches only links to the target, Result.Out is empty.
+	DirectionIn Direction = "in"
+)
+
+func (d Direction) includes(pref direction) bool {
+	return d == DirectionBoth || string(d) == string(pref)
+}
+
+// BatchConcurrency is default max number of domains processed in parallel by batch search.
+const BatchConcurrency = 20
+
+// ErrInvalidOptions is returned for options which are invalid for any domain, sample: unknown rank table.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// optionsError matches ErrInvalidOptions and keeps message of the wrapped error.
+type optionsError struct {
+	err error
+}
+
+func invalidOptions(err error) error {
+	return &optionsError{err: err}
+}
+
+func (e *optionsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *optionsError) Is(target error) bool {
+	return target == ErrInvalidOptions
+}
+
+func (e *optionsError) Unwrap() error {
+	return e.err
+}
+
 type Searcher struct {
 	// from target to other sites
 	out *edges.Edges
 	// from external sites to target
 	in *edges.Edges
 	v  *vertices.Vertices
-	mu sync.Mutex
+	// rank tables by name used for ordering
+	tables map[string]*ranks.Table
+	limits Limits
+	mu     sync.Mutex
 }
 
-func NewSearcher(v *vertices.Vertices, out *edges.Edges, in *edges.Edges) *Searcher {
-	return &Searcher{v: v, out: out, in: in}
+// Limits bound work of one search.
+type Limits struct {
+	// MaxResults is max number of neighbours in one direction
+	MaxResults int
+	// Concurrency is max number of parallel reads
+	Concurrency int
+	// BatchConcurrency is max number of domains processed in parallel by batch search
+	BatchConcurrency int
+}
+
+// DefaultLimits returns limits compiled into packages.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxResults:       edges.DefaultMaxSize,
+		Concurrency:      vertices.Concurrency,
+		BatchConcurrency: BatchConcurrency,
+	}
+}
+
+// Option configures Searcher.
+type Option func(s *Searcher)
+
+// WithLimits replaces DefaultLimits of Searcher.
+func WithLimits(limits Limits) Option {
+	return func(s *Searcher) {
+		s.limits = limits
+	}
+}
+
+func NewSearcher(v *vertices.Vertices, out *edges.Edges, in *edges.Edges, opts ...Option) *Searcher {
+	s := &Searcher{v: v, out: out, in: in, limits: DefaultLimits()}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Graph returns graph granularity of searched data.
+func (s *Searcher) Graph() vertices.Graph {
+	return s.v.Graph()
 }
 
 type Result struct {
-	Target  string         `json:"target"`
-	Out     []string       `json:"out"`
-	In      []string       `json:"in"`
-	Timings map[string]int `json:"timing"`
+	// graph answered the query, host or domain
+	Graph  vertices.Graph `json:"graph"`
+	Target string         `json:"target"`
+	Out    []string       `json:"out"`
+	In     []string       `json:"in"`
+	// hosts that both link to and are linked from the target
+	// filled only when SearchOptions.Mutual is set
+	Mutual []string `json:"mutual,omitempty"`
+	// ranks of the target and neighbours by domain and rank name
+	// filled only when SearchOptions.Ranks is set
+	Ranks   map[string]map[string]float32 `json:"ranks,omitempty"`
+	Timings map[string]int                `json:"timing"`
+}
+
+// SearchOptions controls optional parts of the search result and filters neighbours.
+// Filters are applied to vertice id ranges before neighbours are resolved to domains.
+type SearchOptions struct {
+	// Mutual enables Result.Mutual calculation, it needs both directions
+	Mutual bool
+	// Direction limits search to one direction, both by default
+	Direction Direction
+	// Include keeps only neighbours under these prefixes in reverse domain format
+	// sample: gov or com.example, prefix matches the domain itself and all its subdomains
+	Include []string
+	// Exclude drops neighbours under these prefixes in reverse domain format
+	Exclude []string
+	// ExcludeInternal drops neighbours with the same registered domain as the target
+	ExcludeInternal bool
+	// Deny drops these hosts and their subdomains, hosts are in browser format
+	// sample: fonts.googleapis.com
+	Deny []string
+	// Order defines neighbours order, alphabetical by default
+	Order Order
+	// Limit is max number of neighbours in every direction, Limits.MaxResults when not set or over it
+	Limit int
+	// Ranks are names of rank tables attached to the target and every neighbour
+	Ranks []string
+	// MinRanks keeps neighbours with ranks greater or equal to the value by rank table name
+	MinRanks map[string]float32
+}
+
+// BatchResult is a search result for one domain of the batch.
+// Result is nil when domain is not in the graph.
+type BatchResult struct {
+	Domain string  `json:"domain"`
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Pair is two hosts in browser format.
+// ReciprocalPairs returns hosts linking each other, LinksBatch checks if A links to B.
+type Pair struct {
+	A string `json:"a"`
+	B string `json:"b"`
 }
 
 func (s *Searcher) GetTargets(ctx context.Context, domain string) (*Result, error) {
+	return s.GetTargetsWithOptions(ctx, domain, SearchOptions{})
+}
+
+func (s *Searcher) GetTargetsWithOptions(ctx context.Context, domain string, opts SearchOptions) (*Result, error) {
 	if domain == "" {
 		return nil, errors.New("domain is empty")
 	}
 
+	err := s.validateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	reversed := vertices.ReverseDomain(domain)
 	timings := make(map[string]int)
 	start := time.Now()
@@ -59,7 +207,35 @@ func (s *Searcher) GetTargets(ctx context.Context, domain string) (*Result, erro
 		return nil, nil //nolint:nilnil
 	}
 
-	var outs, ins []string
+	start = time.Now()
+
+	shared, err := s.newIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.targetFilter(ctx, shared, domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	timings["filter"] = int(time.Since(start).Milliseconds())
+
+	edgesStart := time.Now()
+
+	outIDs, inIDs, err := s.getBothIDs(ctx, vertice.ID(), timings, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// intersect before domain resolution, mutual hosts are subset of out hosts
+	// and we resolve them together with out hosts
+	var mutualIDs map[string]struct{}
+	if opts.Mutual {
+		mutualIDs = intersect(outIDs, inIDs)
+	}
+
+	var outs, ins []vertices.Vertice
 
 	var outErr, inErr error
 
@@ -70,13 +246,13 @@ func (s *Searcher) GetTargets(ctx context.Context, domain string) (*Result, erro
 	go func() {
 		defer wg.Done()
 
-		outs, outErr = s.getDomains(ctx, vertice.ID(), timings, out)
+		outs, outErr = s.getVertices(ctx, outIDs, timings, out, edgesStart)
 	}()
 
 	go func() {
 		defer wg.Done()
 
-		ins, inErr = s.getDomains(ctx, vertice.ID(), timings, in)
+		ins, inErr = s.getVertices(ctx, inIDs, timings, in, edgesStart)
 	}()
 	wg.Wait()
 
@@ -88,24 +264,464 @@ func (s *Searcher) GetTargets(ctx context.Context, domain string) (*Result, erro
 		return nil, inErr
 	}
 
-	return &Result{Target: domain, Out: outs, In: ins, Timings: timings}, nil
+	result := s.newResult(domain, outs, ins, mutualIDs, opts, timings)
+
+	start = time.Now()
+
+	err = s.attachRanks(ctx, opts.Ranks, []rankTarget{newRankTarget(result, host{domain: domain, id: vertice.ID()}, outs, ins)})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Ranks) > 0 {
+		timings["ranks"] = int(time.Since(start).Milliseconds())
+	}
+
+	return result, nil
+}
+
+// validateOptions checks options which do not depend on the domain.
+func (s *Searcher) validateOptions(opts SearchOptions) error {
+	switch opts.Direction {
+	case DirectionBoth, DirectionOut, DirectionIn:
+	default:
+		return invalidOptions(fmt.Errorf("unknown direction: %q", opts.Direction))
+	}
+
+	if opts.Mutual && opts.Direction != DirectionBoth {
+		return invalidOptions(errors.New("mutual links need both directions"))
+	}
+
+	names := slices.Clone(opts.Ranks)
+	if opts.Order.ranked() {
+		names = append(names, string(opts.Order))
+	}
+
+	for name := range opts.MinRanks {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		_, err := s.rankTable(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newResult builds search result from resolved neighbours.
+// mutualIDs is nil when mutual hosts are not requested.
+func (s *Searcher) newResult(
+	domain string, outs []vertices.Vertice, ins []vertices.Vertice, mutualIDs map[string]struct{},
+	opts SearchOptions, timings map[string]int,
+) *Result {
+	result := &Result{
+		Graph:   s.v.Graph(),
+		Target:  domain,
+		Out:     toDomains(outs, opts, s.limit(opts)),
+		In:      toDomains(ins, opts, s.limit(opts)),
+		Timings: timings,
+	}
+
+	if mutualIDs != nil {
+		// mutual hosts are subset of out hosts and already resolved
+		mutual := make([]vertices.Vertice, 0, len(mutualIDs))
+
+		for _, v := range outs {
+			if _, ok := mutualIDs[v.ID()]; ok {
+				mutual = append(mutual, v)
+			}
+		}
+
+		result.Mutual = toDomains(mutual, opts, s.limit(opts))
+	}
+
+	return result
+}
+
+// lookup returns resolved vertices for ids, unresolved ids are skipped.
+func lookup(ids []string, byID map[string]vertices.Vertice) []vertices.Vertice {
+	results := make([]vertices.Vertice, 0, len(ids))
+
+	for _, id := range ids {
+		if v, ok := byID[id]; ok {
+			results = append(results, v)
+		}
+	}
+
+	return results
+}
+
+// ReciprocalPairs returns pairs of hosts from domains list that link to each other.
+// Unknown domains are ignored. Pairs are sorted and A is always less than B.
+// Every pair is checked in both directions, so hubs with more links than
+// Limits.MaxResults do not lose pairs.
+func (s *Searcher) ReciprocalPairs(ctx context.Context, domains []string) ([]Pair, error) {
+	ids, err := s.resolveDomains(ctx, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	// known domains are sorted, so A of every candidate is less than B
+	known := make([]host, 0, len(ids))
+	for domain, id := range ids {
+		known = append(known, host{domain: domain, id: id})
+	}
+
+	sort.Slice(known, func(i, j int) bool {
+		return known[i].domain < known[j].domain
+	})
+
+	// candidate pair i is checked by items 2*i, a to b, and 2*i+1, b to a
+	candidates := make([]Pair, 0)
+	items := make([]edges.Edge, 0)
+
+	for i, a := range known {
+		for _, b := range known[i+1:] {
+			if a.id == b.id {
+				continue
+			}
+
+			candidates = append(candidates, Pair{A: a.domain, B: b.domain})
+			items = append(items, edges.NewEdge(a.id, b.id), edges.NewEdge(b.id, a.id))
+		}
+	}
+
+	found, err := s.out.HasBatch(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]Pair, 0)
+
+	for i, pair := range candidates {
+		if found[2*i] && found[2*i+1] {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	return pairs, nil
 }
 
-func (s *Searcher) getDomains(ctx context.Context, verticeID string, timings map[string]int, pref direction) ([]string, error) {
-	allStart := time.Now()
+// Links reports whether host a links to host b.
+// Unknown hosts do not link anywhere.
+func (s *Searcher) Links(ctx context.Context, a string, b string) (bool, error) {
+	results, err := s.LinksBatch(ctx, []Pair{{A: a, B: b}})
+	if err != nil {
+		return false, err
+	}
+
+	return results[0], nil
+}
+
+// LinksBatch checks for every pair whether A links to B, result is aligned with pairs.
+func (s *Searcher) LinksBatch(ctx context.Context, pairs []Pair) ([]bool, error) {
+	domains := make([]string, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		domains = append(domains, pair.A, pair.B)
+	}
+
+	ids, err := s.resolveDomains(ctx, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]edges.Edge, 0, len(pairs))
+	// index in items for every pair, -1 when any of hosts is unknown
+	positions := make([]int, len(pairs))
+
+	for i, pair := range pairs {
+		fromID, toID := ids[pair.A], ids[pair.B]
+		if fromID == "" || toID == "" {
+			positions[i] = -1
+
+			continue
+		}
 
-	var edges *edges.Edges
+		positions[i] = len(items)
+		items = append(items, edges.NewEdge(fromID, toID))
+	}
+
+	found, err := s.out.HasBatch(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]bool, len(pairs))
+
+	for i, position := range positions {
+		if position >= 0 {
+			results[i] = found[position]
+		}
+	}
+
+	return results, nil
+}
+
+// GetTargetsBatch searches many domains at once, result is aligned with domains.
+// Domains are resolved with chunk grouped lookups and neighbours shared by
+// several results are resolved only once.
+func (s *Searcher) GetTargetsBatch(ctx context.Context, domains []string, opts SearchOptions) ([]BatchResult, error) {
+	err := s.validateOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	ids, err := s.resolveDomains(ctx, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	resolveTime := int(time.Since(start).Milliseconds())
+
+	shared, err := s.newIDFilter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	type found struct {
+		outIDs    []string
+		inIDs     []string
+		mutualIDs map[string]struct{}
+		timings   map[string]int
+		err       error
+	}
+
+	// edges for every known vertice
+	edgesByID := make(map[string]*found, len(ids))
+	domainByID := make(map[string]string, len(ids))
+
+	for domain, id := range ids {
+		edgesByID[id] = &found{timings: map[string]int{"get_by_domain": resolveTime}}
+		domainByID[id] = domain
+	}
+
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, s.limits.BatchConcurrency)
+
+	for id, f := range edgesByID {
+		wg.Add(1)
+
+		semaphore <- struct{}{}
+
+		go func(id string, f *found) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			filter, err := s.targetFilter(ctx, shared, domainByID[id], opts)
+			if err != nil {
+				f.err = err
+
+				return
+			}
+
+			f.outIDs, f.inIDs, f.err = s.getBothIDs(ctx, id, f.timings, filter, opts)
+			if f.err == nil && opts.Mutual {
+				f.mutualIDs = intersect(f.outIDs, f.inIDs)
+			}
+		}(id, f)
+	}
+
+	wg.Wait()
+
+	// all neighbours resolved together, overlapping ids are looked up once
+	neighbours := make(map[string]struct{})
+
+	for _, f := range edgesByID {
+		for _, ids := range [][]string{f.outIDs, f.inIDs} {
+			for _, id := range ids {
+				neighbours[id] = struct{}{}
+			}
+		}
+	}
+
+	start = time.Now()
+
+	resolved, err := s.v.GetByIDs(ctx, slices.Collect(maps.Keys(neighbours)))
+	if err != nil {
+		// failed lookup is repeated per domain, so it fails only domains it belongs to
+		resolved = nil
+
+		for _, f := range edgesByID {
+			if f.err != nil {
+				continue
+			}
+
+			found, err := s.v.GetByIDs(ctx, slices.Concat(f.outIDs, f.inIDs))
+			if err != nil {
+				f.err = err
+
+				continue
+			}
+
+			resolved = append(resolved, found...)
+		}
+	}
+
+	resolveTime = int(time.Since(start).Milliseconds())
+
+	byID := make(map[string]vertices.Vertice, len(resolved))
+	for _, v := range resolved {
+		byID[v.ID()] = v
+	}
+
+	results := make([]BatchResult, 0, len(domains))
+	targets := make([]rankTarget, 0, len(ids))
+	// index of result for every rank target
+	positions := make([]int, 0, len(ids))
+
+	for _, domain := range domains {
+		result := BatchResult{Domain: domain}
+
+		id, ok := ids[domain]
+
+		switch {
+		case domain == "":
+			result.Error = "domain is empty"
+		case !ok:
+			// not in the graph
+		case edgesByID[id].err != nil:
+			result.Error = edgesByID[id].err.Error()
+		default:
+			f := edgesByID[id]
+			timings := maps.Clone(f.timings)
+			timings["v_get_by_ids"] = resolveTime
+			outs, ins := lookup(f.outIDs, byID), lookup(f.inIDs, byID)
+			result.Result = s.newResult(domain, outs, ins, f.mutualIDs, opts, timings)
+			targets = append(targets, newRankTarget(result.Result, host{domain: domain, id: id}, outs, ins))
+			positions = append(positions, len(results))
+		}
+
+		results = append(results, result)
+	}
+
+	err = s.attachRanks(ctx, opts.Ranks, targets)
+	if err != nil {
+		// ranks are read per result to find results they fail
+		for i, target := range targets {
+			err := s.attachRanks(ctx, opts.Ranks, []rankTarget{target})
+			if err != nil {
+				results[positions[i]].Result = nil
+				results[positions[i]].Error = err.Error()
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// resolveDomains returns vertice ids for domains in browser format.
+// Unknown and empty domains are not in the result.
+func (s *Searcher) resolveDomains(ctx context.Context, domains []string) (map[string]string, error) {
+	unique := make([]string, 0, len(domains))
+	seen := make(map[string]struct{}, len(domains))
+
+	for _, domain := range domains {
+		if _, ok := seen[domain]; ok || domain == "" {
+			continue
+		}
+
+		seen[domain] = struct{}{}
+
+		unique = append(unique, domain)
+	}
+
+	reversed := make([]string, 0, len(unique))
+	for _, domain := range unique {
+		reversed = append(reversed, vertices.ReverseDomain(domain))
+	}
+
+	found, err := s.v.GetByDomains(ctx, reversed)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(unique))
+
+	for i, vertice := range found {
+		if vertice != nil {
+			ids[unique[i]] = vertice.ID()
+		}
+	}
+
+	return ids, nil
+}
+
+// getBothIDs loads out and in vertice ids for the vertice in parallel.
+func (s *Searcher) getBothIDs(
+	ctx context.Context, verticeID string, timings map[string]int, filter edges.Filter, opts SearchOptions,
+) ([]string, []string, error) {
+	var outIDs, inIDs []string
+
+	var outErr, inErr error
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		outIDs, outErr = s.getIDs(ctx, verticeID, timings, out, filter, opts)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		inIDs, inErr = s.getIDs(ctx, verticeID, timings, in, filter, opts)
+	}()
+	wg.Wait()
+
+	if outErr != nil {
+		return nil, nil, outErr
+	}
+
+	if inErr != nil {
+		return nil, nil, inErr
+	}
+
+	return outIDs, inIDs, nil
+}
+
+// getIDs loads neighbour ids, they are ordered and truncated to the limit unless order is alphabetical.
+func (s *Searcher) getIDs(
+	ctx context.Context, verticeID string, timings map[string]int, pref direction, filter edges.Filter, opts SearchOptions,
+) ([]string, error) {
+	if !opts.Direction.includes(pref) {
+		return []string{}, nil
+	}
+
+	var run *edges.Edges
 
 	switch pref {
 	case out:
-		edges = s.out
+		run = s.out
 	case in:
-		edges = s.in
+		run = s.in
 	}
 
 	start := time.Now()
 
-	outIDs, err := edges.Get(ctx, verticeID)
+	// alphabetical order needs domains, ids are ordered after they are resolved
+	if opts.Order == OrderAlphabetical && len(opts.MinRanks) == 0 {
+		ids, err := run.GetLimited(ctx, verticeID, filter, s.limits.MaxResults)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		timings[fmt.Sprintf("edges_get_%s", pref)] = int(time.Since(start).Milliseconds())
+		s.mu.Unlock()
+
+		return ids, nil
+	}
+
+	numbers, err := scanIDs(ctx, run, verticeID, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -116,25 +732,55 @@ func (s *Searcher) getDomains(ctx context.Context, verticeID string, timings map
 
 	start = time.Now()
 
-	domains, err := s.v.GetByIDs(ctx, outIDs)
+	ids, err := s.orderIDs(ctx, numbers, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	s.mu.Lock()
-	timings[fmt.Sprintf("v_get_by_ids_%s", pref)] = int(time.Since(start).Milliseconds())
+	timings[fmt.Sprintf("order_%s", pref)] = int(time.Since(start).Milliseconds())
 	s.mu.Unlock()
 
-	results := make([]string, 0, len(domains))
-	for _, d := range domains {
-		results = append(results, vertices.ReverseDomain(d.Domain()))
-	}
+	return ids, nil
+}
 
-	sort.Strings(results)
+func (s *Searcher) getVertices(
+	ctx context.Context, ids []string, timings map[string]int, pref direction, allStart time.Time,
+) ([]vertices.Vertice, error) {
+	start := time.Now()
+
+	domains, err := s.v.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
 
 	s.mu.Lock()
+	timings[fmt.Sprintf("v_get_by_ids_%s", pref)] = int(time.Since(start).Milliseconds())
 	timings[fmt.Sprintf("%s_domains", pref)] = int(time.Since(allStart).Milliseconds())
 	s.mu.Unlock()
 
-	return results, nil
+	byID := make(map[string]vertices.Vertice, len(domains))
+	for _, d := range domains {
+		byID[d.ID()] = d
+	}
+
+	// keep order of ids
+	return lookup(ids, byID), nil
+}
+
+func intersect(a []string, b []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(a))
+	for _, id := range a {
+		set[id] = struct{}{}
+	}
+
+	result := make(map[string]struct{})
+
+	for _, id := range b {
+		if _, ok := set[id]; ok {
+			result[id] = struct{}{}
+		}
+	}
+
+	return result
 }
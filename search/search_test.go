@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/dharnitski/cc-hosts/access/file"
@@ -98,3 +99,156 @@ func TestSearcher_Missed(t *testing.T) {
 	err = os.WriteFile("output.json", jsonData, 0o644)
 	require.NoError(t, err)
 }
+
+func TestSearcher_Mutual(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	results, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Mutual: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com", "c.com", "d.com"}, results.Out)
+	assert.Equal(t, []string{"b.com", "c.com", "e.org"}, results.In)
+	assert.Equal(t, []string{"b.com", "c.com"}, results.Mutual)
+
+	results, err = searcher.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Nil(t, results.Mutual)
+	assert.Equal(t, vertices.GraphHost, results.Graph)
+}
+
+func TestSearcher_ReciprocalPairs(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	pairs, err := searcher.ReciprocalPairs(t.Context(), []string{"d.com", "c.com", "b.com", "a.com", "x.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []search.Pair{{A: "a.com", B: "b.com"}, {A: "a.com", B: "c.com"}}, pairs)
+
+	// a.com links more hosts than the limit, its pairs are still found
+	limits := search.DefaultLimits()
+	limits.MaxResults = 1
+	searcher = newTestSearcher(t, testDomains, testLinks, search.WithLimits(limits))
+
+	pairs, err = searcher.ReciprocalPairs(t.Context(), []string{"c.com", "a.com", "b.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []search.Pair{{A: "a.com", B: "b.com"}, {A: "a.com", B: "c.com"}}, pairs)
+}
+
+func TestSearcher_Links(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	found, err := searcher.Links(t.Context(), "c.com", "b.com")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = searcher.Links(t.Context(), "b.com", "c.com")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	results, err := searcher.LinksBatch(t.Context(), []search.Pair{
+		{A: "a.com", B: "d.com"},
+		{A: "d.com", B: "a.com"},
+		{A: "x.com", B: "a.com"},
+		{A: "e.org", B: "a.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false, true}, results)
+}
+
+func TestSearcher_GetTargetsBatch(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	results, err := searcher.GetTargetsBatch(t.Context(), []string{"a.com", "x.com", "", "e.org", "a.com"}, search.SearchOptions{Mutual: true})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, "a.com", results[0].Domain)
+	require.NotNil(t, results[0].Result)
+	assert.Equal(t, []string{"b.com", "c.com", "d.com"}, results[0].Result.Out)
+	assert.Equal(t, []string{"b.com", "c.com", "e.org"}, results[0].Result.In)
+	assert.Equal(t, []string{"b.com", "c.com"}, results[0].Result.Mutual)
+
+	assert.Equal(t, search.BatchResult{Domain: "x.com"}, results[1])
+	assert.Equal(t, "domain is empty", results[2].Error)
+
+	require.NotNil(t, results[3].Result)
+	assert.Equal(t, []string{"a.com"}, results[3].Result.Out)
+	assert.Equal(t, []string{}, results[3].Result.In)
+	assert.Empty(t, results[3].Result.Mutual)
+
+	assert.Equal(t, results[0].Result.Out, results[4].Result.Out)
+}
+
+func TestSearcher_GetTargetsBatch_DomainError(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	folder := filepath.Join(root, edges.EdgesFolder)
+	// b.com links vertice with broken ID
+	size := writeLines(t, folder, []string{"0\t1", "1\tbad"})
+
+	offsets := edges.Offsets{}
+	offsets.Append([]edges.Offset{edges.NewOffset(0, "0", testFile), edges.NewOffset(size, "1", testFile)})
+
+	out := edges.NewEdges(file.NewGetter(folder), offsets)
+	in := newTestEdges(t, filepath.Join(root, edges.EdgesReversedFolder), [][2]int{{1, 0}})
+	searcher := search.NewSearcher(newTestVertices(t, root, testDomains), out, in)
+
+	results, err := searcher.GetTargetsBatch(t.Context(), []string{"a.com", "b.com"}, search.SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.NotNil(t, results[0].Result)
+	assert.Equal(t, []string{"b.com"}, results[0].Result.Out)
+	assert.Empty(t, results[0].Error)
+
+	assert.Nil(t, results[1].Result)
+	assert.Contains(t, results[1].Error, "invalid ID: bad")
+}
+
+func TestSearcher_Direction(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, testDomains, testLinks)
+
+	results, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Direction: search.DirectionOut})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com", "c.com", "d.com"}, results.Out)
+	assert.Empty(t, results.In)
+	assert.NotContains(t, results.Timings, "edges_get_in")
+
+	results, err = searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Direction: search.DirectionIn})
+	require.NoError(t, err)
+	assert.Empty(t, results.Out)
+	assert.Equal(t, []string{"b.com", "c.com", "e.org"}, results.In)
+
+	_, err = searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Direction: "up"})
+	require.Error(t, err)
+
+	_, err = searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Direction: search.DirectionIn, Mutual: true})
+	require.Error(t, err)
+}
+
+func TestSearcher_WithLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := search.DefaultLimits()
+	limits.MaxResults = 2
+	searcher := newTestSearcher(t, testDomains, testLinks, search.WithLimits(limits))
+
+	// a.com links b.com, c.com and d.com, requested limit can't exceed max results
+	result, err := searcher.GetTargetsWithOptions(t.Context(), "a.com", search.SearchOptions{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com", "c.com"}, result.Out)
+
+	subdomains, err := newTestSearcher(t, []string{"com.a", "com.a.x", "com.a.y", "com.a.z"}, testLinks[:1],
+		search.WithLimits(limits)).Subdomains(t.Context(), "a.com", 0)
+	require.NoError(t, err)
+	assert.Len(t, subdomains, 2)
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+// Subdomains returns hosts under domain in browser format, domain itself is not included.
+// Hosts are sorted by reversed domain, only the first limit hosts are returned, 0 means Limits.MaxResults.
+func (s *Searcher) Subdomains(ctx context.Context, domain string, limit int) ([]string, error) {
+	if domain == "" {
+		return nil, errors.New("domain is empty")
+	}
+
+	if limit <= 0 {
+		limit = s.limits.MaxResults
+	}
+
+	reversed := vertices.ReverseDomain(domain)
+
+	ranges, err := s.v.PrefixRanges(ctx, reversed)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving subdomains of %q: %w", domain, err)
+	}
+
+	// the domain itself can take one id in front of subdomains
+	ids := make([]string, 0, limit+1)
+
+	for _, r := range ranges {
+		for id := r.From; id < r.To && len(ids) <= limit; id++ {
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+
+	found, err := s.v.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]vertices.Vertice, 0, len(found))
+
+	for _, vertice := range found {
+		if vertice.Domain() != reversed {
+			items = append(items, vertice)
+		}
+	}
+
+	// ids grow with reversed domain
+	slices.SortFunc(items, func(a, b vertices.Vertice) int {
+		return strings.Compare(a.Domain(), b.Domain())
+	})
+
+	results := make([]string, 0, len(items))
+	for _, vertice := range items[:min(len(items), limit)] {
+		results = append(results, vertices.ReverseDomain(vertice.Domain()))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,35 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearcher_Subdomains(t *testing.T) {
+	t.Parallel()
+
+	searcher := newTestSearcher(t, filterDomains, filterLinks)
+
+	tests := []struct {
+		domain   string
+		limit    int
+		expected []string
+	}{
+		{domain: "a.com", expected: []string{"blog.a.com"}},
+		{domain: "gov", expected: []string{"nasa.gov", "www.nasa.gov"}},
+		{domain: "gov", limit: 1, expected: []string{"nasa.gov"}},
+		{domain: "www.nasa.gov", expected: []string{}},
+		{domain: "x.com", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		hosts, err := searcher.Subdomains(t.Context(), test.domain, test.limit)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, hosts, test.domain)
+	}
+
+	_, err := searcher.Subdomains(t.Context(), "", 0)
+	require.Error(t, err)
+}
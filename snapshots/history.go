@@ -0,0 +1,150 @@
+package snapshots
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/dharnitski/cc-hosts/search"
+)
+
+const (
+	// max number of snapshots searched in parallel by history
+	HistoryConcurrency = 4
+)
+
+// Point is presence of host in one snapshot.
+type Point struct {
+	Snapshot string `json:"snapshot"`
+	search.Presence
+}
+
+// History is presence of host across snapshots in requested order.
+type History struct {
+	Host string `json:"host"`
+	// first and last snapshots with the host, empty when host is not found
+	FirstSeen string  `json:"first_seen,omitempty"`
+	LastSeen  string  `json:"last_seen,omitempty"`
+	Timeline  []Point `json:"timeline"`
+}
+
+// History reports presence and degrees of host in every snapshot.
+// Names are expected in time order, all snapshots sorted by release date are used when names are empty.
+func (r *Registry) History(ctx context.Context, host string, names []string) (*History, error) {
+	if host == "" {
+		return nil, errors.New("host is empty")
+	}
+
+	if len(names) == 0 {
+		released, err := r.Released()
+		if err != nil {
+			return nil, err
+		}
+
+		names = released
+	}
+
+	timeline := make([]Point, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, HistoryConcurrency)
+
+	for i, name := range names {
+		wg.Add(1)
+
+		semaphore <- struct{}{}
+
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			timeline[i].Snapshot = name
+
+			searcher, err := r.Get(ctx, name)
+			if err != nil {
+				errs[i] = err
+
+				return
+			}
+
+			presence, err := searcher.Presence(ctx, host)
+			if err != nil {
+				errs[i] = fmt.Errorf("error searching snapshot %q: %w", name, err)
+
+				return
+			}
+
+			timeline[i].Presence = *presence
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	failed := make([]error, 0)
+
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("errors: %v", failed)
+	}
+
+	history := &History{Host: host, Timeline: timeline}
+
+	for _, point := range timeline {
+		if !point.Present {
+			continue
+		}
+
+		if history.FirstSeen == "" {
+			history.FirstSeen = point.Snapshot
+		}
+
+		history.LastSeen = point.Snapshot
+	}
+
+	return history, nil
+}
+
+// WriteCSVHeader writes CSV header of history rows, it is written once per output.
+func WriteCSVHeader(w io.Writer) error {
+	return writeCSV(w, [][]string{{"host", "snapshot", "present", "id", "out_degree", "in_degree"}})
+}
+
+// WriteCSVRows writes timeline as CSV rows without header, histories of many hosts share one header.
+func (h *History) WriteCSVRows(w io.Writer) error {
+	rows := make([][]string, 0, len(h.Timeline))
+
+	for _, point := range h.Timeline {
+		rows = append(rows, []string{
+			h.Host,
+			point.Snapshot,
+			strconv.FormatBool(point.Present),
+			point.ID,
+			strconv.Itoa(point.OutDegree),
+			strconv.Itoa(point.InDegree),
+		})
+	}
+
+	return writeCSV(w, rows)
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return nil
+}
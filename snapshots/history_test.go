@@ -0,0 +1,100 @@
+package snapshots_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_History(t *testing.T) {
+	t.Parallel()
+
+	// a.com is not in the first and the last snapshots
+	other := newTestLinkSnapshot(t, "com.b", "com.c")
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default: "2024",
+		Snapshots: map[string]snapshots.Snapshot{
+			"2023": other,
+			"2024": newTestSnapshot(t),
+			"2025": other,
+		},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	history, err := registry.History(t.Context(), "a.com", []string{"2023", "2024", "2025"})
+	require.NoError(t, err)
+	assert.Equal(t, &snapshots.History{
+		Host:      "a.com",
+		FirstSeen: "2024",
+		LastSeen:  "2024",
+		Timeline: []snapshots.Point{
+			{Snapshot: "2023"},
+			{Snapshot: "2024", Presence: search.Presence{Present: true, ID: "0", OutDegree: 1}},
+			{Snapshot: "2025"},
+		},
+	}, history)
+
+	second, err := registry.History(t.Context(), "b.com", []string{"2023", "2024"})
+	require.NoError(t, err)
+
+	// header is written once for two hosts
+	csv := strings.Builder{}
+	require.NoError(t, snapshots.WriteCSVHeader(&csv))
+	require.NoError(t, history.WriteCSVRows(&csv))
+	require.NoError(t, second.WriteCSVRows(&csv))
+	assert.Equal(t, "host,snapshot,present,id,out_degree,in_degree\n"+
+		"a.com,2023,false,,0,0\n"+
+		"a.com,2024,true,0,1,0\n"+
+		"a.com,2025,false,,0,0\n"+
+		"b.com,2023,true,0,1,0\n"+
+		"b.com,2024,true,1,0,1\n", csv.String())
+
+	_, err = registry.History(t.Context(), "a.com", []string{"2024", "missed"})
+	require.Error(t, err)
+}
+
+func TestRegistry_HistoryReleased(t *testing.T) {
+	t.Parallel()
+
+	other := newTestLinkSnapshot(t, "com.b", "com.c")
+	older := newTestSnapshot(t)
+	older.Released = "2024-10-01"
+	newer := other
+	newer.Released = "2024-12-01"
+
+	// names are not in time order, release dates are
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default: "cc-main-2024-oct-nov-dec",
+		Snapshots: map[string]snapshots.Snapshot{
+			"cc-main-2024-oct-nov-dec": newer,
+			"cc-main-2024-sep":         older,
+		},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cc-main-2024-sep", "cc-main-2024-oct-nov-dec"}, registry.Names())
+
+	history, err := registry.History(t.Context(), "a.com", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "cc-main-2024-sep", history.FirstSeen)
+	assert.Equal(t, "cc-main-2024-sep", history.LastSeen)
+	assert.Equal(t, "cc-main-2024-oct-nov-dec", history.Timeline[1].Snapshot)
+
+	// time order is unknown without release dates
+	registry, err = snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": other, "b": older},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	_, err = registry.History(t.Context(), "a.com", nil)
+	require.ErrorIs(t, err, snapshots.ErrNoReleaseOrder)
+
+	history, err = registry.History(t.Context(), "a.com", []string{"b", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, "b", history.FirstSeen)
+}
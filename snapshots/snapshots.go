@@ -0,0 +1,581 @@
+package snapshots
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/dharnitski/cc-hosts/access"
+	"github.com/dharnitski/cc-hosts/access/aws"
+	"github.com/dharnitski/cc-hosts/access/blocks"
+	"github.com/dharnitski/cc-hosts/access/file"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/ranks"
+	"github.com/dharnitski/cc-hosts/search"
+	"github.com/dharnitski/cc-hosts/vertices"
+)
+
+const (
+	// DefaultName is the snapshot of default config, it is served from embedded offsets.
+	DefaultName = "default"
+	s3Scheme    = "s3://"
+	// region of Common Crawl bucket, used when AWS config has no region
+	defaultRegion = "us-east-1"
+)
+
+var (
+	// ErrUnknownSnapshot is returned for snapshot missed in config.
+	ErrUnknownSnapshot = errors.New("unknown snapshot")
+	// ErrNoReleaseOrder is returned when snapshots have to be ordered in time but have no release dates.
+	ErrNoReleaseOrder = errors.New("snapshots have no release dates")
+)
+
+// Config is a registry of Common Crawl releases, sample:
+//
+//	{
+//	  "default": "cc-main-2024-oct-nov-dec",
+//	  "snapshots": {
+//	    "cc-main-2024-oct-nov-dec": {"location": "s3://common-crawl-hosts/cc-main-2024-oct-nov-dec", "offsets": "offsets/cc-main-2024-oct-nov-dec", "released": "2024-12-01"}
+//	  }
+//	}
+type Config struct {
+	// Default is used when request has no snapshot
+	Default   string              `json:"default"`
+	Snapshots map[string]Snapshot `json:"snapshots"`
+	// Folders are names of data folders in every location, indexer names are used for empty ones
+	Folders Folders `json:"folders,omitzero"`
+	// MaxLoaded is max number of snapshots kept in memory, least recently used one is dropped, 0 keeps all
+	MaxLoaded int `json:"max_loaded,omitempty"`
+}
+
+// Folders are names of data folders in snapshot location.
+type Folders struct {
+	Vertices      string `json:"vertices,omitempty"`
+	Edges         string `json:"edges,omitempty"`
+	EdgesReversed string `json:"edges_reversed,omitempty"`
+	Ranks         string `json:"ranks,omitempty"`
+}
+
+// DefaultFolders returns folders written by indexer.
+func DefaultFolders() Folders {
+	return Folders{
+		Vertices:      vertices.Folder,
+		Edges:         edges.EdgesFolder,
+		EdgesReversed: edges.EdgesReversedFolder,
+		Ranks:         ranks.Folder,
+	}
+}
+
+// withDefaults replaces empty names with default ones.
+func (f Folders) withDefaults() Folders {
+	defaults := DefaultFolders()
+
+	return Folders{
+		Vertices:      cmp.Or(f.Vertices, defaults.Vertices),
+		Edges:         cmp.Or(f.Edges, defaults.Edges),
+		EdgesReversed: cmp.Or(f.EdgesReversed, defaults.EdgesReversed),
+		Ranks:         cmp.Or(f.Ranks, defaults.Ranks),
+	}
+}
+
+// Snapshot is one indexed Common Crawl release.
+type Snapshot struct {
+	// Location is local folder or s3://bucket/prefix with vertices, edges and ranks folders
+	Location string `json:"location"`
+	// Offsets is local folder with offsets files and manifest, embedded offsets are used when empty
+	Offsets string `json:"offsets,omitempty"`
+	// Ranks are names of rank tables in ranks folder
+	Ranks []string `json:"ranks,omitempty"`
+	// Released is release date in 2006-01-02 format, it orders snapshots in time,
+	// names of Common Crawl releases like cc-main-2024-oct-nov-dec are not sorted in time
+	Released string `json:"released,omitempty"`
+}
+
+// DefaultConfig serves embedded offsets from the bucket used before registry was added.
+func DefaultConfig() Config {
+	return Config{
+		Default:   DefaultName,
+		Snapshots: map[string]Snapshot{DefaultName: {Location: s3Scheme + aws.Bucket}},
+	}
+}
+
+// LoadConfig reads config in JSON format.
+func LoadConfig(reader io.Reader) (*Config, error) {
+	var cfg Config
+
+	err := json.NewDecoder(reader).Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing snapshots config: %w", err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// LoadConfigFile reads config from file.
+func LoadConfigFile(fileName string) (*Config, error) {
+	file, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	return LoadConfig(file)
+}
+
+func (c *Config) Validate() error {
+	if len(c.Snapshots) == 0 {
+		return errors.New("no snapshots in config")
+	}
+
+	if c.MaxLoaded < 0 {
+		return fmt.Errorf("invalid max_loaded: %d", c.MaxLoaded)
+	}
+
+	if _, ok := c.Snapshots[c.Default]; !ok {
+		return fmt.Errorf("default snapshot %q is not in config", c.Default)
+	}
+
+	for name, snapshot := range c.Snapshots {
+		if snapshot.Location == "" {
+			return fmt.Errorf("snapshot %q has no location", name)
+		}
+
+		if snapshot.Released != "" {
+			_, err := time.Parse(time.DateOnly, snapshot.Released)
+			if err != nil {
+				return fmt.Errorf("snapshot %q has invalid release date: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetterFunc creates Getter for folder in snapshot location.
+type GetterFunc func(ctx context.Context, location string, folder string) (access.Getter, error)
+
+// S3Options configures client of s3:// locations.
+type S3Options struct {
+	// Endpoint is URL of S3 compatible server, AWS is used when empty
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region overrides region of AWS config
+	Region string `json:"region,omitempty"`
+	// PathStyle addresses bucket in path instead of host name
+	PathStyle bool `json:"path_style,omitempty"`
+	// AccessKeyID and SecretAccessKey replace credentials of AWS config
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	// Anonymous sends unsigned requests to public buckets
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// options converts S3Options into S3 client options.
+func (o S3Options) options() []aws.Option {
+	var opts []aws.Option
+
+	if o.Endpoint != "" {
+		opts = append(opts, aws.WithEndpoint(o.Endpoint))
+	}
+
+	if o.PathStyle {
+		opts = append(opts, aws.WithPathStyle())
+	}
+
+	switch {
+	case o.Anonymous:
+		opts = append(opts, aws.WithAnonymous())
+	case o.AccessKeyID != "":
+		opts = append(opts, aws.WithStaticCredentials(o.AccessKeyID, o.SecretAccessKey, o.SessionToken))
+	}
+
+	return opts
+}
+
+// NewGetter creates S3 Getter for s3://bucket/prefix location and file Getter for local folder.
+func NewGetter(ctx context.Context, location string, folder string) (access.Getter, error) {
+	return NewGetterFunc(S3Options{})(ctx, location, folder)
+}
+
+// NewGetterFunc returns GetterFunc creating S3 Getters with options, local folders are read from files.
+func NewGetterFunc(opts S3Options) GetterFunc {
+	return func(ctx context.Context, location string, folder string) (access.Getter, error) {
+		bucketPath, ok := strings.CutPrefix(location, s3Scheme)
+		if !ok {
+			return file.NewGetter(path.Join(location, folder)), nil
+		}
+
+		bucket, prefix, _ := strings.Cut(bucketPath, "/")
+
+		var loadOpts []func(*config.LoadOptions) error
+		if opts.Region != "" {
+			loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+		}
+
+		cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("error loading AWS config: %w", err)
+		}
+
+		// machines without AWS config still can read public buckets and local servers
+		if cfg.Region == "" {
+			cfg.Region = defaultRegion
+		}
+
+		return aws.New(cfg, bucket, path.Join(prefix, folder), opts.options()...), nil
+	}
+}
+
+// Registry creates Searcher for snapshot on first use and caches it.
+type Registry struct {
+	config    Config
+	newGetter GetterFunc
+	// limits of every loaded Searcher
+	limits search.Limits
+	// entries are created for all snapshots in config and never change
+	entries map[string]*entry
+
+	// mu guards recent, names of loaded snapshots from least to most recently used
+	mu     sync.Mutex
+	recent []string
+}
+
+// entry is a lazy loaded Searcher, failed loads are retried on the next request.
+type entry struct {
+	mu       sync.Mutex
+	searcher *search.Searcher
+}
+
+// Option configures Registry.
+type Option func(r *Registry)
+
+// WithLimits sets limits of loaded snapshots, search.DefaultLimits by default.
+func WithLimits(limits search.Limits) Option {
+	return func(r *Registry) {
+		r.limits = limits
+	}
+}
+
+func NewRegistry(cfg Config, newGetter GetterFunc, opts ...Option) (*Registry, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*entry, len(cfg.Snapshots))
+	for name := range cfg.Snapshots {
+		entries[name] = &entry{}
+	}
+
+	r := &Registry{config: cfg, newGetter: newGetter, entries: entries, limits: search.DefaultLimits()}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// Default returns name of default snapshot.
+func (r *Registry) Default() string {
+	return r.config.Default
+}
+
+// Names returns names of all snapshots sorted by release date and name.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.config.Snapshots))
+	for name := range r.config.Snapshots {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(r.config.Snapshots[a].Released, r.config.Snapshots[b].Released), cmp.Compare(a, b))
+	})
+
+	return names
+}
+
+// Released returns names of all snapshots in time order.
+// It fails when more than one snapshot is configured and any of them has no release date.
+func (r *Registry) Released() ([]string, error) {
+	names := r.Names()
+	if len(names) < 2 {
+		return names, nil
+	}
+
+	for _, name := range names {
+		if r.config.Snapshots[name].Released == "" {
+			return nil, fmt.Errorf("%w: snapshot %q has no released field, list snapshots in time order", ErrNoReleaseOrder, name)
+		}
+	}
+
+	return names, nil
+}
+
+// Get returns Searcher for snapshot, default snapshot is used for empty name.
+func (r *Registry) Get(ctx context.Context, name string) (*search.Searcher, error) {
+	if name == "" {
+		name = r.config.Default
+	}
+
+	e, ok := r.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSnapshot, name)
+	}
+
+	searcher, err := r.searcher(ctx, name, e)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, evicted := range r.use(name) {
+		r.evict(evicted)
+	}
+
+	return searcher, nil
+}
+
+func (r *Registry) searcher(ctx context.Context, name string, e *entry) (*search.Searcher, error) {
+	// other snapshots are not blocked while this one is loading
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.searcher != nil {
+		return e.searcher, nil
+	}
+
+	searcher, err := r.load(ctx, r.config.Snapshots[name])
+	if err != nil {
+		return nil, fmt.Errorf("error loading snapshot %q: %w", name, err)
+	}
+
+	e.searcher = searcher
+
+	return searcher, nil
+}
+
+// use marks snapshot as the most recently used one and returns snapshots over MaxLoaded limit.
+func (r *Registry) use(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recent = slices.DeleteFunc(r.recent, func(recent string) bool { return recent == name })
+	r.recent = append(r.recent, name)
+
+	if r.config.MaxLoaded == 0 || len(r.recent) <= r.config.MaxLoaded {
+		return nil
+	}
+
+	over := len(r.recent) - r.config.MaxLoaded
+	evicted := slices.Clone(r.recent[:over])
+	r.recent = slices.Delete(r.recent, 0, over)
+
+	return evicted
+}
+
+// evict drops Searcher of snapshot unless it was used again after it was selected for eviction.
+// Searchers in use by requests stay valid, they are released by GC when requests are done.
+func (r *Registry) evict(name string) {
+	e := r.entries[name]
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !slices.Contains(r.recent, name) {
+		e.searcher = nil
+	}
+}
+
+func (r *Registry) load(ctx context.Context, snapshot Snapshot) (*search.Searcher, error) {
+	idx, err := loadIndex(snapshot.Offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := r.config.Folders.withDefaults()
+	// getters are keyed by full location of the folder, not by folder name
+	getters := make(map[string]access.Getter)
+
+	for _, folder := range []string{folders.Edges, folders.EdgesReversed, folders.Vertices, folders.Ranks} {
+		location := folderLocation(snapshot.Location, folder)
+		if _, ok := getters[location]; ok {
+			continue
+		}
+
+		getter, err := r.newGetter(ctx, snapshot.Location, folder)
+		if err != nil {
+			return nil, err
+		}
+
+		getters[location] = getter
+	}
+
+	outGetter := getters[folderLocation(snapshot.Location, folders.Edges)]
+	inGetter := getters[folderLocation(snapshot.Location, folders.EdgesReversed)]
+	verticesGetter := getters[folderLocation(snapshot.Location, folders.Vertices)]
+	ranksGetter := getters[folderLocation(snapshot.Location, folders.Ranks)]
+
+	// ranks are never compressed, they are addressed by vertice id
+	if idx.manifest.Compression == vertices.CompressionBlocks {
+		outGetter = blocks.NewGetter(outGetter, idx.blocks.out)
+		inGetter = blocks.NewGetter(inGetter, idx.blocks.in)
+		verticesGetter = blocks.NewGetter(verticesGetter, idx.blocks.vertices)
+	}
+
+	format := edges.Format(idx.manifest.EdgesFormat)
+	edgesOpts := []edges.Option{edges.WithMaxSize(r.limits.MaxResults), edges.WithConcurrency(r.limits.Concurrency)}
+
+	out, err := edges.NewFormatEdges(outGetter, idx.out, format, edgesOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := edges.NewFormatEdges(inGetter, idx.in, format, edgesOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vertices.NewGraphVertices(verticesGetter, idx.vertices, idx.manifest.Graph,
+		vertices.WithConcurrency(r.limits.Concurrency))
+	if err != nil {
+		return nil, err
+	}
+
+	searcher := search.NewSearcher(v, out, in, search.WithLimits(r.limits))
+
+	for _, name := range snapshot.Ranks {
+		searcher.AddRanks(name, ranks.NewTable(ranksGetter, name, ranks.WithConcurrency(r.limits.Concurrency)))
+	}
+
+	return searcher, nil
+}
+
+// folderLocation returns full location of folder, s3://bucket/prefix/folder or local path.
+func folderLocation(location string, folder string) string {
+	return strings.TrimSuffix(location, "/") + "/" + folder
+}
+
+// index is offsets and manifest of a snapshot.
+type index struct {
+	out      edges.Offsets
+	in       edges.Offsets
+	vertices vertices.Offsets
+	manifest *vertices.Manifest
+	// blocks are loaded for compressed data only
+	blocks struct {
+		out      *blocks.Index
+		in       *blocks.Index
+		vertices *blocks.Index
+	}
+}
+
+// loadIndex reads offsets from folder, embedded offsets are used when folder is empty.
+func loadIndex(folder string) (*index, error) {
+	if folder == "" {
+		return embeddedIndex()
+	}
+
+	idx := &index{}
+
+	err := idx.out.Load(path.Join(folder, offsets.EdgesOffsetsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	err = idx.in.Load(path.Join(folder, offsets.EdgesReversedOffsetFile))
+	if err != nil {
+		return nil, err
+	}
+
+	err = idx.vertices.Load(path.Join(folder, offsets.VerticesOffsetsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	idx.manifest, err = vertices.LoadManifest(path.Join(folder, offsets.ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if idx.manifest.Compression == vertices.CompressionBlocks {
+		err = idx.loadBlocks(folder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return idx, nil
+}
+
+func (idx *index) loadBlocks(folder string) error {
+	idx.blocks.out = &blocks.Index{}
+
+	err := idx.blocks.out.Load(path.Join(folder, offsets.EdgesBlocksFile))
+	if err != nil {
+		return err
+	}
+
+	idx.blocks.in = &blocks.Index{}
+
+	err = idx.blocks.in.Load(path.Join(folder, offsets.EdgesReversedBlocksFile))
+	if err != nil {
+		return err
+	}
+
+	idx.blocks.vertices = &blocks.Index{}
+
+	return idx.blocks.vertices.Load(path.Join(folder, offsets.VerticesBlocksFile))
+}
+
+func embeddedIndex() (*index, error) {
+	out, err := edges.NewOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := edges.NewOffsetsReversed()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vertices.NewOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := vertices.NewManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Compression != "" {
+		return nil, fmt.Errorf("%s compression needs offsets folder, block index is not embedded", manifest.Compression)
+	}
+
+	return &index{out: *out, in: *in, vertices: *v, manifest: manifest}, nil
+}
@@ -0,0 +1,376 @@
+package snapshots_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/access"
+	"github.com/dharnitski/cc-hosts/access/blocks"
+	"github.com/dharnitski/cc-hosts/edges"
+	"github.com/dharnitski/cc-hosts/offsets"
+	"github.com/dharnitski/cc-hosts/snapshots"
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFile = "part-00000.txt"
+
+// newTestSnapshot writes a.com -> b.com graph with offsets into temporary folder.
+func newTestSnapshot(t *testing.T) snapshots.Snapshot {
+	t.Helper()
+
+	return newTestLinkSnapshot(t, "com.a", "com.b")
+}
+
+// newTestLinkSnapshot writes graph with one link between two reversed domains, domains have to be sorted.
+func newTestLinkSnapshot(t *testing.T, first string, second string) snapshots.Snapshot {
+	t.Helper()
+
+	root := t.TempDir()
+	offsetsFolder := filepath.Join(root, offsets.Folder)
+	require.NoError(t, os.MkdirAll(offsetsFolder, 0o755))
+
+	vSize := writeFile(t, filepath.Join(root, vertices.Folder), "0\t"+first+"\n1\t"+second+"\n")
+	vOffsets := vertices.Offsets{}
+	vOffsets.Append([]vertices.Offset{
+		vertices.NewOffset(0, first, 0, testFile),
+		vertices.NewOffset(vSize, second, 1, testFile),
+	})
+	require.NoError(t, vOffsets.Save(filepath.Join(offsetsFolder, offsets.VerticesOffsetsFile)))
+
+	for folder, offsetsFile := range map[string]string{
+		edges.EdgesFolder:         offsets.EdgesOffsetsFile,
+		edges.EdgesReversedFolder: offsets.EdgesReversedOffsetFile,
+	} {
+		from, to := "0", "1"
+		if folder == edges.EdgesReversedFolder {
+			from, to = to, from
+		}
+
+		size := writeFile(t, filepath.Join(root, folder), from+"\t"+to+"\n")
+		eOffsets := edges.Offsets{}
+		eOffsets.Append([]edges.Offset{edges.NewOffset(0, from, testFile), edges.NewOffset(size, from, testFile)})
+		require.NoError(t, eOffsets.Save(filepath.Join(offsetsFolder, offsetsFile)))
+	}
+
+	return snapshots.Snapshot{Location: root, Offsets: offsetsFolder}
+}
+
+func writeFile(t *testing.T, folder string, content string) int {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, testFile), []byte(content), 0o644))
+
+	return len(content)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := snapshots.LoadConfig(strings.NewReader(`{
+		"default": "cc-main-2024-oct-nov-dec",
+		"snapshots": {
+			"cc-main-2024-oct-nov-dec": {"location": "s3://bucket/2024", "offsets": "offsets/2024", "ranks": ["indegree"]},
+			"cc-main-2025-jan-feb-mar": {"location": "data"}
+		}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "cc-main-2024-oct-nov-dec", cfg.Default)
+	assert.Equal(t, snapshots.Snapshot{Location: "s3://bucket/2024", Offsets: "offsets/2024", Ranks: []string{"indegree"}},
+		cfg.Snapshots["cc-main-2024-oct-nov-dec"])
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		`{`,
+		`{"default": "a"}`,
+		`{"default": "b", "snapshots": {"a": {"location": "data"}}}`,
+		`{"default": "a", "snapshots": {"a": {}}}`,
+		`{"default": "a", "snapshots": {"a": {"location": "data", "released": "2024-oct"}}}`,
+	}
+
+	for _, data := range tests {
+		_, err := snapshots.LoadConfig(strings.NewReader(data))
+		require.Error(t, err, data)
+	}
+}
+
+func TestRegistry_Get(t *testing.T) {
+	t.Parallel()
+
+	cfg := snapshots.Config{
+		Default: "new",
+		Snapshots: map[string]snapshots.Snapshot{
+			"new": newTestSnapshot(t),
+			"old": newTestSnapshot(t),
+		},
+	}
+
+	var calls atomic.Int32
+
+	newGetter := func(ctx context.Context, location string, folder string) (access.Getter, error) {
+		calls.Add(1)
+
+		return snapshots.NewGetter(ctx, location, folder)
+	}
+
+	registry, err := snapshots.NewRegistry(cfg, newGetter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new", "old"}, registry.Names())
+	assert.Equal(t, "new", registry.Default())
+	// nothing is loaded before the first request
+	assert.Equal(t, int32(0), calls.Load())
+
+	searcher, err := registry.Get(t.Context(), "")
+	require.NoError(t, err)
+
+	result, err := searcher.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+
+	loaded := calls.Load()
+
+	cached, err := registry.Get(t.Context(), "new")
+	require.NoError(t, err)
+	assert.Same(t, searcher, cached)
+	assert.Equal(t, loaded, calls.Load())
+
+	old, err := registry.Get(t.Context(), "old")
+	require.NoError(t, err)
+	assert.NotSame(t, searcher, old)
+
+	_, err = registry.Get(t.Context(), "missed")
+	require.ErrorIs(t, err, snapshots.ErrUnknownSnapshot)
+}
+
+// compressSnapshot replaces data files of snapshot with block compressed files.
+func compressSnapshot(t *testing.T, snapshot snapshots.Snapshot) {
+	t.Helper()
+
+	for folder, blocksFile := range map[string]string{
+		vertices.Folder:           offsets.VerticesBlocksFile,
+		edges.EdgesFolder:         offsets.EdgesBlocksFile,
+		edges.EdgesReversedFolder: offsets.EdgesReversedBlocksFile,
+	} {
+		fileName := filepath.Join(snapshot.Location, folder, testFile)
+		data, err := os.ReadFile(fileName)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(fileName))
+
+		out, err := os.Create(fileName + blocks.Ext)
+		require.NoError(t, err)
+
+		writer := blocks.NewWriter(out)
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		require.NoError(t, out.Close())
+
+		index := blocks.Index{}
+		index.Append(testFile, writer.Blocks())
+		require.NoError(t, index.Save(filepath.Join(snapshot.Offsets, blocksFile)))
+	}
+
+	manifest := vertices.Manifest{Graph: vertices.GraphHost, Compression: vertices.CompressionBlocks}
+	require.NoError(t, manifest.Save(filepath.Join(snapshot.Offsets, offsets.ManifestFile)))
+}
+
+func TestRegistry_Blocks(t *testing.T) {
+	t.Parallel()
+
+	snapshot := newTestSnapshot(t)
+	compressSnapshot(t, snapshot)
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": snapshot},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	searcher, err := registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+
+	result, err := searcher.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+
+	result, err = searcher.GetTargets(t.Context(), "b.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.com"}, result.In)
+
+	// block index is required for compressed data
+	require.NoError(t, os.Remove(filepath.Join(snapshot.Offsets, offsets.EdgesBlocksFile)))
+
+	registry, err = snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": snapshot},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	_, err = registry.Get(t.Context(), "a")
+	require.Error(t, err)
+}
+
+func TestRegistry_GetLoadError(t *testing.T) {
+	t.Parallel()
+
+	snapshot := newTestSnapshot(t)
+	snapshot.Offsets = filepath.Join(t.TempDir(), "missed")
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": snapshot},
+	}, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	_, err = registry.Get(t.Context(), "a")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, snapshots.ErrUnknownSnapshot)
+}
+
+func TestRegistry_MaxLoaded(t *testing.T) {
+	t.Parallel()
+
+	cfg := snapshots.Config{
+		Default: "a",
+		Snapshots: map[string]snapshots.Snapshot{
+			"a": newTestSnapshot(t),
+			"b": newTestSnapshot(t),
+			"c": newTestSnapshot(t),
+		},
+		MaxLoaded: 2,
+	}
+
+	registry, err := snapshots.NewRegistry(cfg, snapshots.NewGetter)
+	require.NoError(t, err)
+
+	a, err := registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+
+	b, err := registry.Get(t.Context(), "b")
+	require.NoError(t, err)
+
+	// a is used again, so b is the least recently used one
+	cached, err := registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+	assert.Same(t, a, cached)
+
+	_, err = registry.Get(t.Context(), "c")
+	require.NoError(t, err)
+
+	cached, err = registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+	assert.Same(t, a, cached)
+
+	reloaded, err := registry.Get(t.Context(), "b")
+	require.NoError(t, err)
+	assert.NotSame(t, b, reloaded)
+}
+
+func TestRegistry_Folders(t *testing.T) {
+	t.Parallel()
+
+	snapshot := newTestSnapshot(t)
+	require.NoError(t, os.Rename(filepath.Join(snapshot.Location, vertices.Folder), filepath.Join(snapshot.Location, "v")))
+
+	var folders []string
+
+	newGetter := func(ctx context.Context, location string, folder string) (access.Getter, error) {
+		folders = append(folders, folder)
+
+		return snapshots.NewGetter(ctx, location, folder)
+	}
+
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default:   "a",
+		Snapshots: map[string]snapshots.Snapshot{"a": snapshot},
+		Folders:   snapshots.Folders{Vertices: "v"},
+	}, newGetter)
+	require.NoError(t, err)
+
+	searcher, err := registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"v", edges.EdgesFolder, edges.EdgesReversedFolder, "ranks"}, folders)
+
+	result, err := searcher.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+}
+
+func TestRegistry_SameFolderNames(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		locations []string
+	)
+
+	newGetter := func(ctx context.Context, location string, folder string) (access.Getter, error) {
+		mu.Lock()
+		locations = append(locations, location+"/"+folder)
+		mu.Unlock()
+
+		return snapshots.NewGetter(ctx, location, folder)
+	}
+
+	// folder names are the same in both locations
+	registry, err := snapshots.NewRegistry(snapshots.Config{
+		Default: "a",
+		Snapshots: map[string]snapshots.Snapshot{
+			"a": newTestSnapshot(t),
+			"b": newTestLinkSnapshot(t, "com.b", "com.c"),
+		},
+	}, newGetter)
+	require.NoError(t, err)
+
+	first, err := registry.Get(t.Context(), "a")
+	require.NoError(t, err)
+
+	result, err := first.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"b.com"}, result.Out)
+
+	second, err := registry.Get(t.Context(), "b")
+	require.NoError(t, err)
+
+	result, err = second.GetTargets(t.Context(), "a.com")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	result, err = second.GetTargets(t.Context(), "b.com")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"c.com"}, result.Out)
+
+	// every location gets its own getters
+	slices.Sort(locations)
+	assert.Len(t, slices.Compact(locations), 8)
+}
+
+func TestLoadConfig_FoldersAndMaxLoaded(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := snapshots.LoadConfig(strings.NewReader(`{
+		"default": "a",
+		"snapshots": {"a": {"location": "data"}},
+		"folders": {"edges_reversed": "reversed"},
+		"max_loaded": 1
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, snapshots.Folders{EdgesReversed: "reversed"}, cfg.Folders)
+	assert.Equal(t, 1, cfg.MaxLoaded)
+
+	_, err = snapshots.LoadConfig(strings.NewReader(`{"default": "a", "snapshots": {"a": {"location": "data"}}, "max_loaded": -1}`))
+	require.Error(t, err)
+}
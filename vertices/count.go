@@ -0,0 +1,76 @@
+package vertices
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/access/file"
+)
+
+// Count returns number of vertices in vertices folder, ids start from 0.
+// Files continue each other, so only the last not empty file is read.
+func Count(folder string) (int, error) {
+	// names are sorted by filename
+	names, err := file.ReadDir(folder)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(names) - 1; i >= 0; i-- {
+		filePath := filepath.Join(folder, names[i])
+
+		line, err := readLastLine(filePath)
+		if err != nil {
+			return 0, err
+		}
+
+		if line == "" {
+			continue
+		}
+
+		// ID is the first column in every graph
+		sid, _, _ := strings.Cut(line, "\t")
+
+		id, err := strconv.Atoi(sid)
+		if err != nil || id < 0 {
+			return 0, fmt.Errorf("invalid ID in file %q: %q", filePath, sid)
+		}
+
+		return id + 1, nil
+	}
+
+	return 0, errors.New("no vertices found")
+}
+
+func readLastLine(filePath string) (string, error) {
+	reader, err := file.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("error closing file %s: %v", filePath, err)
+		}
+	}()
+
+	last := ""
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			last = line
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading file %q: %w", filePath, err)
+	}
+
+	return last, nil
+}
@@ -0,0 +1,27 @@
+package vertices_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-0.txt"), []byte("0\tcom.a\n1\tcom.b\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-1.txt"), []byte("2\tcom.c\t3\n3\tcom.d\t1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "part-2.txt"), []byte(""), 0o644))
+
+	count, err := vertices.Count(folder)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	_, err = vertices.Count(t.TempDir())
+	require.Error(t, err)
+}
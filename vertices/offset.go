@@ -3,11 +3,13 @@ package vertices
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -50,6 +52,10 @@ func (v Offset) Domain() string {
 	return v.domain
 }
 
+func (v Offset) ID() int {
+	return v.id
+}
+
 func loadOffset(line string) (Offset, error) {
 	parts := strings.Split(line, "\t")
 	if len(parts) != 4 {
@@ -288,3 +294,31 @@ func (v *Offsets) FindForID(id int) (Offset, Offset) {
 	// left is the smallest index with id > target
 	return items[right], items[left]
 }
+
+// around returns offsets of the chunk with the line of offset.
+// The last offset in file points to the end of file and its line is in the previous chunk.
+func (v *Offsets) around(offset Offset) (Offset, Offset) {
+	items := v.offsets
+
+	i, ok := slices.BinarySearchFunc(items, offset.id, func(item Offset, id int) int {
+		return cmp.Compare(item.id, id)
+	})
+	if !ok {
+		return offset, offset
+	}
+
+	// the same id is in two offsets when its line starts the last chunk of file
+	for i+1 < len(items) && items[i] != offset && items[i+1].id == offset.id {
+		i++
+	}
+
+	if i+1 < len(items) && items[i+1].file == offset.file {
+		return offset, items[i+1]
+	}
+
+	if i > 0 && items[i-1].file == offset.file {
+		return items[i-1], offset
+	}
+
+	return offset, offset
+}
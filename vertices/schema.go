@@ -0,0 +1,164 @@
+package vertices
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dharnitski/cc-hosts/offsets"
+)
+
+// Graph is granularity of Common Crawl web graph.
+type Graph string
+
+const (
+	// GraphHost has vertice for every host, sample: com.example.www
+	GraphHost Graph = "host"
+	// GraphDomain has vertice for every registered domain, sample: com.example
+	GraphDomain Graph = "domain"
+)
+
+// Schema describes columns of vertices file.
+// Every line starts with id and reversed domain, Extra columns follow them.
+type Schema struct {
+	Extra []string
+}
+
+// Schema returns vertices file schema for the graph.
+// Host graph: id, reversed host.
+// Domain graph: id, reversed domain, number of hosts in the domain.
+func (g Graph) Schema() (Schema, error) {
+	switch g {
+	case GraphHost:
+		return Schema{}, nil
+	case GraphDomain:
+		return Schema{Extra: []string{"num_hosts"}}, nil
+	default:
+		return Schema{}, fmt.Errorf("unknown graph: %q", g)
+	}
+}
+
+// DetectGraph returns graph by number of columns in vertices line.
+func DetectGraph(line string) (Graph, error) {
+	columns := len(strings.Split(line, "\t"))
+
+	for _, graph := range []Graph{GraphHost, GraphDomain} {
+		schema, _ := graph.Schema()
+		if schema.Columns() == columns {
+			return graph, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown vertices format: %s, %d parts", line, columns)
+}
+
+// Columns returns number of columns in vertices line.
+func (s Schema) Columns() int {
+	return 2 + len(s.Extra)
+}
+
+// Load parses vertices line, line has to have all schema columns.
+func (s Schema) Load(line string) (*Vertice, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) != s.Columns() {
+		return nil, fmt.Errorf("invalid line: %s, %d parts", line, len(parts))
+	}
+
+	vertice := &Vertice{id: parts[0], domain: parts[1]}
+	if len(parts) > 2 {
+		vertice.extra = parts[2:]
+	}
+
+	return vertice, nil
+}
+
+// Column returns value of extra column by name.
+func (s Schema) Column(vertice *Vertice, name string) (string, bool) {
+	i := slices.Index(s.Extra, name)
+	if i < 0 || i >= len(vertice.extra) {
+		return "", false
+	}
+
+	return vertice.extra[i], true
+}
+
+// CompressionBlocks is data saved as independently compressed blocks with block index next to offsets.
+const CompressionBlocks = "blocks"
+
+// Manifest describes data indexed into offsets.
+type Manifest struct {
+	Graph Graph `json:"graph"`
+	// Compression of vertices and edges files, they are not compressed when empty
+	Compression string `json:"compression,omitempty"`
+	// EdgesFormat is layout of edges files, TSV when empty
+	EdgesFormat string `json:"edges_format,omitempty"`
+}
+
+// NewManifest returns embedded manifest, host graph is used when manifest is empty.
+func NewManifest() (*Manifest, error) {
+	return parseManifest(offsets.Manifest)
+}
+
+// LoadManifest reads manifest from file, host graph is used when file does not exist.
+func LoadManifest(fileName string) (*Manifest, error) {
+	data, err := os.ReadFile(fileName) //nolint:gosec
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading file %q: %w", fileName, err)
+	}
+
+	return parseManifest(data)
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	manifest := &Manifest{Graph: GraphHost}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return manifest, nil
+	}
+
+	err := json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+
+	_, err = manifest.Graph.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Compression != "" && manifest.Compression != CompressionBlocks {
+		return nil, fmt.Errorf("unknown compression: %q", manifest.Compression)
+	}
+
+	return manifest, nil
+}
+
+func (m *Manifest) Save(fileName string) error {
+	file, err := os.Create(fileName) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("error creating file %q: %w", fileName, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("error closing file %s: %v", fileName, err)
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(m)
+	if err != nil {
+		return fmt.Errorf("error writing to file %q: %w", fileName, err)
+	}
+
+	return nil
+}
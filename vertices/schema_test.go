@@ -0,0 +1,87 @@
+package vertices_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharnitski/cc-hosts/vertices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_Load(t *testing.T) {
+	t.Parallel()
+
+	schema, err := vertices.GraphDomain.Schema()
+	require.NoError(t, err)
+
+	vertice, err := schema.Load("42\tcom.example\t17")
+	require.NoError(t, err)
+	assert.Equal(t, "42", vertice.ID())
+	assert.Equal(t, "com.example", vertice.Domain())
+
+	hosts, ok := schema.Column(vertice, "num_hosts")
+	assert.True(t, ok)
+	assert.Equal(t, "17", hosts)
+
+	_, ok = schema.Column(vertice, "unknown")
+	assert.False(t, ok)
+
+	_, err = schema.Load("42\tcom.example")
+	require.Error(t, err)
+
+	_, err = vertices.LoadVertice("42\tcom.example\t17")
+	require.Error(t, err)
+}
+
+func TestDetectGraph(t *testing.T) {
+	t.Parallel()
+
+	graph, err := vertices.DetectGraph("42\tcom.example.www")
+	require.NoError(t, err)
+	assert.Equal(t, vertices.GraphHost, graph)
+
+	graph, err = vertices.DetectGraph("42\tcom.example\t17")
+	require.NoError(t, err)
+	assert.Equal(t, vertices.GraphDomain, graph)
+
+	_, err = vertices.DetectGraph("42")
+	require.Error(t, err)
+
+	_, err = vertices.Graph("page").Schema()
+	require.Error(t, err)
+}
+
+func TestNewManifest(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := vertices.NewManifest()
+	require.NoError(t, err)
+
+	_, err = manifest.Graph.Schema()
+	require.NoError(t, err)
+}
+
+func TestLoadManifest(t *testing.T) {
+	t.Parallel()
+
+	folder := t.TempDir()
+	fileName := filepath.Join(folder, "manifest.json")
+
+	manifest, err := vertices.LoadManifest(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, &vertices.Manifest{Graph: vertices.GraphHost}, manifest)
+
+	saved := &vertices.Manifest{Graph: vertices.GraphDomain, Compression: vertices.CompressionBlocks, EdgesFormat: "adjacency"}
+	require.NoError(t, saved.Save(fileName))
+
+	manifest, err = vertices.LoadManifest(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, saved, manifest)
+
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"graph": "host", "compression": "zip"}`), 0o644))
+
+	_, err = vertices.LoadManifest(fileName)
+	require.Error(t, err)
+}
@@ -13,6 +13,7 @@ import (
 )
 
 const (
+	// Concurrency is default max number of parallel reads
 	Concurrency = 100
 	Folder      = "vertices"
 )
@@ -23,6 +24,13 @@ type Vertice struct {
 	// domain name in reverse domain format
 	// sample: com.example
 	domain string
+	// columns after domain defined by Schema, nil for host graph
+	extra []string
+}
+
+// Extra returns values of Schema.Extra columns.
+func (v *Vertice) Extra() []string {
+	return v.extra
 }
 
 func (v *Vertice) ID() string {
@@ -50,26 +58,69 @@ func (v *Vertice) ReversedDomain() string {
 	return ReverseDomain(v.domain)
 }
 
+// LoadVertice parses host graph vertices line.
 func LoadVertice(line string) (*Vertice, error) {
-	parts := strings.Split(line, "\t")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid line: %s, %d parts", line, len(parts))
-	}
-
-	return &Vertice{id: parts[0], domain: parts[1]}, nil
+	return Schema{}.Load(line)
 }
 
 type Vertices struct {
 	// offsets to find vertices in vertices files
 	offsets Offsets
 	getter  access.Getter
+	graph   Graph
+	schema  Schema
+	// max number of parallel reads
+	concurrency int
+}
+
+// Option configures Vertices.
+type Option func(v *Vertices)
+
+// WithConcurrency sets max number of parallel reads, Concurrency by default.
+func WithConcurrency(concurrency int) Option {
+	return func(v *Vertices) {
+		v.concurrency = concurrency
+	}
 }
 
-func NewVertices(getter access.Getter, offsets Offsets) *Vertices {
-	return &Vertices{
-		offsets: offsets,
-		getter:  getter,
+// NewVertices creates Vertices for host graph.
+func NewVertices(getter access.Getter, offsets Offsets, opts ...Option) *Vertices {
+	v := &Vertices{
+		offsets:     offsets,
+		getter:      getter,
+		graph:       GraphHost,
+		concurrency: Concurrency,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// NewGraphVertices creates Vertices for the graph with graph vertices schema.
+func NewGraphVertices(getter access.Getter, offsets Offsets, graph Graph, opts ...Option) (*Vertices, error) {
+	schema, err := graph.Schema()
+	if err != nil {
+		return nil, err
 	}
+
+	v := NewVertices(getter, offsets, opts...)
+	v.graph = graph
+	v.schema = schema
+
+	return v, nil
+}
+
+// Graph returns graph of vertices.
+func (v *Vertices) Graph() Graph {
+	return v.graph
+}
+
+// Schema returns vertices file schema.
+func (v *Vertices) Schema() Schema {
+	return v.schema
 }
 
 type searchKey string
@@ -87,34 +138,99 @@ func (v *Vertices) GetByID(ctx context.Context, id string) (*Vertice, error) {
 	return v.get(ctx, id, searchKeyID)
 }
 
+// GetByIDs returns vertices for ids, missed ids are skipped and order is not preserved.
+// IDs from the same chunk are resolved with one read.
 func (v *Vertices) GetByIDs(ctx context.Context, ids []string) ([]Vertice, error) {
+	found, err := v.getMany(ctx, ids, searchKeyID)
+
+	results := []Vertice{}
+
+	for _, vertice := range found {
+		if vertice != nil {
+			results = append(results, *vertice)
+		}
+	}
+
+	return results, err
+}
+
+// GetByDomains returns vertices for reversed domains, result is aligned with domains
+// and has nil for missed domains. Domains from the same chunk are resolved with one read.
+func (v *Vertices) GetByDomains(ctx context.Context, domains []string) ([]*Vertice, error) {
+	return v.getMany(ctx, domains, searchKeyDomain)
+}
+
+// chunk is a range of vertices file to read.
+type chunk struct {
+	from Offset
+	to   Offset
+}
+
+func (v *Vertices) getMany(ctx context.Context, keys []string, searchSwitch searchKey) ([]*Vertice, error) {
+	results := make([]*Vertice, len(keys))
+	// keys grouped by chunk to read every chunk only once
+	groups := make(map[chunk][]int)
+	errs := []error{}
+
+	for i, key := range keys {
+		from, to, ok, err := v.find(key, searchSwitch)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		// if we lucky and Vertice is in offset
+		if from == to {
+			results[i] = &Vertice{id: strconv.Itoa(from.id), domain: from.domain}
+
+			continue
+		}
+
+		c := chunk{from: from, to: to}
+		groups[c] = append(groups[c], i)
+	}
+
 	type result struct {
-		vertice *Vertice
-		err     error
-		index   int
+		indexes  []int
+		vertices map[string]*Vertice
+		err      error
 	}
 
-	resultChan := make(chan result, len(ids))
+	resultChan := make(chan result, len(groups))
 
 	var wg sync.WaitGroup
 
-	semaphore := make(chan struct{}, Concurrency)
+	semaphore := make(chan struct{}, v.concurrency)
 
-	for i, id := range ids {
+	for c, indexes := range groups {
 		wg.Add(1)
+
 		semaphore <- struct{}{}
 
-		go func(idx int, id string) {
+		go func(c chunk, indexes []int) {
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			vertice, err := v.GetByID(ctx, id)
-			resultChan <- result{
-				vertice: vertice,
-				err:     err,
-				index:   idx,
+			wanted := make(map[string]struct{}, len(indexes))
+			for _, i := range indexes {
+				wanted[keys[i]] = struct{}{}
+			}
+
+			buffer, err := v.getter.Get(ctx, c.from.file, c.from.offset, c.to.offset-c.from.offset)
+			if err != nil {
+				resultChan <- result{indexes: indexes, err: err}
+
+				return
 			}
-		}(i, id)
+
+			found, err := findVertices(buffer, wanted, searchSwitch, v.schema)
+			resultChan <- result{indexes: indexes, vertices: found, err: err}
+		}(c, indexes)
 	}
 
 	// Close the channel when all goroutines are done
@@ -124,17 +240,15 @@ func (v *Vertices) GetByIDs(ctx context.Context, ids []string) ([]Vertice, error
 		close(resultChan)
 	}()
 
-	// Prepare results in order
-	results := []Vertice{}
-	errs := []error{}
-
 	for res := range resultChan {
 		if res.err != nil {
 			errs = append(errs, res.err)
+
+			continue
 		}
 
-		if res.vertice != nil {
-			results = append(results, *res.vertice)
+		for _, i := range res.indexes {
+			results[i] = res.vertices[keys[i]]
 		}
 	}
 
@@ -145,7 +259,9 @@ func (v *Vertices) GetByIDs(ctx context.Context, ids []string) ([]Vertice, error
 	return results, nil
 }
 
-func (v *Vertices) get(ctx context.Context, key string, searchSwitch searchKey) (*Vertice, error) {
+// find returns from and to offsets to search key.
+// ok is false when key is outside of indexed ranges and cannot be in vertices files.
+func (v *Vertices) find(key string, searchSwitch searchKey) (Offset, Offset, bool, error) {
 	var from, to Offset
 
 	switch searchSwitch {
@@ -154,11 +270,30 @@ func (v *Vertices) get(ctx context.Context, key string, searchSwitch searchKey)
 	case searchKeyID:
 		id, err := strconv.Atoi(key)
 		if err != nil {
-			return nil, fmt.Errorf("invalid ID: %s", key)
+			return Offset{}, Offset{}, false, fmt.Errorf("invalid ID: %s", key)
 		}
 
 		from, to = v.offsets.FindForID(id)
 	}
+
+	// before first or after last offset or between files
+	if from.file == "" || from.file != to.file {
+		return Offset{}, Offset{}, false, nil
+	}
+
+	// offsets keep only id and domain, extra columns have to be read from file
+	if from == to && len(v.schema.Extra) > 0 {
+		from, to = v.offsets.around(from)
+	}
+
+	return from, to, true, nil
+}
+
+func (v *Vertices) get(ctx context.Context, key string, searchSwitch searchKey) (*Vertice, error) {
+	from, to, ok, err := v.find(key, searchSwitch)
+	if err != nil || !ok {
+		return nil, err
+	}
 	// if we lucky and Vertice is in offset
 	if from.domain == to.domain &&
 		from.id == to.id && from.offset == to.offset {
@@ -170,17 +305,17 @@ func (v *Vertices) get(ctx context.Context, key string, searchSwitch searchKey)
 		return nil, err
 	}
 
-	return findVertice(buffer, key, searchSwitch)
+	return findVertice(buffer, key, searchSwitch, v.schema)
 }
 
-func findVertice(buffer []byte, key string, searchSwitch searchKey) (*Vertice, error) {
+func findVertice(buffer []byte, key string, searchSwitch searchKey, schema Schema) (*Vertice, error) {
 	reader := bytes.NewReader(buffer)
 
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		vertice, err := LoadVertice(line)
+		vertice, err := schema.Load(line)
 		if err != nil {
 			return nil, err
 		}
@@ -203,3 +338,113 @@ func findVertice(buffer []byte, key string, searchSwitch searchKey) (*Vertice, e
 
 	return nil, nil //nolint:nilnil
 }
+
+// findVertices returns vertices for all wanted keys found in buffer.
+func findVertices(buffer []byte, wanted map[string]struct{}, searchSwitch searchKey, schema Schema) (map[string]*Vertice, error) {
+	results := make(map[string]*Vertice, len(wanted))
+	reader := bytes.NewReader(buffer)
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() && len(results) < len(wanted) {
+		vertice, err := schema.Load(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		key := vertice.id
+		if searchSwitch == searchKeyDomain {
+			key = vertice.domain
+		}
+
+		if _, ok := wanted[key]; ok {
+			results[key] = vertice
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return results, nil
+}
+
+// IDRange is half open range of vertice ids [From, To).
+type IDRange struct {
+	From int
+	To   int
+}
+
+func (r IDRange) Contains(id int) bool {
+	return id >= r.From && id < r.To
+}
+
+// PrefixRanges returns id ranges for the domain and all its subdomains.
+// Domain is in reverse domain format, sample: com.example or gov.
+// Vertices are sorted by domain and ids grow with domain, so every range is contiguous.
+func (v *Vertices) PrefixRanges(ctx context.Context, domain string) ([]IDRange, error) {
+	// domain itself and subdomains are not next to each other,
+	// com.example-shop is between com.example and com.example.www
+	bounds := []string{domain, domain + "\x00", domain + ".", domain + "/"}
+	ids := make([]int, 0, len(bounds))
+
+	for _, bound := range bounds {
+		id, err := v.lowerBound(ctx, bound)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	results := make([]IDRange, 0, 2)
+
+	for i := 0; i < len(ids); i += 2 {
+		if ids[i] < ids[i+1] {
+			results = append(results, IDRange{From: ids[i], To: ids[i+1]})
+		}
+	}
+
+	return results, nil
+}
+
+// lowerBound returns id of the first vertice with domain greater or equal to domain.
+func (v *Vertices) lowerBound(ctx context.Context, domain string) (int, error) {
+	from, to := v.offsets.FindForDomain(domain)
+
+	switch {
+	case from.file == "" && to.file == "":
+		// no offsets
+		return 0, nil
+	case to.file == "":
+		// after the last vertice
+		return from.id + 1, nil
+	case from.file == "" || from.file != to.file:
+		// before the first vertice or between files
+		return to.id, nil
+	case from == to:
+		return from.id, nil
+	}
+
+	buffer, err := v.getter.Get(ctx, from.file, from.offset, to.offset-from.offset)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(buffer))
+	for scanner.Scan() {
+		vertice, err := v.schema.Load(scanner.Text())
+		if err != nil {
+			return 0, err
+		}
+
+		if vertice.domain >= domain {
+			return strconv.Atoi(vertice.id)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading file for key %s: %w", domain, err)
+	}
+
+	return to.id, nil
+}
@@ -1,6 +1,11 @@
 package vertices_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/dharnitski/cc-hosts/access/file"
@@ -104,3 +109,150 @@ func TestVerticesGetByIDs(t *testing.T) {
 	require.NotNil(t, vertices)
 	assert.Len(t, vertices, 4)
 }
+
+// newTestVertices writes domains into two files, vertice ID is the index in the list.
+func newTestVertices(t *testing.T, domains []string) *vertices.Vertices {
+	t.Helper()
+
+	return newGraphTestVertices(t, domains, vertices.GraphHost)
+}
+
+// newGraphTestVertices writes domains of the graph into two files.
+// Domain graph vertices have number of hosts equal to vertice ID + 1.
+func newGraphTestVertices(t *testing.T, domains []string, graph vertices.Graph) *vertices.Vertices {
+	t.Helper()
+
+	folder := t.TempDir()
+	offsets := vertices.Offsets{}
+	half := len(domains) / 2
+
+	for i, part := range [][]string{domains[:half], domains[half:]} {
+		fileName := fmt.Sprintf("part-%d.txt", i)
+		firstID := i * half
+		content := strings.Builder{}
+
+		for j, domain := range part {
+			if graph == vertices.GraphDomain {
+				content.WriteString(fmt.Sprintf("%d\t%s\t%d\n", firstID+j, domain, firstID+j+1))
+			} else {
+				content.WriteString(fmt.Sprintf("%d\t%s\n", firstID+j, domain))
+			}
+		}
+
+		require.NoError(t, os.WriteFile(filepath.Join(folder, fileName), []byte(content.String()), 0o644))
+		offsets.Append([]vertices.Offset{
+			vertices.NewOffset(0, part[0], firstID, fileName),
+			vertices.NewOffset(content.Len(), part[len(part)-1], firstID+len(part)-1, fileName),
+		})
+	}
+
+	v, err := vertices.NewGraphVertices(file.NewGetter(folder), offsets, graph)
+	require.NoError(t, err)
+
+	return v
+}
+
+func TestVertices_DomainGraph(t *testing.T) {
+	t.Parallel()
+
+	v := newGraphTestVertices(t, []string{"com.a", "com.b", "com.c", "com.d", "org.e", "org.f", "org.g", "org.h"}, vertices.GraphDomain)
+	assert.Equal(t, vertices.GraphDomain, v.Graph())
+
+	// first and last vertices of file are in offsets
+	for _, domain := range []string{"com.a", "com.c", "com.d", "org.e", "org.h"} {
+		vertice, err := v.GetByDomain(t.Context(), domain)
+		require.NoError(t, err)
+		require.NotNil(t, vertice, domain)
+
+		id, err := strconv.Atoi(vertice.ID())
+		require.NoError(t, err)
+
+		hosts, ok := v.Schema().Column(vertice, "num_hosts")
+		assert.True(t, ok, domain)
+		assert.Equal(t, strconv.Itoa(id+1), hosts, domain)
+	}
+
+	found, err := v.GetByIDs(t.Context(), []string{"0", "3", "5"})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+
+	for _, vertice := range found {
+		assert.Len(t, vertice.Extra(), 1)
+	}
+}
+
+func TestVerticesGetByDomains(t *testing.T) {
+	t.Parallel()
+
+	v := newTestVertices(t, []string{"com.a", "com.b", "com.c", "com.d", "org.e", "org.f", "org.g", "org.h"})
+
+	found, err := v.GetByDomains(t.Context(), []string{"org.g", "com.b", "aaa.before", "com.c", "com.zz", "zzz.after", "org.e", "com.c"})
+	require.NoError(t, err)
+	require.Len(t, found, 8)
+
+	ids := make([]string, 0, len(found))
+
+	for _, vertice := range found {
+		if vertice == nil {
+			ids = append(ids, "")
+
+			continue
+		}
+
+		ids = append(ids, vertice.ID())
+	}
+
+	assert.Equal(t, []string{"6", "1", "", "2", "", "", "4", "2"}, ids)
+}
+
+func TestVerticesGetByIDs_Grouped(t *testing.T) {
+	t.Parallel()
+
+	v := newTestVertices(t, []string{"com.a", "com.b", "com.c", "com.d", "org.e", "org.f", "org.g", "org.h"})
+
+	found, err := v.GetByIDs(t.Context(), []string{"2", "5", "6", "100"})
+	require.NoError(t, err)
+
+	domains := make([]string, 0, len(found))
+	for _, vertice := range found {
+		domains = append(domains, vertice.Domain())
+	}
+
+	assert.ElementsMatch(t, []string{"com.c", "org.f", "org.g"}, domains)
+
+	_, err = v.GetByIDs(t.Context(), []string{"x"})
+	require.Error(t, err)
+}
+
+func TestVerticesPrefixRanges(t *testing.T) {
+	t.Parallel()
+
+	v := newTestVertices(t, []string{
+		"com.example", "com.example-shop", "com.example.blog", "com.example.www", "com.examples",
+		"gov.nasa", "gov.nasa.www", "org.example",
+	})
+
+	tests := []struct {
+		prefix   string
+		expected []vertices.IDRange
+	}{
+		{prefix: "com.example", expected: []vertices.IDRange{{From: 0, To: 1}, {From: 2, To: 4}}},
+		{prefix: "com.example-shop", expected: []vertices.IDRange{{From: 1, To: 2}}},
+		{prefix: "gov", expected: []vertices.IDRange{{From: 5, To: 7}}},
+		{prefix: "com", expected: []vertices.IDRange{{From: 0, To: 5}}},
+		{prefix: "org.example", expected: []vertices.IDRange{{From: 7, To: 8}}},
+		{prefix: "aaa", expected: []vertices.IDRange{}},
+		{prefix: "net", expected: []vertices.IDRange{}},
+		{prefix: "zzz", expected: []vertices.IDRange{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			t.Parallel()
+
+			ranges, err := v.PrefixRanges(t.Context(), tt.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}